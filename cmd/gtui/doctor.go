@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gmail-tui/internal/app"
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+
+	"golang.org/x/oauth2"
+)
+
+// doctor collects the results of runDoctor's checks.
+type doctor struct {
+	w      io.Writer
+	failed int
+}
+
+// report prints one check result. A non-nil err marks the check as failed.
+func (d *doctor) report(check, detail string, err error) {
+	status := "ok  "
+	if err != nil {
+		status = "FAIL"
+		d.failed++
+		detail += ": " + err.Error()
+	}
+	fmt.Fprintf(d.w, "%s  %-12s %s\n", status, check, detail)
+}
+
+// warn prints a check result that is worth knowing but doesn't stop the app.
+func (d *doctor) warn(check, detail string) {
+	fmt.Fprintf(d.w, "warn  %-12s %s\n", check, detail)
+}
+
+// runDoctor checks where the app reads its credentials and keeps its token,
+// whether they are usable, and whether the Gmail API answers, printing one
+// line per check. It fails if any check fails, so it can be run in scripts.
+func runDoctor(cfg config.Config, w io.Writer) error {
+	d := &doctor{w: w}
+
+	dir, err := store.Dir()
+	d.report("data dir", dir, err)
+	if err == nil {
+		if fi, err := os.Stat(dir); err == nil && fi.Mode().Perm()&0077 != 0 {
+			d.warn("data dir", fmt.Sprintf("permissions are %v; other users may read your token (chmod 700 %s)", fi.Mode().Perm(), dir))
+		}
+	}
+
+	oauthCfg := checkCredentials(d, cfg)
+	tok := checkToken(d)
+
+	if oauthCfg == nil || tok == nil {
+		d.warn("api", "skipped: needs valid credentials and a saved token")
+	} else {
+		ctx, cancel := gmailx.HumanTimeoutCtx(context.Background(), cfg.TimeoutSeconds)
+		defer cancel()
+		start := time.Now()
+		c, err := gmailx.New(ctx, oauthCfg, tok, gmailx.WithEndpoint(cfg.APIEndpoint),
+			gmailx.WithQuotaLimiter(gmailx.NewQuotaLimiter(cfg.QuotaPerSecond)))
+		if err == nil {
+			err = c.Ping(ctx)
+		}
+		d.report("api", fmt.Sprintf("Gmail API ping (%v)", time.Since(start).Round(time.Millisecond)), err)
+	}
+
+	if d.failed > 0 {
+		return fmt.Errorf("%d check(s) failed", d.failed)
+	}
+	return nil
+}
+
+// checkCredentials reports which credentials file is used and whether it is a
+// usable OAuth client, returning the loaded config or nil.
+func checkCredentials(d *doctor, cfg config.Config) *oauth2.Config {
+	path, err := filepath.Abs(cfg.CredentialsPath)
+	if err != nil {
+		path = cfg.CredentialsPath
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		d.warn("credentials", path+" doesn't exist")
+		if saved, err := store.CredentialsPath(); err == nil {
+			path = saved
+		}
+	}
+	scopes, err := app.RequestedScopes(cfg)
+	if err != nil {
+		d.report("credentials", path, err)
+		return nil
+	}
+	oauthCfg, err := app.LoadOAuthConfig(path, scopes)
+	d.report("credentials", path, err)
+	return oauthCfg
+}
+
+// checkToken reports where the token is kept and what state it is in,
+// returning it if one is saved.
+func checkToken(d *doctor) *oauth2.Token {
+	ts, err := store.NewTokenStore()
+	if err != nil {
+		d.report("token", "token store", err)
+		return nil
+	}
+	tok, err := ts.Load()
+	if errors.Is(err, os.ErrNotExist) {
+		d.report("token", ts.Path(), errors.New("not logged in; run gtui and log in"))
+		return nil
+	}
+	d.report("token", ts.Path(), err)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case tok.Expiry.IsZero():
+		d.warn("token", "access token has no expiry")
+	case tok.Expiry.Before(time.Now()):
+		d.warn("token", "access token expired "+tok.Expiry.Local().Format(time.DateTime)+"; it is refreshed on next use")
+	default:
+		fmt.Fprintf(d.w, "      %-12s access token expires %s\n", "", tok.Expiry.Local().Format(time.DateTime))
+	}
+	if tok.RefreshToken == "" {
+		d.warn("token", "no refresh token; you will have to log in again when the access token expires")
+	}
+	return tok
+}
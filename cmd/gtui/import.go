@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/mbox"
+)
+
+// importTimeout bounds each individual message upload. Imports can be large,
+// so this is generous compared with the interactive timeout.
+const importTimeout = 2 * time.Minute
+
+// runImport uploads every message in the mbox file at path into the mailbox,
+// reading the file as a stream so large archives don't need to fit in memory.
+// Failures are reported per message and don't stop the import; the command
+// fails at the end if any message couldn't be imported. It reuses the token
+// saved by the TUI, so the user must have logged in there first.
+func runImport(cfg config.Config, path string) error {
+	if path == "" {
+		return errors.New("usage: gtui import --mbox file.mbox")
+	}
+	if cfg.MetadataOnly {
+		return errors.New("import needs full access and isn't available in metadata-only mode")
+	}
+	oauthCfg, tok, err := savedLogin(cfg)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := gmailx.New(ctx, oauthCfg, tok, gmailx.WithEndpoint(cfg.APIEndpoint),
+		gmailx.WithQuotaLimiter(gmailx.NewQuotaLimiter(cfg.QuotaPerSecond)))
+	if err != nil {
+		return err
+	}
+
+	r := mbox.NewReader(f)
+	var imported, failed int
+	for n := 1; ; n++ {
+		raw, line, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		msgCtx, cancel := context.WithTimeout(ctx, importTimeout)
+		_, err = c.Import(msgCtx, raw, nil)
+		cancel()
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d messages", imported)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "message %d (line %d): %v\n", n, line, err)
+			continue
+		}
+		imported++
+		fmt.Printf("\rimported %d", imported)
+	}
+	fmt.Printf("\rimported %d messages, %d failed\n", imported, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d messages could not be imported", failed)
+	}
+	return nil
+}
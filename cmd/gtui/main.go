@@ -1,20 +1,184 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"gmail-tui/internal/app"
+	"gmail-tui/internal/auth"
+	"gmail-tui/internal/config"
+	"gmail-tui/internal/store"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/oauth2"
 )
 
-// main initializes and runs the Gmail TUI application using the Bubble Tea framework.
-// It creates a new program with an alternate screen buffer (fullscreen mode) and handles any startup errors.
+// setupLogging configures the default slog logger. When debug is enabled, logs are
+// written to ~/.gmail-tui/gtui.log since the TUI owns the terminal; otherwise all
+// log output is discarded. Returns a function that closes the log file.
+func setupLogging(debug bool) (func(), error) {
+	if !debug {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return func() {}, nil
+	}
+	dir, err := store.Dir()
+	if err != nil {
+		return nil, err
+	}
+	f, err := tea.LogToFile(filepath.Join(dir, "gtui.log"), "gtui")
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	slog.Info("debug logging enabled")
+	return func() { _ = f.Close() }, nil
+}
+
+// fail prints an error and exits with a non-zero status.
+func fail(err error) {
+	fmt.Println("error:", err)
+	os.Exit(1)
+}
+
+// main loads the configuration and dispatches to a subcommand. With no subcommand
+// it runs the TUI; "config" prints the effective settings, "import" uploads
+// an mbox file, "doctor" checks the credentials, token and API access and
+// "watch" prints new messages matching a query. Flags may follow the
+// subcommand and override the config file and environment.
 func main() {
-	p := tea.NewProgram(app.NewModel(), tea.WithAltScreen())
+	args := os.Args[1:]
+	cmd := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	cfg, opts, err := loadSettings(args)
+	if err != nil {
+		fail(err)
+	}
+
+	switch cmd {
+	case "":
+		if cfg.MetadataOnly && opts.query != "" {
+			fail(errors.New("searching isn't available in metadata-only mode"))
+		}
+		start := app.StartOptions{Label: opts.label, Query: opts.query}
+		runTUI(cfg, start, func() (config.Config, error) {
+			cfg, _, err := loadSettings(args)
+			return cfg, err
+		})
+	case "config":
+		if err := cfg.Print(os.Stdout); err != nil {
+			fail(err)
+		}
+	case "import":
+		if err := runImport(cfg, opts.mbox); err != nil {
+			fail(err)
+		}
+	case "doctor":
+		if err := runDoctor(cfg, os.Stdout); err != nil {
+			fail(err)
+		}
+	case "watch":
+		if err := runWatch(cfg, opts, os.Stdout); err != nil {
+			fail(err)
+		}
+	default:
+		fail(fmt.Errorf("unknown command %q (available: config, import, doctor, watch)", cmd))
+	}
+}
+
+// commandFlags holds the flags that only apply to one subcommand and so
+// aren't part of the config.
+type commandFlags struct {
+	// mbox is the file the import command uploads.
+	mbox string
+	// label and query pick the messages the inbox opens with, or that the
+	// watch command prints.
+	label string
+	query string
+	// interval and json configure the watch command.
+	interval time.Duration
+	json     bool
+}
+
+// loadSettings builds the effective configuration from the config file, the
+// environment and the command-line flags in args, and returns the subcommand
+// flags. It is called again when the TUI reloads its settings, so flags keep
+// taking precedence over an edited config file.
+func loadSettings(args []string) (config.Config, commandFlags, error) {
+	var opts commandFlags
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, opts, err
+	}
+	fs := flag.NewFlagSet("gtui", flag.ExitOnError)
+	cfg.RegisterFlags(fs)
+	fs.StringVar(&opts.mbox, "mbox", "", "mbox file to upload (import command)")
+	fs.StringVar(&opts.label, "label", "", "label name or ID to open the inbox to, or to watch")
+	fs.StringVar(&opts.query, "query", "", "Gmail search to open the inbox with, or for the messages to print (watch command); combined with --label")
+	fs.DurationVar(&opts.interval, "interval", 30*time.Second, "how often to check for new messages (watch command)")
+	fs.BoolVar(&opts.json, "json", false, "print each message as a JSON object (watch command)")
+	_ = fs.Parse(args)
+	if err := cfg.Validate(); err != nil {
+		return cfg, opts, err
+	}
+	if _, err := auth.FlowByName(cfg.LoginFlow, cfg.ForceConsent, nil, nil); err != nil {
+		return cfg, opts, err
+	}
+	if _, err := app.RequestedScopes(cfg); err != nil {
+		return cfg, opts, err
+	}
+	return cfg, opts, nil
+}
+
+// savedLogin loads the OAuth config and the token saved by the TUI, for the
+// subcommands that call the API without logging in themselves.
+func savedLogin(cfg config.Config) (*oauth2.Config, *oauth2.Token, error) {
+	scopes, err := app.RequestedScopes(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	oauthCfg, err := app.LoadOAuthConfig(cfg.CredentialsPath, scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+	ts, err := store.NewTokenStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	tok, err := ts.Load()
+	if err != nil {
+		return nil, nil, errors.New("not logged in: run gtui and log in first")
+	}
+	return oauthCfg, tok, nil
+}
+
+// runTUI initializes and runs the Gmail TUI application using the Bubble Tea framework.
+// It creates a new program on the alternate screen buffer (fullscreen mode), or inline
+// when no_alt_screen is set, and handles any startup errors. start picks the
+// label and query the inbox opens with.
+func runTUI(cfg config.Config, start app.StartOptions, reload func() (config.Config, error)) {
+	closeLog, err := setupLogging(cfg.Debug)
+	if err != nil {
+		fail(err)
+	}
+	defer closeLog()
+
+	var opts []tea.ProgramOption
+	if !cfg.NoAltScreen {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(app.NewModel(cfg, reload, start), opts...)
 	if _, err := p.Run(); err != nil {
-		fmt.Println("error:", err)
-		os.Exit(1)
+		slog.Error("program exited with error", "err", err)
+		closeLog()
+		fail(err)
 	}
 }
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// runWatch checks every opts.interval for messages matching opts.query, in
+// opts.label when it is set, and
+// prints each one that wasn't there on the previous check to w, one line per
+// message, until interrupted. Messages that already match when it starts are
+// not printed. Only the newest page of results, as many as the page size, is
+// compared, so a burst of more messages than that between checks prints just
+// the newest ones. Failed checks are reported on stderr and retried on the
+// next tick, so a dropped connection doesn't end the watch.
+func runWatch(cfg config.Config, opts commandFlags, w io.Writer) error {
+	if opts.interval < time.Second {
+		return errors.New("--interval must be at least 1s")
+	}
+	if cfg.MetadataOnly && opts.query != "" {
+		return errors.New("searching isn't available in metadata-only mode")
+	}
+	oauthCfg, tok, err := savedLogin(cfg)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := gmailx.New(ctx, oauthCfg, tok, gmailx.WithEndpoint(cfg.APIEndpoint),
+		gmailx.WithQuotaLimiter(gmailx.NewQuotaLimiter(cfg.QuotaPerSecond)))
+	if err != nil {
+		return err
+	}
+	var labelID string
+	if opts.label != "" {
+		lctx, cancel := gmailx.HumanTimeoutCtx(ctx, cfg.TimeoutSeconds)
+		label, err := c.ResolveLabel(lctx, opts.label)
+		cancel()
+		if err != nil {
+			return err
+		}
+		labelID = label.ID
+	}
+
+	var seen []string
+	first := true
+	tick := time.NewTicker(opts.interval)
+	defer tick.Stop()
+	for {
+		ids, err := watchCheck(ctx, c, cfg, opts.query, labelID)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "check failed:", err)
+		} else {
+			if !first {
+				if err := printNew(ctx, c, cfg, w, ids, seen, opts.json); err != nil {
+					return err
+				}
+			}
+			seen, first = ids, false
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+// watchCheck lists the IDs of the newest messages matching query, in the
+// label with ID labelID if it isn't empty.
+func watchCheck(ctx context.Context, c *gmailx.Client, cfg config.Config, query, labelID string) ([]string, error) {
+	ctx, cancel := gmailx.HumanTimeoutCtx(ctx, cfg.TimeoutSeconds)
+	defer cancel()
+	p, err := c.ListInboxPage(ctx, cfg.PageSize, query, labelID, "", false)
+	return p.IDs, err
+}
+
+// printNew prints the messages in ids that aren't in seen, oldest first.
+// A message that fails to load is reported on stderr and skipped.
+func printNew(ctx context.Context, c *gmailx.Client, cfg config.Config, w io.Writer, ids, seen []string, asJSON bool) error {
+	for _, id := range slices.Backward(ids) {
+		if slices.Contains(seen, id) {
+			continue
+		}
+		rctx, cancel := gmailx.HumanTimeoutCtx(ctx, cfg.TimeoutSeconds)
+		row, err := c.GetRow(rctx, id)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "message %s: %v\n", id, err)
+			continue
+		}
+		if err := printRow(w, row, asJSON); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printRow writes row as one line: a JSON object, or its date, sender and
+// subject separated by tabs.
+func printRow(w io.Writer, row gmailx.EmailRow, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return enc.Encode(row)
+	}
+	date := row.Date
+	if !row.Time.IsZero() {
+		date = row.Time.Local().Format(time.DateTime)
+	}
+	_, err := fmt.Fprintf(w, "%s\t%s\t%s\n", date, row.From, row.Subject)
+	return err
+}
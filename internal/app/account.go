@@ -0,0 +1,110 @@
+package app
+
+import (
+	"hash/fnv"
+	"log/slog"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// accountBannerDuration is how long the banner shown when the active account
+// changes stays on screen.
+const accountBannerDuration = 3 * time.Second
+
+// accountPalette is the set of accent colors assigned to accounts that have no
+// color configured. They are picked to be distinguishable on dark and light
+// terminals.
+var accountPalette = []lipgloss.Color{"33", "35", "166", "28", "127", "172", "31", "160"}
+
+type profileMsg struct {
+	profile gmailx.AccountProfile
+	err     error
+}
+
+type accountBannerDoneMsg struct {
+	email string
+}
+
+// fetchProfileCmd creates a command that looks up the signed-in account's address
+// and mailbox totals.
+// Uses the configured timeout for the API call.
+func (m model) fetchProfileCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return profileMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return profileMsg{err: err}
+		}
+		p, err := c.Profile(ctx)
+		return profileMsg{profile: p, err: err}
+	}
+}
+
+// accountColor returns the accent color for an account: the one configured in
+// account_colors if present, otherwise a palette color chosen by hashing the
+// address so each account keeps the same color across runs.
+func (m model) accountColor(email string) lipgloss.Color {
+	if c, ok := m.settings.AccountColors[email]; ok && c != "" {
+		return lipgloss.Color(c)
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(email))
+	return accountPalette[h.Sum32()%uint32(len(accountPalette))]
+}
+
+// setAccount records the active account. When it differs from the previous
+// one, a banner naming the account is shown briefly so it's obvious which
+// mailbox subsequent actions apply to, and the account's own contacts are
+// loaded, learning those of the rows already listed.
+func (m *model) setAccount(email string) tea.Cmd {
+	if email == "" || email == m.account {
+		return nil
+	}
+	m.account = email
+	m.accountBanner = true
+	m.saveContacts()
+	contacts, err := store.LoadContacts(email)
+	if err != nil {
+		slog.Warn("failed to load contacts", "err", err)
+	}
+	m.contacts = contacts
+	m.learnItems(m.inbox.Items())
+	m.saveContacts()
+	return tea.Tick(accountBannerDuration, func(time.Time) tea.Msg {
+		return accountBannerDoneMsg{email: email}
+	})
+}
+
+// accountBadge renders the active account's address in its accent color, for
+// the screen header. Empty until the account is known.
+func (m model) accountBadge() string {
+	if m.account == "" {
+		return ""
+	}
+	style := lipgloss.NewStyle().Bold(true).Foreground(m.accountColor(m.account))
+	return style.Render(m.account)
+}
+
+// accountBannerView renders the full-width banner shown after the account changes.
+func (m model) accountBannerView() string {
+	style := lipgloss.NewStyle().Bold(true).Padding(0, 1).
+		Foreground(lipgloss.Color("231")).
+		Background(m.accountColor(m.account))
+	return style.Render("Now using " + m.account)
+}
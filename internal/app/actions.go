@@ -0,0 +1,188 @@
+package app
+
+import (
+	"cmp"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type markedReadMsg struct {
+	ids    []string
+	dryRun bool
+	err    error
+}
+
+type markedAllReadMsg struct {
+	count  int
+	dryRun bool
+	err    error
+}
+
+type rowsMsg struct {
+	rows []gmailx.EmailRow
+	err  error
+}
+
+// refreshRowsCmd creates a command that re-fetches the metadata of the given
+// messages so their rows can be updated in place after an action, keeping the
+// list's selection and scroll position. Uses the configured timeout for the calls.
+func (m model) refreshRowsCmd(ids []string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return rowsMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return rowsMsg{err: err}
+		}
+		rows := make([]gmailx.EmailRow, 0, len(ids))
+		for _, id := range ids {
+			r, err := c.GetRow(ctx, id)
+			if err != nil {
+				return rowsMsg{rows: rows, err: err}
+			}
+			rows = append(rows, r)
+		}
+		return rowsMsg{rows: rows}
+	}
+}
+
+// replaceRows swaps the loaded inbox rows that have the same IDs as rows for
+// the fresh versions, leaving every other row and the selection untouched. A
+// fresh row keeps the thread size already known for it.
+func (m *model) replaceRows(rows []gmailx.EmailRow) {
+	fresh := make(map[string]emailItem, len(rows))
+	for _, it := range rowsToItems(rows) {
+		e := it.(emailItem)
+		fresh[e.id] = e
+	}
+	for i, it := range m.inbox.Items() {
+		if e, ok := it.(emailItem); ok {
+			if f, ok := fresh[e.id]; ok {
+				f.threadSize = cmp.Or(f.threadSize, e.threadSize)
+				m.inbox.SetItem(i, f)
+			}
+		}
+	}
+}
+
+// markReadCmd creates a command that marks the given messages as read with a single
+// batched modify request. Uses the configured timeout for the API call.
+func (m model) markReadCmd(ids []string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	timeout := m.settings.TimeoutSeconds
+	cache := m.cache
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return markedReadMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return markedReadMsg{err: err}
+		}
+		if err := c.MarkRead(ctx, ids); err != nil {
+			return markedReadMsg{err: err}
+		}
+		if !dryRun {
+			cacheRead(cache, ids)
+		}
+		return markedReadMsg{ids: ids, dryRun: dryRun}
+	}
+}
+
+// markAllReadCmd creates a command that marks every unread message matching the
+// current query (or the whole inbox) as read, paging through all matching IDs rather
+// than just the loaded rows. Has a 2-minute timeout since it can touch thousands of messages.
+func (m model) markAllReadCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	q := gmailx.ExpandQuery(m.query)
+	labelID := m.labelID
+	everywhere := m.everywhere
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return markedAllReadMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, 120)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return markedAllReadMsg{err: err}
+		}
+		ids, err := c.ListMessageIDs(ctx, q+" is:unread", labelID, everywhere)
+		if err != nil {
+			return markedAllReadMsg{err: err}
+		}
+		if err := c.MarkRead(ctx, ids); err != nil {
+			return markedAllReadMsg{err: err}
+		}
+		return markedAllReadMsg{count: len(ids), dryRun: dryRun}
+	}
+}
+
+// unreadIDs returns the IDs of the loaded inbox rows that are still unread.
+func (m model) unreadIDs() []string {
+	var ids []string
+	for _, it := range m.inbox.Items() {
+		if e, ok := it.(emailItem); ok && e.unread {
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
+
+// unreadCount returns how many loaded inbox rows are unread.
+func (m model) unreadCount() int {
+	return len(m.unreadIDs())
+}
+
+// setRead clears the unread flag on the loaded inbox rows with the given IDs,
+// keeping the current selection.
+func (m *model) setRead(ids []string) {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	items := m.inbox.Items()
+	for i, it := range items {
+		if e, ok := it.(emailItem); ok && set[e.id] {
+			e.unread = false
+			items[i] = e
+		}
+	}
+	m.inbox.SetItems(items)
+}
+
+// removeRow drops the message with the given id from the inbox list, if present.
+func (m *model) removeRow(id string) {
+	for i, it := range m.inbox.Items() {
+		if e, ok := it.(emailItem); ok && e.id == id {
+			m.inbox.RemoveItem(i)
+			return
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"net/mail"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// displayAddresses shortens an address header such as From or To to the
+// display names of its addresses, using the bare address for any without a
+// name. With full set, or when the header can't be parsed, it is returned as
+// is.
+func displayAddresses(s string, full bool) string {
+	if full || s == "" {
+		return s
+	}
+	addrs, err := mail.ParseAddressList(s)
+	if err != nil {
+		return s
+	}
+	names := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		if a.Name != "" {
+			names = append(names, a.Name)
+		} else {
+			names = append(names, a.Address)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// withAddresses returns d with its From and To headers shortened to display
+// names, unless full is set. d itself is not modified.
+func withAddresses(d *gmailx.EmailDetail, full bool) *gmailx.EmailDetail {
+	if full {
+		return d
+	}
+	c := *d
+	c.From = displayAddresses(d.From, false)
+	c.To = displayAddresses(d.To, false)
+	c.Cc = displayAddresses(d.Cc, false)
+	return &c
+}
@@ -0,0 +1,261 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// archiveAllChunk is how many messages each step of an archive-all job
+// archives, one BatchModify call's worth.
+const archiveAllChunk = 1000
+
+// archiveAllJob archives every inbox message matching a query, not just the
+// loaded page. It runs one API call per step so the status line can show its
+// progress: first it pages through the matching IDs, then it archives them in
+// chunks. Collecting the IDs first keeps the paging stable, since archiving
+// removes messages from the listing being paged.
+type archiveAllJob struct {
+	seq        int
+	query      string
+	labelID    string
+	everywhere bool
+	estimate   int64
+	ids        []string
+	done       int
+}
+
+type archiveAllEstimateMsg struct {
+	query      string
+	labelID    string
+	everywhere bool
+	estimate   int64
+	err        error
+}
+
+type archiveAllConfirmedMsg struct {
+	query      string
+	labelID    string
+	everywhere bool
+	estimate   int64
+}
+
+type archiveAllPageMsg struct {
+	seq  int
+	ids  []string
+	next string
+	err  error
+}
+
+type archiveAllChunkMsg struct {
+	seq    int
+	count  int
+	dryRun bool
+	err    error
+}
+
+// archiveAllQuery narrows a listing query to the messages still in the inbox,
+// the only ones archiving changes.
+func archiveAllQuery(query string) string {
+	return strings.TrimSpace(gmailx.ExpandQuery(query) + " in:inbox")
+}
+
+// startArchiveAll asks Gmail how many inbox messages match the current query,
+// label and scope, so the count can be confirmed before archiving them all.
+func (m *model) startArchiveAll() tea.Cmd {
+	if m.archiveAll != nil {
+		m.status = "Already archiving — wait for it to finish"
+		return nil
+	}
+	m.status = "Counting messages to archive..."
+
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	query := m.query
+	labelID := m.labelID
+	everywhere := m.everywhere
+
+	return func() tea.Msg {
+		msg := archiveAllEstimateMsg{query: query, labelID: labelID, everywhere: everywhere}
+		if cfg == nil || tok == nil {
+			msg.err = errMissingCfg{}
+			return msg
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.estimate, msg.err = c.EstimateResults(ctx, archiveAllQuery(query), labelID, everywhere)
+		return msg
+	}
+}
+
+// archiveAllEstimated asks to confirm archiving the estimated number of
+// messages.
+func (m *model) archiveAllEstimated(msg archiveAllEstimateMsg) {
+	if msg.err != nil {
+		m.status = ""
+		m.err = msg.err
+		return
+	}
+	if msg.estimate == 0 {
+		m.status = "Nothing to archive"
+		return
+	}
+	m.status = ""
+	scope := "the inbox"
+	if msg.query != "" {
+		scope = "\"" + msg.query + "\""
+	}
+	confirmed := archiveAllConfirmedMsg{query: msg.query, labelID: msg.labelID, everywhere: msg.everywhere, estimate: msg.estimate}
+	m.confirm = &confirmPrompt{
+		text:  fmt.Sprintf("Archive ~%s messages in %s? They stay in All Mail.", groupDigits(msg.estimate), scope),
+		onYes: func() tea.Msg { return confirmed },
+	}
+}
+
+// beginArchiveAll starts the confirmed job by listing its first page of IDs.
+func (m *model) beginArchiveAll(msg archiveAllConfirmedMsg) tea.Cmd {
+	if m.archiveAll != nil {
+		return nil
+	}
+	m.archiveSeq++
+	m.archiveAll = &archiveAllJob{
+		seq:        m.archiveSeq,
+		query:      msg.query,
+		labelID:    msg.labelID,
+		everywhere: msg.everywhere,
+		estimate:   msg.estimate,
+	}
+	m.status = fmt.Sprintf("Finding messages to archive... 0 of ~%s", groupDigits(msg.estimate))
+	return m.archiveAllPageCmd(m.archiveAll, "")
+}
+
+// archiveAllPageCmd creates a command that lists one page of the job's
+// message IDs. Uses the configured timeout for the API call.
+func (m model) archiveAllPageCmd(job *archiveAllJob, pageToken string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	seq, query, labelID, everywhere := job.seq, job.query, job.labelID, job.everywhere
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return archiveAllPageMsg{seq: seq, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return archiveAllPageMsg{seq: seq, err: err}
+		}
+		ids, next, err := c.ListMessageIDsPage(ctx, archiveAllQuery(query), labelID, everywhere, pageToken)
+		return archiveAllPageMsg{seq: seq, ids: ids, next: next, err: err}
+	}
+}
+
+// archiveAllPaged adds a listed page to the job and lists the next one, or
+// starts archiving after the last.
+func (m *model) archiveAllPaged(msg archiveAllPageMsg) tea.Cmd {
+	job := m.archiveAll
+	if job == nil || job.seq != msg.seq {
+		return nil
+	}
+	if msg.err != nil {
+		m.archiveAll = nil
+		m.status = ""
+		m.err = msg.err
+		return nil
+	}
+	job.ids = append(job.ids, msg.ids...)
+	if msg.next != "" {
+		m.status = fmt.Sprintf("Finding messages to archive... %s of ~%s", groupDigits(int64(len(job.ids))), groupDigits(job.estimate))
+		return m.archiveAllPageCmd(job, msg.next)
+	}
+	if len(job.ids) == 0 {
+		m.archiveAll = nil
+		m.status = "Nothing to archive"
+		return nil
+	}
+	m.status = fmt.Sprintf("Archiving... 0 of %s", groupDigits(int64(len(job.ids))))
+	return m.archiveAllChunkCmd(job)
+}
+
+// archiveAllChunkCmd creates a command that archives the job's next chunk of
+// messages. Uses the configured timeout for the API call.
+func (m model) archiveAllChunkCmd(job *archiveAllJob) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	timeout := m.settings.TimeoutSeconds
+	cache := m.cache
+	seq := job.seq
+	ids := job.ids[job.done:min(job.done+archiveAllChunk, len(job.ids))]
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return archiveAllChunkMsg{seq: seq, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return archiveAllChunkMsg{seq: seq, err: err}
+		}
+		if err := c.Archive(ctx, ids); err != nil {
+			return archiveAllChunkMsg{seq: seq, err: err}
+		}
+		if !dryRun {
+			uncacheRemoved(cache, ids, false)
+		}
+		return archiveAllChunkMsg{seq: seq, count: len(ids), dryRun: dryRun}
+	}
+}
+
+// archiveAllArchived records an archived chunk and archives the next one, or
+// reports the total and reloads the inbox when the job is done. A failed
+// chunk stops the job, reporting how far it got.
+func (m *model) archiveAllArchived(msg archiveAllChunkMsg) tea.Cmd {
+	job := m.archiveAll
+	if job == nil || job.seq != msg.seq {
+		return nil
+	}
+	total := groupDigits(int64(len(job.ids)))
+	if msg.err != nil {
+		m.archiveAll = nil
+		m.status = ""
+		m.err = fmt.Errorf("archived %s of %s messages, then: %w", groupDigits(int64(job.done)), total, msg.err)
+		if job.done == 0 {
+			return nil
+		}
+		return m.fetchInboxCmd()
+	}
+	job.done += msg.count
+	if job.done < len(job.ids) {
+		m.status = fmt.Sprintf("Archiving... %s of %s", groupDigits(int64(job.done)), total)
+		return m.archiveAllChunkCmd(job)
+	}
+	m.archiveAll = nil
+	if msg.dryRun {
+		m.status = dryRunStatus("archive", job.done)
+		return nil
+	}
+	m.status = fmt.Sprintf("Archived %s messages", total)
+	return m.fetchInboxCmd()
+}
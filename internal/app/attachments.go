@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// attachmentItem is an attachment of the open message in the attachments list.
+// index is its position in the message's attachments.
+type attachmentItem struct {
+	a     gmailx.Attachment
+	index int
+}
+
+// Title returns the attachment's name after a symbol for its kind.
+func (i attachmentItem) Title() string { return attachmentGlyph(i.a) + " " + i.a.Filename }
+
+// Description returns the attachment's MIME type and size.
+func (i attachmentItem) Description() string {
+	return i.a.MimeType + " • " + formatSize(i.a.Size)
+}
+
+// FilterValue returns the file name for filtering in the list.
+func (i attachmentItem) FilterValue() string { return i.a.Filename }
+
+type attachmentsSavedMsg struct {
+	paths []string
+	err   error
+}
+
+// showAttachments switches to the attachments screen for the open message.
+func (m *model) showAttachments() {
+	items := make([]list.Item, len(m.detail.Attachments))
+	for i, a := range m.detail.Attachments {
+		items[i] = attachmentItem{a: a, index: i}
+	}
+	m.attachments.SetItems(items)
+	m.attachments.Select(m.attachIdx)
+	m.attachments.Title = "Attachments — " + m.detail.Subject
+	m.screen = screenAttachments
+	m.status = ""
+}
+
+// saveAttachmentsCmd creates a command that downloads attachments of a message
+// into the downloads directory, stopping at the first failure. Uses the
+// configured timeout for all of the downloads together.
+func (m model) saveAttachmentsCmd(msgID string, as []gmailx.Attachment) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return attachmentsSavedMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return attachmentsSavedMsg{err: err}
+		}
+		var paths []string
+		for _, a := range as {
+			data, err := c.GetAttachment(ctx, msgID, a)
+			if err != nil {
+				return attachmentsSavedMsg{paths: paths, err: err}
+			}
+			path, err := store.DownloadPath(a.Filename)
+			if err != nil {
+				return attachmentsSavedMsg{paths: paths, err: err}
+			}
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				return attachmentsSavedMsg{paths: paths, err: err}
+			}
+			paths = append(paths, path)
+		}
+		return attachmentsSavedMsg{paths: paths}
+	}
+}
+
+// savedStatus describes the outcome of a download for the status line.
+func savedStatus(msg attachmentsSavedMsg) string {
+	switch {
+	case msg.err != nil && len(msg.paths) > 0:
+		return fmt.Sprintf("Saved %d attachments before failing: %v", len(msg.paths), msg.err)
+	case msg.err != nil:
+		return "Couldn't save attachment: " + msg.err.Error()
+	case len(msg.paths) == 1:
+		return "Saved " + msg.paths[0]
+	}
+	return fmt.Sprintf("Saved %d attachments to %s", len(msg.paths), filepath.Dir(msg.paths[0]))
+}
+
+// updateAttachments handles keys on the attachments screen.
+func (m model) updateAttachments(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.attachments.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.attachments, cmd = m.attachments.Update(msg)
+		return m, cmd
+	}
+	switch msg.String() {
+	case "b", "esc":
+		m.screen = screenDetail
+		m.status = ""
+		return m, nil
+	case "enter", "o":
+		if it, ok := m.attachments.SelectedItem().(attachmentItem); ok {
+			m.attachIdx = it.index
+			return m.openAttachment()
+		}
+		return m, nil
+	case "d":
+		if it, ok := m.attachments.SelectedItem().(attachmentItem); ok {
+			m.status = "Saving " + it.a.Filename + "..."
+			return m, m.saveAttachmentsCmd(m.detailID, []gmailx.Attachment{it.a})
+		}
+		return m, nil
+	case "D":
+		if m.detail == nil {
+			return m, nil
+		}
+		m.status = fmt.Sprintf("Saving %d attachments...", len(m.detail.Attachments))
+		return m, m.saveAttachmentsCmd(m.detailID, m.detail.Attachments)
+	}
+	var cmd tea.Cmd
+	m.attachments, cmd = m.attachments.Update(msg)
+	return m, cmd
+}
+
+// attachmentsView renders the attachments list with the footer and status.
+func (m model) attachmentsView() string {
+	return m.attachmentsHeader() + "\n\n" + m.attachments.View()
+}
+
+// attachmentsHeader renders the lines above the attachments list, below the
+// title.
+func (m model) attachmentsHeader() string {
+	h := m.footer()
+	if m.status != "" {
+		h += "\n" + faint.Render(m.status)
+	}
+	return h
+}
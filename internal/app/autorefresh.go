@@ -0,0 +1,55 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxRefreshBackoff caps how far the auto-refresh interval is stretched while
+// the network is unreachable.
+const maxRefreshBackoff = 30 * time.Minute
+
+type refreshTickMsg struct {
+	seq int
+}
+
+// refreshInterval is the wait before the next automatic inbox refresh: the
+// configured interval, doubled for each consecutive fetch that failed because
+// the network was unreachable.
+func (m model) refreshInterval() time.Duration {
+	d := time.Duration(m.settings.RefreshSeconds) * time.Second
+	for range m.offlineFetches {
+		if d >= maxRefreshBackoff {
+			return maxRefreshBackoff
+		}
+		d *= 2
+	}
+	return min(d, maxRefreshBackoff)
+}
+
+// scheduleRefresh starts the timer for the next automatic inbox refresh, if
+// auto-refresh is enabled. Each call replaces the previous timer, so it is
+// safe to call after every fetch.
+func (m *model) scheduleRefresh() tea.Cmd {
+	m.refreshSeq++
+	if m.settings.RefreshSeconds <= 0 || m.token == nil {
+		return nil
+	}
+	seq := m.refreshSeq
+	return tea.Tick(m.refreshInterval(), func(time.Time) tea.Msg {
+		return refreshTickMsg{seq: seq}
+	})
+}
+
+// recordFetch notes the outcome of an inbox fetch for the auto-refresh
+// backoff: only failures caused by the network count, so auth or quota errors
+// don't mark the app offline.
+func (m *model) recordFetch(offline bool) tea.Cmd {
+	if offline {
+		m.offlineFetches++
+	} else {
+		m.offlineFetches = 0
+	}
+	return m.scheduleRefresh()
+}
@@ -0,0 +1,60 @@
+package app
+
+import gmailx "gmail-tui/internal/gmail"
+
+// bodyView selects which rendering of the open message's body the detail view
+// shows. The s key cycles through them in order.
+type bodyView int
+
+const (
+	// bodyCleaned is the normal body: the plain-text part, or the HTML part
+	// converted to text, with quoted replies folded.
+	bodyCleaned bodyView = iota
+	// bodyHTMLText is the HTML part converted to text, even when there is a
+	// plain-text part.
+	bodyHTMLText
+	// bodyHTMLSource is the HTML part as sent, to inspect links and markup.
+	bodyHTMLSource
+	// bodyPlainSource is the plain-text part as sent.
+	bodyPlainSource
+	bodyViewCount
+)
+
+var bodyViewNames = [bodyViewCount]string{
+	bodyCleaned:     "cleaned text",
+	bodyHTMLText:    "HTML as text",
+	bodyHTMLSource:  "HTML source",
+	bodyPlainSource: "plain-text source",
+}
+
+// next returns the view after v, wrapping around to bodyCleaned.
+func (v bodyView) next() bodyView { return (v + 1) % bodyViewCount }
+
+// withBodyView returns d with its body replaced by the given rendering. The
+// source renderings are shown without quote folding, and as truncated as the
+// part they come from. d itself is not modified.
+func withBodyView(d *gmailx.EmailDetail, v bodyView, expandQuotes bool) *gmailx.EmailDetail {
+	if v == bodyCleaned {
+		return withQuotes(d, expandQuotes)
+	}
+	c := *d
+	c.HTMLFallback = false
+	switch v {
+	case bodyHTMLText:
+		c.Body, c.Truncated = gmailx.HTMLToText(d.HTMLPart), d.HTMLTruncated
+	case bodyHTMLSource:
+		c.Body, c.Truncated = d.HTMLPart, d.HTMLTruncated
+	case bodyPlainSource:
+		c.Body, c.Truncated = d.PlainPart, d.PlainTruncated
+	}
+	if c.Body == "" {
+		c.Body = "(this message has no " + bodyViewNames[v] + ")"
+	}
+	return &c
+}
+
+// truncated reports whether any rendering of d was cut short by the body cap,
+// so loading the full message would show more.
+func truncated(d *gmailx.EmailDetail) bool {
+	return d.Truncated > 0 || d.PlainTruncated > 0 || d.HTMLTruncated > 0
+}
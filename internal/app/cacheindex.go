@@ -0,0 +1,53 @@
+package app
+
+import (
+	"log/slog"
+	"slices"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+)
+
+// editCachedRows rewrites the cached inbox rows with fn, so changes made in
+// the app show up when the cache is next read, at startup or offline, rather
+// than only after the next fetch.
+func editCachedRows(cache *store.Cache, fn func([]gmailx.EmailRow) []gmailx.EmailRow) {
+	if cache == nil {
+		return
+	}
+	var rows []gmailx.EmailRow
+	if cache.Get(inboxCacheKey, &rows) != nil {
+		return
+	}
+	if err := cache.Put(inboxCacheKey, fn(rows)); err != nil {
+		slog.Warn("failed to update cached inbox", "err", err)
+	}
+}
+
+// uncacheRemoved drops messages that left the inbox from the cached rows.
+// With forget, as for trash and spam, their cached details are deleted too.
+func uncacheRemoved(cache *store.Cache, ids []string, forget bool) {
+	editCachedRows(cache, func(rows []gmailx.EmailRow) []gmailx.EmailRow {
+		return slices.DeleteFunc(rows, func(r gmailx.EmailRow) bool { return slices.Contains(ids, r.ID) })
+	})
+	if !forget || cache == nil {
+		return
+	}
+	for _, id := range ids {
+		if err := cache.Delete(detailCacheKey(id)); err != nil {
+			slog.Warn("failed to delete cached message", "id", id, "err", err)
+		}
+	}
+}
+
+// cacheRead marks messages read in the cached rows.
+func cacheRead(cache *store.Cache, ids []string) {
+	editCachedRows(cache, func(rows []gmailx.EmailRow) []gmailx.EmailRow {
+		for i := range rows {
+			if slices.Contains(ids, rows[i].ID) {
+				rows[i].Unread = false
+			}
+		}
+		return rows
+	})
+}
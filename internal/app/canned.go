@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// senderValues are the placeholders a canned response can use, taken from
+// the sender of d: {{first_name}}, {{name}} and {{email}}. Without a display
+// name the local part of the address stands in for the names.
+func senderValues(d *gmailx.EmailDetail) map[string]string {
+	name := strings.TrimSpace(d.FromName)
+	first := ""
+	if last, given, ok := strings.Cut(name, ","); ok && strings.TrimSpace(given) != "" {
+		// "Doe, Jane"
+		name = strings.TrimSpace(given) + " " + strings.TrimSpace(last)
+	}
+	if f := strings.Fields(name); len(f) > 0 {
+		first = f[0]
+	}
+	if name == "" {
+		local, _, _ := strings.Cut(d.FromAddress, "@")
+		name = local
+		first, _, _ = strings.Cut(local, ".")
+	}
+	return map[string]string{"first_name": first, "name": name, "email": d.FromAddress}
+}
+
+// cannedBody fills the placeholders of the canned response text from the
+// sender of d and places it in a reply quoted in the given style.
+func cannedBody(text string, d *gmailx.EmailDetail, style string) string {
+	text = strings.TrimRight(mailTemplate{body: text}.fill(senderValues(d)).body, "\n")
+	quote := attribution(d) + "\n" + quoteText(d.Body)
+	switch style {
+	case quoteNone:
+		return text
+	case quoteBottom:
+		return quote + "\n\n" + text
+	default:
+		return text + "\n\n" + quote
+	}
+}
+
+// startCannedReply opens a reply to the open message prefilled with the n-th
+// canned response, counting from 1, ready to send or edit.
+func (m *model) startCannedReply(n int) {
+	if m.detail == nil {
+		return
+	}
+	if n < 1 || n > len(m.settings.CannedResponses) {
+		m.status = fmt.Sprintf("No canned response %d — add it to canned_responses in the config", n)
+		return
+	}
+	m.startReply()
+	m.composeBody.SetValue(cannedBody(m.settings.CannedResponses[n-1], m.detail, m.settings.ReplyQuoting))
+	m.replyCursor()
+	m.status = fmt.Sprintf("Canned response %d — ctrl+s to send", n)
+}
@@ -0,0 +1,278 @@
+package app
+
+import (
+	"errors"
+	"net/mail"
+	"slices"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	composeTo = iota
+	composeSubject
+	composeBody
+	composeFieldCount
+)
+
+// sentMsg reports a sent message; to and id are its recipients and ID, and
+// seq identifies the pending send it delivered.
+type sentMsg struct {
+	seq int
+	to  string
+	id  string
+	err error
+}
+
+// newComposeInputs creates the single-line header inputs of the compose screen,
+// indexed by the compose* field constants.
+func newComposeInputs() []textinput.Model {
+	inputs := make([]textinput.Model, composeBody)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 60
+	}
+	inputs[composeTo].Prompt = "To:      "
+	inputs[composeTo].Placeholder = "name@example.com"
+	inputs[composeSubject].Prompt = "Subject: "
+	return inputs
+}
+
+// newComposeBody creates the multi-line editor for the message body.
+func newComposeBody() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Message"
+	ta.ShowLineNumbers = false
+	ta.SetWidth(70)
+	ta.SetHeight(composeBodyHeight)
+	return ta
+}
+
+// composeBodyHeight is how many lines the message body takes on the compose
+// screen when the terminal has room for them, and composeBodyMinHeight how
+// few it may shrink to when it doesn't.
+const (
+	composeBodyHeight    = 12
+	composeBodyMinHeight = 3
+)
+
+// fitComposeBody sizes the message body so the compose screen fits the
+// terminal, taking what the fields, suggestions and status above and below
+// it leave.
+func (m *model) fitComposeBody() {
+	if m.tmpl != nil {
+		return
+	}
+	spare := m.height - frameHeight - lipgloss.Height(m.titleView()) - 1 - lipgloss.Height(m.composeView())
+	m.composeBody.SetHeight(min(max(m.composeBody.Height()+spare, composeBodyMinHeight), composeBodyHeight))
+}
+
+// senderAddress extracts the bare email address from a From header such as
+// `"Jane Doe" <jane@example.com>`. Falls back to the trimmed header when it
+// can't be parsed.
+func senderAddress(from string) string {
+	if a, err := mail.ParseAddress(from); err == nil {
+		return a.Address
+	}
+	return strings.TrimSpace(from)
+}
+
+// startCompose opens the compose screen with a blank message addressed to to,
+// which may be empty. Focus starts on the first empty field.
+func (m *model) startCompose(to string) {
+	for i := range m.composeInputs {
+		m.composeInputs[i].SetValue("")
+	}
+	m.composeBody.Reset()
+	m.composeInputs[composeTo].SetValue(to)
+	m.reply = nil
+	m.composeFormat = m.settings.ComposeFormat
+	m.status = ""
+	m.screen = screenCompose
+	if to == "" {
+		m.focusCompose(composeTo)
+	} else {
+		m.focusCompose(composeSubject)
+	}
+}
+
+// focusCompose moves keyboard focus to the i-th compose field.
+func (m *model) focusCompose(i int) {
+	m.composeFocus = (i + composeFieldCount) % composeFieldCount
+	for j := range m.composeInputs {
+		if j == m.composeFocus {
+			m.composeInputs[j].Focus()
+		} else {
+			m.composeInputs[j].Blur()
+		}
+	}
+	if m.composeFocus == composeBody {
+		m.composeBody.Focus()
+	} else {
+		m.composeBody.Blur()
+	}
+}
+
+// composeMessage validates the compose fields and builds the message to send,
+// appending the signature to the body.
+func (m model) composeMessage() (*gmailx.OutgoingMessage, error) {
+	to := strings.TrimSpace(m.composeInputs[composeTo].Value())
+	if to == "" {
+		return nil, errors.New("add at least one recipient")
+	}
+	if _, err := mail.ParseAddressList(to); err != nil {
+		return nil, errors.New("invalid recipient: " + to)
+	}
+	body := m.composeBody.Value()
+	out := &gmailx.OutgoingMessage{
+		To:      to,
+		Subject: strings.TrimSpace(m.composeInputs[composeSubject].Value()),
+		Body:    body + m.signatureBlock(body),
+		Format:  m.composeFormat,
+	}
+	if m.reply != nil {
+		out.ThreadID = m.reply.threadID
+		out.InReplyTo = m.reply.messageID
+		out.References = m.reply.references
+	}
+	return out, nil
+}
+
+// sendCmd creates a command that sends the given message.
+// Uses the configured timeout for the API call.
+func (m model) sendCmd(out *gmailx.OutgoingMessage) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	seq := m.sendSeq
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return sentMsg{seq: seq, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return sentMsg{seq: seq, err: err}
+		}
+		id, err := c.Send(ctx, out)
+		if err != nil {
+			return sentMsg{seq: seq, err: err}
+		}
+		return sentMsg{seq: seq, to: out.To, id: id}
+	}
+}
+
+// updateCompose handles key presses on the compose screen. tab/shift+tab move
+// between fields, ctrl+t inserts a template, ctrl+o switches the format the
+// message is sent in, ctrl+s sends after the undo window and esc discards the
+// message.
+// While addresses are suggested for the To field, up/down choose one and tab
+// accepts it.
+func (m model) updateCompose(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.tmpl != nil {
+		return m.updateTemplate(msg)
+	}
+	switch msg.String() {
+	case "ctrl+t":
+		m.openTemplatePicker()
+		return m, nil
+	case "ctrl+o":
+		m.cycleComposeFormat()
+		return m, nil
+	case "esc":
+		m.leaveCompose()
+		m.status = "Message discarded"
+		return m, nil
+	case "tab":
+		if !m.acceptSuggestion() {
+			m.focusCompose(m.composeFocus + 1)
+		}
+		return m, nil
+	case "down":
+		if m.moveSuggestion(1) {
+			return m, nil
+		}
+	case "up":
+		if m.moveSuggestion(-1) {
+			return m, nil
+		}
+	case "shift+tab":
+		m.focusCompose(m.composeFocus - 1)
+		return m, nil
+	case "ctrl+s":
+		if m.offline {
+			m.status = "Offline — messages can't be sent until the connection is back"
+			return m, nil
+		}
+		if m.pendingSend != nil {
+			return m, nil
+		}
+		out, err := m.composeMessage()
+		if err != nil {
+			m.status = "Can't send: " + err.Error()
+			return m, nil
+		}
+		cmd := m.queueSend(out)
+		return m, cmd
+	}
+	var cmd tea.Cmd
+	if m.composeFocus == composeBody {
+		m.composeBody, cmd = m.composeBody.Update(msg)
+	} else {
+		m.composeInputs[m.composeFocus], cmd = m.composeInputs[m.composeFocus].Update(msg)
+		m.suggestIdx = 0
+	}
+	return m, cmd
+}
+
+// composeFormats are the formats ctrl+o cycles through, and
+// composeFormatNames describe them on the compose screen.
+var (
+	composeFormats     = []string{gmailx.FormatPlain, gmailx.FormatHTML, gmailx.FormatAlternative}
+	composeFormatNames = map[string]string{
+		gmailx.FormatPlain:       "plain text",
+		gmailx.FormatHTML:        "HTML",
+		gmailx.FormatAlternative: "plain text and HTML",
+	}
+)
+
+// cycleComposeFormat switches the message being composed to the next format.
+func (m *model) cycleComposeFormat() {
+	i := slices.Index(composeFormats, m.composeFormat)
+	m.composeFormat = composeFormats[(i+1)%len(composeFormats)]
+}
+
+// composeView renders the compose screen.
+func (m model) composeView() string {
+	if m.tmpl != nil {
+		return m.templateView()
+	}
+	body := "New message\n\n"
+	for i, in := range m.composeInputs {
+		body += in.View() + "\n"
+		if i == composeTo {
+			body += m.suggestionsView()
+		}
+	}
+	body += "\n" + m.composeBody.View() + "\n"
+	note := "Send as " + composeFormatNames[m.composeFormat]
+	if m.signatureBlock(m.composeBody.Value()) != "" {
+		note += ", with your signature"
+	}
+	body += faint.Render(note) + "\n"
+	if m.status != "" {
+		body += "\n" + m.status + "\n"
+	}
+	return body + "\n" + m.footer()
+}
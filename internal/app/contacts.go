@@ -0,0 +1,139 @@
+package app
+
+import (
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// maxSuggestions is how many address suggestions compose shows at once.
+const maxSuggestions = 5
+
+// learnContacts records the addresses in the from and to headers of the
+// message with the given ID and date as contacts for autocomplete. The
+// signed-in account itself is left out.
+func (m model) learnContacts(id, from, to string, date time.Time) {
+	for _, h := range []string{from, to} {
+		addrs, err := mail.ParseAddressList(h)
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if strings.EqualFold(a.Address, m.account) {
+				continue
+			}
+			m.contacts.Add(a.Name, a.Address, id, date)
+		}
+	}
+}
+
+// learnItems records the contacts of every message in items.
+func (m model) learnItems(items []list.Item) {
+	for _, it := range items {
+		if e, ok := it.(emailItem); ok {
+			date, _ := mail.ParseDate(e.date)
+			m.learnContacts(e.id, e.from, e.to, date)
+		}
+	}
+}
+
+// saveContacts writes the contacts learned since the last save.
+func (m model) saveContacts() {
+	if err := m.contacts.Save(); err != nil {
+		slog.Warn("failed to save contacts", "err", err)
+	}
+}
+
+// recipientPrefix splits the To field into the recipients already complete
+// and the one being typed after the last comma.
+func recipientPrefix(to string) (done, typing string) {
+	i := strings.LastIndex(to, ",")
+	if i < 0 {
+		return "", strings.TrimSpace(to)
+	}
+	return to[:i+1], strings.TrimSpace(to[i+1:])
+}
+
+// formatContact renders a contact as a recipient for the To field, quoting
+// the name when it contains characters that would break the address list.
+// Unlike mail.Address.String it leaves non-ASCII names readable.
+func formatContact(name, addr string) string {
+	if name == "" {
+		return addr
+	}
+	if strings.ContainsAny(name, `,;:<>@()[]\".`) {
+		name = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name) + `"`
+	}
+	return name + " <" + addr + ">"
+}
+
+// suggestions returns the contacts matching the recipient being typed in the
+// To field, when it has focus.
+func (m model) suggestions() []string {
+	if m.composeFocus != composeTo {
+		return nil
+	}
+	_, typing := recipientPrefix(m.composeInputs[composeTo].Value())
+	var out []string
+	for _, c := range m.contacts.Suggest(typing, maxSuggestions) {
+		s := formatContact(c.Name, c.Address)
+		if strings.EqualFold(c.Address, typing) || s == typing {
+			// Already typed out in full.
+			return nil
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// acceptSuggestion replaces the recipient being typed with the selected
+// suggestion and starts the next one. It reports false when there is
+// nothing to accept.
+func (m *model) acceptSuggestion() bool {
+	s := m.suggestions()
+	if len(s) == 0 {
+		return false
+	}
+	in := &m.composeInputs[composeTo]
+	done, _ := recipientPrefix(in.Value())
+	if done != "" {
+		done += " "
+	}
+	in.SetValue(done + s[min(m.suggestIdx, len(s)-1)] + ", ")
+	in.CursorEnd()
+	m.suggestIdx = 0
+	return true
+}
+
+// moveSuggestion moves the selected suggestion by step, wrapping around. It
+// reports false when no suggestions are shown.
+func (m *model) moveSuggestion(step int) bool {
+	n := len(m.suggestions())
+	if n == 0 {
+		return false
+	}
+	m.suggestIdx = (m.suggestIdx + step + n) % n
+	return true
+}
+
+// suggestionsView lists the suggestions under the To field, marking the
+// selected one.
+func (m model) suggestionsView() string {
+	s := m.suggestions()
+	if len(s) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, a := range s {
+		if i == min(m.suggestIdx, len(s)-1) {
+			b.WriteString("  › " + bold.Render(a) + "\n")
+		} else {
+			b.WriteString("    " + faint.Render(a) + "\n")
+		}
+	}
+	b.WriteString(faint.Render("    tab accept • ↑/↓ choose") + "\n")
+	return b.String()
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// emailDelegate renders inbox rows. It draws the title and description like the
+// default delegate and, when enabled, a third line with the message snippet
+// truncated to snippetLen cells. showRecipient names the recipients
+// instead of the sender, for sent mail and drafts. Messages with a local flag
+// in flags get its marker before the subject. Senders and recipients are
+// shortened to display names unless fullAddresses is set. compact draws each
+// message on a single line instead, without the snippet. The row of the
+// message expandedID shows its whole snippet on up to expandedSnippetLines
+// lines, taller than the rest.
+type emailDelegate struct {
+	list.DefaultDelegate
+	showSnippet   bool
+	snippetLen    int
+	showRecipient bool
+	fullAddresses bool
+	compact       bool
+	expandedID    string
+	selected      map[string]bool
+	flags         *store.Flags
+}
+
+// expandedSnippetLines is how many lines of snippet an expanded row shows.
+const expandedSnippetLines = 4
+
+// newEmailDelegate creates the inbox delegate with the given snippet, address
+// and density settings and local flags.
+func newEmailDelegate(showSnippet bool, snippetLen int, showRecipient, fullAddresses, compact bool, flags *store.Flags) emailDelegate {
+	return emailDelegate{
+		DefaultDelegate: list.NewDefaultDelegate(),
+		showSnippet:     showSnippet && !compact,
+		snippetLen:      snippetLen,
+		showRecipient:   showRecipient,
+		fullAddresses:   fullAddresses,
+		compact:         compact,
+		flags:           flags,
+	}
+}
+
+// inboxDelegate returns the delegate for the current settings and mailbox.
+func (m model) inboxDelegate() emailDelegate {
+	outgoing := gmailx.IsOutgoingMailbox(m.query) || m.labelID == "SENT" || m.labelID == "DRAFT"
+	d := newEmailDelegate(m.settings.ShowSnippets, m.settings.SnippetLength, outgoing, m.settings.FullAddresses, m.settings.CompactRows, m.flags)
+	d.expandedID = m.expandedID
+	d.selected = m.selected
+	return d
+}
+
+// expandedExtraLines is how many lines the expanded row takes beyond the
+// height of the others.
+func (d emailDelegate) expandedExtraLines() int {
+	if d.showSnippet {
+		return expandedSnippetLines - 1
+	}
+	return expandedSnippetLines
+}
+
+// Height returns the number of lines each row occupies.
+func (d emailDelegate) Height() int {
+	switch {
+	case d.compact:
+		return 1
+	case d.showSnippet:
+		return d.DefaultDelegate.Height() + 1
+	}
+	return d.DefaultDelegate.Height()
+}
+
+// Spacing returns the number of blank lines between rows; compact rows have
+// none.
+func (d emailDelegate) Spacing() int {
+	if d.compact {
+		return 0
+	}
+	return d.DefaultDelegate.Spacing()
+}
+
+// shortDate formats a Date header for a compact row: the time for today,
+// the day for this year and the full date otherwise. Unparseable dates are
+// returned as is.
+func shortDate(s string, now time.Time) string {
+	t, err := mail.ParseDate(s)
+	if err != nil {
+		return s
+	}
+	t = t.In(now.Location())
+	switch {
+	case t.Year() == now.Year() && t.YearDay() == now.YearDay():
+		return t.Format("15:04")
+	case t.Year() == now.Year():
+		return t.Format("Jan 2")
+	}
+	return t.Format("2006-01-02")
+}
+
+// renderCompact draws e on one line as "date · sender · subject", truncated to
+// the list width and styled like the title of a two-line row.
+func (d emailDelegate) renderCompact(w io.Writer, m list.Model, index int, e emailItem) {
+	s := &d.Styles
+	style := s.NormalTitle
+	switch {
+	case m.FilterState() == list.Filtering && m.FilterValue() == "":
+		style = s.DimmedTitle
+	case index == m.Index() && m.FilterState() != list.Filtering:
+		style = s.SelectedTitle
+	}
+	width := m.Width() - style.GetPaddingLeft() - style.GetPaddingRight() - style.GetBorderLeftSize()
+	date := fitWidth(shortDate(e.date, time.Now()), compactDateWidth)
+	line := date + " · " + fitWidth(e.from, compactSenderWidth) + " · " + e.Title()
+	fmt.Fprint(w, style.Render(ansi.Truncate(line, max(width, 1), "…")))
+}
+
+// Column widths of a compact row, in terminal cells. The date column fits
+// the longest format shortDate produces.
+const (
+	compactDateWidth   = len("2006-01-02")
+	compactSenderWidth = 24
+)
+
+// fitWidth truncates s to n terminal cells, ending with an ellipsis when it
+// was cut, and pads it with spaces to exactly n cells. Widths are measured as
+// displayed, so CJK characters and emoji count as two cells and columns stay
+// aligned.
+func fitWidth(s string, n int) string {
+	s = ansi.Truncate(s, n, "…")
+	return s + strings.Repeat(" ", max(n-ansi.StringWidth(s), 0))
+}
+
+// truncateSnippet shortens s to at most n terminal cells, ending with an
+// ellipsis when it was cut. Wide characters such as CJK and emoji count as
+// two cells. A non-positive n leaves s unchanged.
+func truncateSnippet(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if n <= 0 || ansi.StringWidth(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(ansi.Truncate(s, n-1, "")) + "…"
+}
+
+// Render draws one row. The snippet line reuses the description styles so it
+// follows the selection and filtering highlight of the lines above it.
+func (d emailDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if e, ok := item.(emailItem); ok {
+		e.from = displayAddresses(e.from, d.fullAddresses)
+		if d.showRecipient {
+			e.from = "To: " + displayAddresses(e.to, d.fullAddresses)
+		}
+		if f := d.flags.Get(e.id); f != "" {
+			e.subject = flagMarker(f) + " " + e.subject
+		}
+		if d.selected[e.id] {
+			e.subject = "✓ " + e.subject
+		}
+		if e.threadSize > 1 {
+			e.subject += fmt.Sprintf(" (%d)", e.threadSize)
+		}
+		if d.compact {
+			d.renderCompact(w, m, index, e)
+			d.renderSnippet(w, m, index, e)
+			return
+		}
+		item = e
+	}
+	var buf bytes.Buffer
+	d.DefaultDelegate.Render(&buf, m, index, item)
+	_, _ = w.Write(buf.Bytes())
+
+	if e, ok := item.(emailItem); ok {
+		d.renderSnippet(w, m, index, e)
+	}
+}
+
+// renderSnippet draws the snippet lines below a row: one truncated line when
+// snippets are shown, or the whole snippet wrapped on up to
+// expandedSnippetLines lines when the row is expanded.
+func (d emailDelegate) renderSnippet(w io.Writer, m list.Model, index int, e emailItem) {
+	expanded := e.id != "" && e.id == d.expandedID
+	if (!d.showSnippet && !expanded) || m.Width() <= 0 {
+		return
+	}
+	s := &d.Styles
+	style := s.NormalDesc
+	switch {
+	case m.FilterState() == list.Filtering && m.FilterValue() == "":
+		style = s.DimmedDesc
+	case index == m.Index() && m.FilterState() != list.Filtering:
+		style = s.SelectedDesc
+	}
+	width := m.Width() - s.NormalDesc.GetPaddingLeft() - s.NormalDesc.GetPaddingRight()
+	if !expanded {
+		line := ansi.Truncate(truncateSnippet(e.snippet, d.snippetLen), width, "…")
+		fmt.Fprintf(w, "\n%s", style.Render(line))
+		return
+	}
+	snippet := strings.Join(strings.Fields(e.snippet), " ")
+	lines := strings.Split(ansi.Wordwrap(snippet, max(width, 1), ""), "\n")
+	if len(lines) > expandedSnippetLines {
+		lines = lines[:expandedSnippetLines]
+		lines[len(lines)-1] = ansi.Truncate(lines[len(lines)-1]+" …", width, "…")
+	}
+	for _, line := range lines {
+		fmt.Fprintf(w, "\n%s", style.Render(ansi.Truncate(line, width, "…")))
+	}
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestFitWidthCountsDisplayCells(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"Ana", 6, "Ana   "},
+		{"Ana Lopez Garcia", 6, "Ana L…"},
+		{"田中太郎", 8, "田中太郎"},
+		{"田中太郎", 6, "田中… "},
+		{"田中太郎さん", 9, "田中太郎…"},
+		{"🎉🎉🎉 Party", 5, "🎉🎉…"},
+		{"Zoë", 4, "Zoë "},
+	}
+	for _, tt := range tests {
+		got := fitWidth(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("fitWidth(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if w := ansi.StringWidth(got); w != tt.n {
+			t.Errorf("fitWidth(%q, %d) is %d cells wide, want %d", tt.s, tt.n, w, tt.n)
+		}
+	}
+}
+
+func TestTruncateSnippetCountsDisplayCells(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"会議の議事録を送ります", 10, "会議の議…"},
+		{"Launch 🚀🚀🚀 today", 10, "Launch 🚀…"},
+		{"  spaced\n out  ", 0, "spaced out"},
+	}
+	for _, tt := range tests {
+		got := truncateSnippet(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("truncateSnippet(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+		if tt.n > 0 && ansi.StringWidth(got) > tt.n {
+			t.Errorf("truncateSnippet(%q, %d) is %d cells wide, want at most %d", tt.s, tt.n, ansi.StringWidth(got), tt.n)
+		}
+	}
+}
+
+func TestCompactRowsAlignWithWideCharacters(t *testing.T) {
+	const width = 80
+	items := []list.Item{
+		emailItem{id: "a", subject: "Plain subject", from: "Ana <ana@example.com>", date: "2006-01-02"},
+		emailItem{id: "b", subject: "会議の議事録", from: "田中太郎 <tanaka@example.jp>", date: "2006-01-02"},
+		emailItem{id: "c", subject: "🎉 Party on Friday", from: "🎉 Events Team With A Very Long Name <events@example.com>", date: "2006-01-02"},
+		emailItem{id: "d", subject: strings.Repeat("長い件名", 20), from: "Zoë <zoe@example.com>", date: "2006-01-02"},
+	}
+	d := newEmailDelegate(false, 0, false, false, true, nil)
+	l := list.New(items, d, width, 20)
+
+	var columns []int
+	for i, it := range items {
+		var buf bytes.Buffer
+		d.Render(&buf, l, i, it)
+		line := ansi.Strip(buf.String())
+		if w := ansi.StringWidth(line); w > width {
+			t.Errorf("row %d is %d cells wide, want at most %d: %q", i, w, width, line)
+		}
+		// The subject starts after the second separator.
+		first := strings.Index(line, " · ")
+		second := strings.Index(line[first+len(" · "):], " · ")
+		if first < 0 || second < 0 {
+			t.Fatalf("row %d = %q, want date · sender · subject", i, line)
+		}
+		columns = append(columns, ansi.StringWidth(line[:first+len(" · ")+second]))
+	}
+	for i, c := range columns {
+		if c != columns[0] {
+			t.Errorf("row %d's subject starts at cell %d, want %d like row 0", i, c, columns[0])
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// expandTickMsg fires when the cursor has rested on the row id long enough to
+// expand it.
+type expandTickMsg struct {
+	seq int
+	id  string
+}
+
+// scheduleExpand collapses the expanded row and, when snippet expansion is
+// enabled, starts the timer to expand the selected one once the cursor stops
+// on it. Like the split view preview it is debounced, so rows passed over on
+// the way are never expanded. Only the snippet already fetched is shown.
+func (m *model) scheduleExpand() tea.Cmd {
+	it, ok := m.inbox.SelectedItem().(emailItem)
+	if ok && it.id == m.expandedID {
+		return nil
+	}
+	m.setExpanded("")
+	if !m.settings.ExpandSnippets || !ok {
+		return nil
+	}
+	m.expandSeq++
+	seq := m.expandSeq
+	delay := time.Duration(m.settings.ExpandDelayMS) * time.Millisecond
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return expandTickMsg{seq: seq, id: it.id}
+	})
+}
+
+// expandRow expands the row the timer was started for if the cursor is still
+// on it.
+func (m *model) expandRow(msg expandTickMsg) {
+	if msg.seq != m.expandSeq || m.screen != screenInbox || m.splitActive() {
+		return
+	}
+	if it, ok := m.inbox.SelectedItem().(emailItem); ok && it.id == msg.id {
+		m.setExpanded(msg.id)
+	}
+}
+
+// setExpanded makes id the expanded row, or collapses it when id is empty.
+// The list is shortened by the extra lines of the expanded row so it still
+// fits on screen, keeping the same message selected.
+func (m *model) setExpanded(id string) {
+	if id == m.expandedID {
+		return
+	}
+	m.expandedID = id
+	m.inbox.SetDelegate(m.inboxDelegate())
+	idx := m.inbox.Index()
+	m.resize()
+	m.inbox.Select(idx)
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/store"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pdfRenderers are the headless renderers tried, in order, to turn an HTML
+// export into a PDF. The export works without any of them.
+var pdfRenderers = []string{"chromium", "chromium-browser", "google-chrome", "wkhtmltopdf"}
+
+// pdfTimeout bounds the external PDF renderer, which can hang on odd input.
+const pdfTimeout = time.Minute
+
+type exportedMsg struct {
+	path string
+	pdf  string
+	err  error
+}
+
+// renderPDF converts the HTML file at src into a PDF next to it using the first
+// available renderer. Returns "" when no renderer is installed or it fails.
+func renderPDF(ctx context.Context, src string) string {
+	dst := strings.TrimSuffix(src, ".html") + ".pdf"
+	for _, name := range pdfRenderers {
+		bin, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		var cmd *exec.Cmd
+		if name == "wkhtmltopdf" {
+			cmd = exec.CommandContext(ctx, bin, "--quiet", src, dst)
+		} else {
+			cmd = exec.CommandContext(ctx, bin, "--headless", "--disable-gpu", "--print-to-pdf="+dst, "file://"+src)
+		}
+		if cmd.Run() == nil {
+			return dst
+		}
+	}
+	return ""
+}
+
+// exportCmd creates a command that saves a message as a standalone HTML file
+// under the exports directory and, when a headless renderer is installed, as a
+// PDF too. Uses the configured timeout for the API calls.
+func (m model) exportCmd(id string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return exportedMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return exportedMsg{err: err}
+		}
+		doc, subject, err := c.ExportHTML(ctx, id)
+		if err != nil {
+			return exportedMsg{err: err}
+		}
+		path, err := store.ExportPath(subject+"-"+id, ".html")
+		if err != nil {
+			return exportedMsg{err: err}
+		}
+		if err := os.WriteFile(path, doc, 0600); err != nil {
+			return exportedMsg{err: err}
+		}
+		pdfCtx, cancelPDF := context.WithTimeout(root, pdfTimeout)
+		defer cancelPDF()
+		return exportedMsg{path: path, pdf: renderPDF(pdfCtx, path)}
+	}
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type filterItem struct {
+	filter gmailx.Filter
+	labels map[string]string
+}
+
+// Title returns the filter's matching criteria for display in the list.
+func (f filterItem) Title() string { return filterCriteria(f.filter) }
+
+// Description returns the filter's actions for display in the list.
+func (f filterItem) Description() string { return filterActions(f.filter, f.labels) }
+
+// FilterValue returns the criteria and actions so filters can be searched by either.
+func (f filterItem) FilterValue() string { return f.Title() + " " + f.Description() }
+
+type filtersMsg struct {
+	items []list.Item
+	err   error
+}
+
+// filterCriteria renders a filter's criteria in Gmail search syntax,
+// e.g. `from:boss@example.com subject:"weekly report" has:attachment`.
+func filterCriteria(f gmailx.Filter) string {
+	var parts []string
+	add := func(op, v string) {
+		if v == "" {
+			return
+		}
+		if strings.ContainsAny(v, " \t") {
+			v = `"` + v + `"`
+		}
+		parts = append(parts, op+v)
+	}
+	add("from:", f.From)
+	add("to:", f.To)
+	add("subject:", f.Subject)
+	if f.Query != "" {
+		parts = append(parts, f.Query)
+	}
+	if f.NegatedQuery != "" {
+		parts = append(parts, "-("+f.NegatedQuery+")")
+	}
+	if f.HasAttachment {
+		parts = append(parts, "has:attachment")
+	}
+	if len(parts) == 0 {
+		return "(matches everything)"
+	}
+	return strings.Join(parts, " ")
+}
+
+// labelName resolves a label ID to its display name, falling back to the ID
+// when the label isn't known.
+func labelName(labels map[string]string, id string) string {
+	if n, ok := labels[id]; ok {
+		return n
+	}
+	return id
+}
+
+// filterActions renders a filter's actions as a short human-readable summary.
+// Well-known system label changes are shown by what they mean to the user,
+// such as removing INBOX being "archive".
+func filterActions(f gmailx.Filter, labels map[string]string) string {
+	var parts []string
+	for _, id := range f.RemoveLabelIDs {
+		switch id {
+		case "INBOX":
+			parts = append(parts, "archive")
+		case "UNREAD":
+			parts = append(parts, "mark read")
+		default:
+			parts = append(parts, "remove "+labelName(labels, id))
+		}
+	}
+	for _, id := range f.AddLabelIDs {
+		switch id {
+		case "STARRED":
+			parts = append(parts, "star")
+		case "IMPORTANT":
+			parts = append(parts, "mark important")
+		case "TRASH":
+			parts = append(parts, "delete")
+		case "SPAM":
+			parts = append(parts, "mark spam")
+		default:
+			parts = append(parts, "label "+labelName(labels, id))
+		}
+	}
+	if f.Forward != "" {
+		parts = append(parts, "forward to "+f.Forward)
+	}
+	if len(parts) == 0 {
+		return "(no actions)"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// filterDetail renders every criterion and action of a filter, one per line,
+// for the expanded view.
+func filterDetail(f gmailx.Filter, labels map[string]string) string {
+	out := "Filter " + f.ID + "\n\nCriteria:\n"
+	row := func(name, v string) {
+		if v != "" {
+			out += "  " + name + v + "\n"
+		}
+	}
+	row("From:           ", f.From)
+	row("To:             ", f.To)
+	row("Subject:        ", f.Subject)
+	row("Has words:      ", f.Query)
+	row("Doesn't have:   ", f.NegatedQuery)
+	if f.HasAttachment {
+		row("Has attachment: ", "yes")
+	}
+	out += "\nActions:\n"
+	for _, id := range f.AddLabelIDs {
+		row("Add label:      ", labelName(labels, id))
+	}
+	for _, id := range f.RemoveLabelIDs {
+		row("Remove label:   ", labelName(labels, id))
+	}
+	row("Forward to:     ", f.Forward)
+	return out
+}
+
+// fetchFiltersCmd creates a command that fetches the user's server-side filters.
+// Labels are fetched alongside so label actions can be shown by name instead of ID.
+// Uses the configured timeout for the API call.
+func (m model) fetchFiltersCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return filtersMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return filtersMsg{err: err}
+		}
+		filters, err := c.ListFilters(ctx)
+		if err != nil {
+			return filtersMsg{err: err}
+		}
+		names := map[string]string{}
+		if labels, err := c.ListLabels(ctx); err == nil {
+			for _, l := range labels {
+				names[l.ID] = l.Name
+			}
+		}
+		items := make([]list.Item, 0, len(filters))
+		for _, f := range filters {
+			items = append(items, filterItem{filter: f, labels: names})
+		}
+		return filtersMsg{items: items}
+	}
+}
+
+// updateFilters handles key presses on the filters screen. enter expands the
+// selected filter into a detail view and b collapses it or returns to the inbox.
+func (m model) updateFilters(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filterExpanded {
+		switch msg.String() {
+		case "b", "esc", "enter":
+			m.filterExpanded = false
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.detailVP, cmd = m.detailVP.Update(msg)
+		return m, cmd
+	}
+
+	if m.filters.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.filters, cmd = m.filters.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "b":
+		m.screen = screenInbox
+		return m, nil
+	case "r":
+		return m, m.fetchFiltersCmd()
+	case "enter":
+		if it, ok := m.filters.SelectedItem().(filterItem); ok {
+			m.detailVP.SetContent(filterDetail(it.filter, it.labels))
+			m.detailVP.GotoTop()
+			m.filterExpanded = true
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.filters, cmd = m.filters.Update(msg)
+	return m, cmd
+}
+
+// filtersView renders the filters list, or the expanded detail of one filter.
+func (m model) filtersView() string {
+	if m.filterExpanded {
+		return faint.Render("b back • q quit") + "\n\n" + m.detailVP.View()
+	}
+	return m.footer() + "\n\n" + m.filters.View()
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var findHighlight = lipgloss.NewStyle().Reverse(true)
+
+// newFindInput creates the input for searching within the open message.
+func newFindInput() textinput.Model {
+	in := textinput.New()
+	in.Prompt = "find: "
+	in.Placeholder = "text in this message"
+	in.Width = 40
+	return in
+}
+
+// setDetailText shows s in the detail viewport, keeping the unstyled text so
+// an in-message search can highlight it and re-run when it changes.
+func (m *model) setDetailText(s string) {
+	m.detailText = s
+	m.renderFind()
+}
+
+// renderFind highlights every case-insensitive match of the find query in the
+// detail text, records the lines they are on and refreshes the viewport. Quote
+// folds without a match are dimmed here too, since the text itself is plain.
+// The viewport doesn't wrap, so a line number is also its scroll offset.
+func (m *model) renderFind() {
+	m.findLines = nil
+	var re *regexp.Regexp
+	if m.findQuery != "" {
+		re = regexp.MustCompile("(?i)" + regexp.QuoteMeta(m.findQuery))
+	}
+	lines := strings.Split(m.detailText, "\n")
+	for i, l := range lines {
+		switch {
+		case re != nil && re.MatchString(l):
+			m.findLines = append(m.findLines, i)
+			lines[i] = re.ReplaceAllStringFunc(l, func(s string) string { return findHighlight.Render(s) })
+		case foldRE.MatchString(l):
+			lines[i] = faint.Render(l)
+		}
+	}
+	m.detailVP.SetContent(strings.Join(lines, "\n"))
+	if m.findIdx >= len(m.findLines) {
+		m.findIdx = 0
+	}
+}
+
+// clearFind ends the in-message search and removes its highlighting.
+func (m *model) clearFind() {
+	m.finding = false
+	m.findInput.Blur()
+	m.findInput.SetValue("")
+	m.findQuery = ""
+	m.findIdx = 0
+	m.renderFind()
+}
+
+// findStep moves to the next match after the current one, or the previous one
+// when step is -1, wrapping around at either end, and scrolls to it.
+func (m *model) findStep(step int) {
+	n := len(m.findLines)
+	if n == 0 {
+		if m.findQuery != "" {
+			m.status = "No matches for " + m.findQuery
+		}
+		return
+	}
+	m.findIdx = (m.findIdx + step + n) % n
+	m.detailVP.SetYOffset(m.findLines[m.findIdx])
+	m.status = fmt.Sprintf("Match %d/%d for %q (n next • N previous • esc clear)", m.findIdx+1, n, m.findQuery)
+}
+
+// updateFind handles keys while the find input is focused: enter searches
+// and jumps to the first match at or below the current scroll position, esc
+// cancels the search.
+func (m model) updateFind(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.clearFind()
+		m.status = ""
+		return m, nil
+	case "enter":
+		m.finding = false
+		m.findInput.Blur()
+		m.findQuery = m.findInput.Value()
+		m.findIdx = 0
+		m.renderFind()
+		if m.findQuery == "" {
+			m.status = ""
+			return m, nil
+		}
+		for i, l := range m.findLines {
+			if l >= m.detailVP.YOffset {
+				m.findIdx = i
+				break
+			}
+		}
+		m.findStep(0)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.findInput, cmd = m.findInput.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// localFlag is a local flag that can be put on a message, drawn as a marker in
+// its color in the inbox list.
+type localFlag struct {
+	name  string
+	color lipgloss.Color
+}
+
+// localFlags are the flags m cycles through, in order.
+var localFlags = []localFlag{
+	{name: "follow up", color: lipgloss.Color("9")},
+	{name: "waiting", color: lipgloss.Color("11")},
+	{name: "reference", color: lipgloss.Color("12")},
+	{name: "done", color: lipgloss.Color("10")},
+}
+
+// nextFlag returns the flag after name in localFlags, "" after the last one
+// and the first one after "".
+func nextFlag(name string) string {
+	for i, f := range localFlags {
+		if f.name == name {
+			if i+1 < len(localFlags) {
+				return localFlags[i+1].name
+			}
+			return ""
+		}
+	}
+	return localFlags[0].name
+}
+
+// flagMarker renders the marker for a flag, or "" for no flag. Flags no longer
+// in localFlags, e.g. from an older version, get an uncolored marker.
+func flagMarker(name string) string {
+	if name == "" {
+		return ""
+	}
+	style := lipgloss.NewStyle()
+	for _, f := range localFlags {
+		if f.name == name {
+			style = style.Foreground(f.color)
+		}
+	}
+	return style.Render("⚑")
+}
+
+// cycleFlag moves the selected inbox message to its next local flag.
+func (m *model) cycleFlag() {
+	e, ok := m.inbox.SelectedItem().(emailItem)
+	if !ok {
+		return
+	}
+	flag := nextFlag(m.flags.Get(e.id))
+	if err := m.flags.Set(e.id, flag); err != nil {
+		m.status = "Couldn't save the flag: " + err.Error()
+		return
+	}
+	m.status = "Flagged " + flag
+	if flag == "" {
+		m.status = "Flag cleared"
+	}
+}
+
+// cycleFlagFilter shows only the loaded messages with the next local flag, or
+// every message again after the last one.
+func (m *model) cycleFlagFilter() {
+	m.flagFilter = nextFlag(m.flagFilter)
+	m.refilter()
+	m.status = "Showing messages flagged " + m.flagFilter
+	if m.flagFilter == "" {
+		m.status = "Showing all flags"
+	}
+}
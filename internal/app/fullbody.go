@@ -0,0 +1,92 @@
+package app
+
+import (
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type fullBodyMsg struct {
+	id     string
+	detail *gmailx.EmailDetail
+	err    error
+}
+
+// fullBodyCmd creates a command that fetches a message again without the body
+// cap. Uses the configured timeout for the API call.
+func (m model) fullBodyCmd(id string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	metadataOnly := m.metadataOnly()
+	cache := m.cache
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return fullBodyMsg{id: id, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return fullBodyMsg{id: id, err: err}
+		}
+		d, err := getDetail(ctx, c, id, 0, metadataOnly)
+		if err != nil {
+			return fullBodyMsg{id: id, err: err}
+		}
+		if cache != nil {
+			_ = cache.Put(detailCacheKey(id), d)
+		}
+		return fullBodyMsg{id: id, detail: d}
+	}
+}
+
+// loadFullBody starts loading the rest of the open, truncated message in the
+// background, with a spinner in the header while it runs. The message stays
+// readable meanwhile.
+func (m *model) loadFullBody() tea.Cmd {
+	if m.detail == nil || !truncated(m.detail) || m.loadingFull != "" {
+		return nil
+	}
+	m.loadingFull = m.detail.ID
+	m.status = ""
+	return tea.Batch(m.fullBodyCmd(m.detail.ID), m.bodySpinner.Tick)
+}
+
+// fullBodyLoaded replaces the truncated body with the full one, keeping the
+// scroll position, if the message is still open.
+func (m *model) fullBodyLoaded(msg fullBodyMsg) {
+	if msg.id != m.loadingFull {
+		return
+	}
+	m.loadingFull = ""
+	if msg.err != nil {
+		m.status = "Couldn't load the full message: " + msg.err.Error()
+		return
+	}
+	if m.detail == nil || m.detail.ID != msg.id {
+		return
+	}
+	m.detail = msg.detail
+	if m.threadView {
+		return
+	}
+	off := m.detailVP.YOffset
+	m.setDetailText(m.detailContent())
+	m.detailVP.SetYOffset(off)
+}
+
+// updateBodySpinner advances the full-body spinner while a load is running.
+func (m *model) updateBodySpinner(msg spinner.TickMsg) tea.Cmd {
+	if m.loadingFull == "" {
+		return nil
+	}
+	var cmd tea.Cmd
+	m.bodySpinner, cmd = m.bodySpinner.Update(msg)
+	return cmd
+}
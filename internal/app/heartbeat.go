@@ -0,0 +1,81 @@
+package app
+
+import (
+	"log/slog"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/oauth2"
+)
+
+// tokenHeartbeat is how often the session token is checked for a refresh
+// that needs saving.
+const tokenHeartbeat = time.Minute
+
+// tokenHeartbeatMsg is the tick of the token heartbeat.
+type tokenHeartbeatMsg struct{}
+
+// tokenCheckedMsg reports a heartbeat check of the token from. tok is set
+// when it was refreshed and saved.
+type tokenCheckedMsg struct {
+	from *oauth2.Token
+	tok  *oauth2.Token
+	err  error
+}
+
+// scheduleTokenHeartbeat starts the timer for the next token check.
+func scheduleTokenHeartbeat() tea.Cmd {
+	return tea.Tick(tokenHeartbeat, func(time.Time) tea.Msg {
+		return tokenHeartbeatMsg{}
+	})
+}
+
+// tokenHeartbeatCmd refreshes the session token once it has expired and
+// saves the new one right away. API commands refresh their own copy without
+// saving it, so without this a rotated refresh token could be lost if the
+// app crashed before the next login saved one.
+// Uses the configured timeout for the refresh.
+func (m model) tokenHeartbeatCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	st := m.store
+	events := m.refreshEvents
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil || st == nil {
+			return tokenCheckedMsg{from: tok}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		fresh, err := gmailx.CurrentToken(ctx, cfg, tok, events)
+		if err != nil {
+			return tokenCheckedMsg{from: tok, err: err}
+		}
+		if fresh.AccessToken == tok.AccessToken && fresh.RefreshToken == tok.RefreshToken {
+			return tokenCheckedMsg{from: tok}
+		}
+		if err := st.Save(fresh); err != nil {
+			return tokenCheckedMsg{from: tok, err: err}
+		}
+		return tokenCheckedMsg{from: tok, tok: fresh}
+	}
+}
+
+// tokenChecked adopts a refreshed token for later commands, unless the user
+// logged in again meanwhile, and schedules the next check. Failures are only
+// logged: the next API command reports them if they persist.
+func (m *model) tokenChecked(msg tokenCheckedMsg) tea.Cmd {
+	if msg.err != nil {
+		slog.Warn("token heartbeat failed", "err", msg.err)
+	}
+	if msg.tok != nil && m.token == msg.from {
+		slog.Debug("saved refreshed token", "expiry", msg.tok.Expiry)
+		m.token = msg.tok
+	}
+	return scheduleTokenHeartbeat()
+}
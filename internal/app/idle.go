@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// idleTickMsg is the tick of the idle logout timer seq.
+type idleTickMsg struct {
+	seq int
+}
+
+// idleLimit is how long the app may go without input before logging out, 0
+// when idle logout is off.
+func (m model) idleLimit() time.Duration {
+	return time.Duration(m.settings.IdleLogoutMinutes) * time.Minute
+}
+
+// scheduleIdleCheck starts the timer that checks for inactivity after wait,
+// if idle logout is on. Key presses don't restart it; each check works out
+// from the last input when the next one is due.
+func (m model) scheduleIdleCheck(wait time.Duration) tea.Cmd {
+	if m.idleLimit() <= 0 {
+		return nil
+	}
+	seq := m.idleSeq
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return idleTickMsg{seq: seq}
+	})
+}
+
+// idleTick logs out once the app has been idle for the configured period,
+// and schedules the next check.
+func (m *model) idleTick(msg idleTickMsg) tea.Cmd {
+	limit := m.idleLimit()
+	if msg.seq != m.idleSeq || limit <= 0 {
+		return nil
+	}
+	if idle := time.Since(m.lastInput); idle < limit {
+		return m.scheduleIdleCheck(limit - idle)
+	}
+	m.idleLogout()
+	return m.scheduleIdleCheck(limit)
+}
+
+// idleLogout ends the session after inactivity, for shared machines: the
+// token and the mail on screen are dropped, and the saved token is deleted
+// too if configured, so someone else at the terminal must log in to see more.
+func (m *model) idleLogout() {
+	if m.token == nil {
+		return
+	}
+	slog.Info("logging out after inactivity", "minutes", m.settings.IdleLogoutMinutes)
+	if m.settings.IdleLogoutForgetToken {
+		m.forgetToken()
+	}
+	m.endSession()
+	m.err = nil
+	m.status = fmt.Sprintf("Logged out after %d minutes without input — press l to log in again", m.settings.IdleLogoutMinutes)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// inboxStream carries the rows of one inbox fetch from the goroutine loading
+// them to Update. cancel stops the fetch when a newer one replaces it.
+type inboxStream struct {
+	ch     chan tea.Msg
+	cancel context.CancelFunc
+}
+
+// inboxStartMsg reports that the message IDs of a page have been listed and
+// their rows are about to stream in.
+type inboxStartMsg struct {
+	stream *inboxStream
+	total  int
+	// nextPageToken and estimate describe the listing for pagination.
+	nextPageToken string
+	estimate      int64
+}
+
+// inboxRowMsg delivers one loaded row, in list order.
+type inboxRowMsg struct {
+	stream *inboxStream
+	row    gmailx.EmailRow
+}
+
+// inboxDoneMsg reports that the stream has ended, either because every row was
+// delivered or because the fetch was cancelled or timed out.
+type inboxDoneMsg struct {
+	stream *inboxStream
+}
+
+// fetchInboxCmd creates a command that fetches one page of emails from the Gmail inbox.
+// Uses the current search query if one is set, and the current page's token. The page is listed first, then each
+// row's metadata is loaded and streamed to the list as it arrives, in order, so rows
+// show up progressively on slow connections. The configured timeout covers the whole page.
+// Completed fetches replace the on-disk inbox cache; if the network is unreachable
+// the cached rows are returned instead, flagged as offline.
+func (m model) fetchInboxCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	q := gmailx.ExpandQuery(m.query)
+	cache := m.cache
+	pageSize := m.settings.PageSize
+	pageToken := m.pageToken()
+	labelID := m.labelID
+	everywhere := m.everywhere
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return inboxMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			cancel()
+			return inboxMsg{err: err}
+		}
+		page, err := c.ListInboxPage(ctx, pageSize, q, labelID, pageToken, everywhere)
+		ids := page.IDs
+		if err != nil {
+			cancel()
+			var cached []gmailx.EmailRow
+			if gmailx.IsNetworkError(err) && cache != nil && cache.Get(inboxCacheKey, &cached) == nil {
+				return inboxMsg{items: rowsToItems(cached), offline: true}
+			}
+			return inboxMsg{err: err}
+		}
+
+		s := &inboxStream{ch: make(chan tea.Msg), cancel: cancel}
+		go func() {
+			defer cancel()
+			defer close(s.ch)
+			send := func(msg tea.Msg) bool {
+				select {
+				case s.ch <- msg:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			rows := make([]gmailx.EmailRow, 0, len(ids))
+			for _, id := range ids {
+				row, err := c.GetRow(ctx, id)
+				if err != nil {
+					slog.Warn("skipping message that failed to fetch", "id", id, "err", err)
+					continue
+				}
+				rows = append(rows, row)
+				if !send(inboxRowMsg{stream: s, row: row}) {
+					return
+				}
+			}
+			if cache != nil {
+				_ = cache.Put(inboxCacheKey, rows)
+			}
+		}()
+		return inboxStartMsg{stream: s, total: len(ids), nextPageToken: page.NextPageToken, estimate: page.Estimate}
+	}
+}
+
+// waitInboxStream creates a command that delivers the next message of a stream,
+// or an inboxDoneMsg once the loading goroutine has finished and closed it.
+func waitInboxStream(s *inboxStream) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-s.ch
+		if !ok {
+			return inboxDoneMsg{stream: s}
+		}
+		return msg
+	}
+}
+
+// pageToken returns the token of the current inbox page, "" for the first.
+func (m model) pageToken() string {
+	if len(m.pageTokens) == 0 {
+		return ""
+	}
+	return m.pageTokens[len(m.pageTokens)-1]
+}
+
+// setLabel restricts the inbox listing to a label, or lifts the restriction
+// when id is empty, clearing the query and going back to the first page.
+func (m *model) setLabel(id, name string) {
+	m.labelID = id
+	m.labelName = name
+	m.everywhere = false
+	m.setQuery("")
+}
+
+// setQuery changes the inbox query and goes back to its first page. Rows name
+// their recipients instead of the sender when the query lists sent mail or drafts.
+func (m *model) setQuery(q string) {
+	m.query = q
+	m.inbox.SetDelegate(m.inboxDelegate())
+	m.pageTokens = nil
+	m.nextPageToken = ""
+	m.resultEstimate = 0
+}
+
+// changePage moves to the next inbox page, or the previous one when step is
+// -1, and fetches it. Visited page tokens are kept on a stack because Gmail
+// only hands out tokens for the following page.
+func (m *model) changePage(step int) tea.Cmd {
+	switch {
+	case step > 0 && m.nextPageToken == "":
+		m.status = "Last page"
+		return nil
+	case step > 0:
+		m.pageTokens = append(m.pageTokens, m.nextPageToken)
+	case len(m.pageTokens) == 0:
+		m.status = "First page"
+		return nil
+	default:
+		m.pageTokens = m.pageTokens[:len(m.pageTokens)-1]
+	}
+	m.status = ""
+	return m.fetchInboxCmd()
+}
+
+// pageInfo describes the current inbox page and Gmail's estimate of the total
+// number of results, e.g. "Page 2 · ~312 results". Returns "" on a single page.
+func (m model) pageInfo() string {
+	page := len(m.pageTokens) + 1
+	if page == 1 && m.nextPageToken == "" {
+		return ""
+	}
+	s := fmt.Sprintf("Page %d", page)
+	if m.resultEstimate > 0 {
+		s += fmt.Sprintf(" · ~%d results (estimate)", m.resultEstimate)
+	}
+	return s
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// bind is shorthand for a key.Binding with one or more keys and a help entry.
+// The first key is the one the command palette sends when the action is chosen.
+func bind(help string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(keys[0], help))
+}
+
+// keyRegistry lists the actions available on each screen, most important first.
+// It is the single source for the footer, the ? help overlay and the command
+// palette. Add a binding here when adding a key to a screen's update function.
+var keyRegistry = map[screen][]key.Binding{
+	screenInbox: {
+		bind("open", "enter"),
+		bind("search", "/"),
+		bind("triage & next", "e"),
+		bind("refresh", "r"),
+		bind("next page", "n"),
+		bind("previous page", "p"),
+		bind("compose", "c"),
+		bind("write to sender", "C"),
+		bind("labels", "g"),
+		bind("open by link or ID", "G"),
+		bind("label quick-pick", "l"),
+		bind("next category (label quick-pick is l)", "f"),
+		bind("account info", "i"),
+		bind("split view", "v"),
+		bind("labels sidebar", "L"),
+		bind("previous label", "["),
+		bind("next label", "]"),
+		bind("attachments only", "a"),
+		bind("snippets", "s"),
+		bind("compact rows", "d"),
+		bind("full addresses", "@"),
+		bind("hide read", "h"),
+		bind("select", "x"),
+		bind("headers of selected", "H"),
+		bind("flag", "m"),
+		bind("show flag", "M"),
+		key.NewBinding(key.WithKeys("0", "1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("0-9", "go to row")),
+		bind("mark loaded read", "ctrl+r"),
+		bind("mark all read", "ctrl+a"),
+		bind("archive all matching", "ctrl+e"),
+		bind("filters", "F"),
+		bind("signature", "S"),
+		bind("vacation", "V"),
+		bind("dry run", "D"),
+		bind("reload config", "R"),
+	},
+	screenDetail: {
+		bind("back", "b"),
+		bind("find", "/"),
+		bind("next match", "n"),
+		bind("previous match", "N"),
+		bind("clear find", "esc"),
+		bind("thread", "t"),
+		bind("thread order", "O"),
+		bind("reply", "R"),
+		key.NewBinding(key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"), key.WithHelp("1-9", "canned reply")),
+		bind("mute/unmute thread", "m"),
+		bind("report phishing", "!"),
+		bind("reload", "r"),
+		bind("quoted text", "z"),
+		bind("raw headers", "H"),
+		bind("header detail", "v"),
+		bind("full addresses", "@"),
+		bind("body view", "s"),
+		bind("copy link", "y"),
+		bind("export", "E"),
+		bind("open attachment", "o"),
+		bind("next attachment", "a"),
+		bind("attachments", "A"),
+		bind("load full body", "X"),
+	},
+	screenLabels: {
+		bind("show label", "enter"),
+		bind("back", "b"),
+		bind("refresh", "r"),
+	},
+	screenFilters: {
+		bind("details", "enter"),
+		bind("back", "b"),
+		bind("refresh", "r"),
+	},
+	screenAttachments: {
+		bind("open", "enter", "o"),
+		bind("download", "d"),
+		bind("download all", "D"),
+		bind("back", "b"),
+	},
+	screenSummary: {
+		bind("back", "b", "esc"),
+		bind("scroll", "up", "down"),
+	},
+	screenProfile: {
+		bind("back", "b"),
+		bind("refresh", "r"),
+	},
+	screenSearch: {
+		bind("apply", "enter"),
+		bind("everywhere", "ctrl+t"),
+		bind("cancel", "esc"),
+	},
+	screenCompose: {
+		bind("next field", "tab"),
+		bind("next suggestion", "down"),
+		bind("previous suggestion", "up"),
+		bind("template", "ctrl+t"),
+		bind("format", "ctrl+o"),
+		bind("send", "ctrl+s"),
+		bind("discard", "esc"),
+	},
+	screenVacation: {
+		bind("next field", "tab"),
+		bind("toggle", "ctrl+t"),
+		bind("save", "ctrl+s"),
+		bind("back", "esc"),
+	},
+	screenSignature: {
+		bind("save", "ctrl+s"),
+		bind("back", "esc"),
+	},
+}
+
+// globalKeys work on every non-typing screen. They are shown in the footer
+// and help but not offered in the palette, which they would only reopen.
+var globalKeys = []key.Binding{
+	bind("commands", ":", "ctrl+p"),
+	bind("help", "?"),
+	bind("quit", "q"),
+}
+
+// activeBindings returns the current screen's bindings with those that can't
+// be used right now disabled, so the footer, help and palette only offer what
+// would work.
+func (m model) activeBindings() []key.Binding {
+	reg := keyRegistry[m.screen]
+	out := make([]key.Binding, len(reg))
+	for i, b := range reg {
+		if m.keyUnavailable(b.Keys()[0]) {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenCompose && (b.Keys()[0] == "down" || b.Keys()[0] == "up") && len(m.suggestions()) == 0 {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenDetail && b.Keys()[0] == "1" && len(m.settings.CannedResponses) == 0 {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenInbox && b.Keys()[0] == "H" && len(m.selected) == 0 {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenInbox && (b.Keys()[0] == "[" || b.Keys()[0] == "]") && !m.sidebarActive() {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenDetail && b.Keys()[0] == "O" && !m.threadView {
+			b.SetEnabled(false)
+		}
+		if b.Keys()[0] == "X" && (m.detail == nil || !truncated(m.detail) || m.loadingFull != "") {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenDetail && (b.Keys()[0] == "n" || b.Keys()[0] == "N" || b.Keys()[0] == "esc") && m.findQuery == "" {
+			b.SetEnabled(false)
+		}
+		if m.screen == screenDetail && (b.Keys()[0] == "o" || b.Keys()[0] == "a" || b.Keys()[0] == "A") && (m.detail == nil || len(m.detail.Attachments) == 0) {
+			b.SetEnabled(false)
+		}
+		out[i] = b
+	}
+	return out
+}
+
+// footer renders the short help line for the current screen, dropping the
+// bindings that don't fit the terminal width.
+func (m model) footer() string {
+	bindings := m.activeBindings()
+	if !m.typing() {
+		bindings = append(bindings, globalKeys...)
+	}
+	h := help.New()
+	h.Width = max(m.width-8, 20)
+	return h.ShortHelpView(bindings)
+}
+
+// helpView renders every binding of the current screen, for the ? overlay.
+func (m model) helpView() string {
+	h := help.New()
+	h.Width = max(m.width-8, 20)
+	return "Keys\n\n" + h.FullHelpView([][]key.Binding{m.activeBindings(), globalKeys}) +
+		"\n\n" + faint.Render("any key close")
+}
+
+// namedKeys maps the key names used in the registry to their key types, for
+// keys that aren't a single printable character.
+var namedKeys = map[string]tea.KeyType{
+	"enter":  tea.KeyEnter,
+	"esc":    tea.KeyEsc,
+	"tab":    tea.KeyTab,
+	"ctrl+a": tea.KeyCtrlA,
+	"ctrl+e": tea.KeyCtrlE,
+	"ctrl+o": tea.KeyCtrlO,
+	"ctrl+r": tea.KeyCtrlR,
+	"ctrl+s": tea.KeyCtrlS,
+	"ctrl+t": tea.KeyCtrlT,
+}
+
+// keyMsgFor builds the key press for a registry key name, so an action picked
+// from the palette runs through the same code path as pressing its key.
+func keyMsgFor(k string) tea.KeyMsg {
+	if t, ok := namedKeys[k]; ok {
+		return tea.KeyMsg{Type: t}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
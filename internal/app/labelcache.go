@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openLabels shows the labels screen. Labels rarely change, so the ones
+// fetched earlier are shown right away; r on the labels screen fetches them
+// again. They are only fetched here the first time.
+func (m *model) openLabels() tea.Cmd {
+	if m.labelsAt.IsZero() {
+		return m.fetchLabelsCmd()
+	}
+	m.screen = screenLabels
+	return nil
+}
+
+// labelsAge describes how long ago the labels were fetched, for the labels
+// screen header.
+func (m model) labelsAge() string {
+	if m.labelsAt.IsZero() {
+		return ""
+	}
+	return "labels as of " + ago(time.Since(m.labelsAt))
+}
+
+// ago formats d as a rough age such as "3m ago".
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newLabelPick creates the list backing the label quick-pick.
+func newLabelPick() list.Model {
+	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Filter by label"
+	l.SetShowHelp(false)
+	return l
+}
+
+// openLabelPick shows the label quick-pick over the inbox. The labels loaded
+// for the labels screen are reused, so they are only fetched the first time.
+func (m *model) openLabelPick() tea.Cmd {
+	m.labelPickOpen = true
+	if len(m.labels.Items()) == 0 {
+		m.status = "Loading labels..."
+		fetch := m.fetchLabelsCmd()
+		return func() tea.Msg {
+			msg := fetch().(labelsMsg)
+			msg.pick = true
+			return msg
+		}
+	}
+	return m.fillLabelPick(m.labels.Items())
+}
+
+// labelPickLoaded handles labels fetched for the quick-pick, keeping them for
+// next time and showing them if the quick-pick is still open.
+func (m *model) labelPickLoaded(msg labelsMsg) tea.Cmd {
+	if msg.err != nil {
+		if m.labelPickOpen {
+			m.labelPickOpen = false
+			m.status = "Couldn't load labels: " + msg.err.Error()
+		}
+		return nil
+	}
+	m.labels.SetItems(msg.items)
+	m.labelsAt = time.Now()
+	if !m.labelPickOpen {
+		return nil
+	}
+	m.status = ""
+	return m.fillLabelPick(msg.items)
+}
+
+// fillLabelPick puts items in the quick-pick and starts filtering so the user
+// can type a label name right away.
+func (m *model) fillLabelPick(items []list.Item) tea.Cmd {
+	m.labelPick.ResetFilter()
+	m.labelPick.ResetSelected()
+	cmd := m.labelPick.SetItems(items)
+	var filterCmd tea.Cmd
+	m.labelPick, filterCmd = m.labelPick.Update(keyMsgFor("/"))
+	return tea.Batch(cmd, filterCmd)
+}
+
+// updateLabelPick handles key presses while the quick-pick is open. enter
+// filters the inbox by the highlighted label and esc closes the quick-pick.
+func (m model) updateLabelPick(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.labelPickOpen = false
+		m.status = ""
+		return m, nil
+	case "enter":
+		it, ok := m.labelPick.SelectedItem().(labelItem)
+		if !ok {
+			return m, nil
+		}
+		m.labelPickOpen = false
+		m.status = ""
+		m.setLabel(it.id, it.name)
+		return m, m.fetchInboxCmd()
+	}
+	var cmd tea.Cmd
+	m.labelPick, cmd = m.labelPick.Update(msg)
+	return m, cmd
+}
+
+// labelPickView renders the label quick-pick.
+func (m model) labelPickView() string {
+	s := m.labelPick.View() + "\n" + faint.Render("type to filter • enter show label • esc close")
+	if m.status != "" {
+		s += "\n" + faint.Render(m.status)
+	}
+	return s
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"context"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// getDetail loads a message for the detail and preview panes: the full message,
+// or only its headers in metadata-only mode, where bodies can't be read.
+func getDetail(ctx context.Context, c *gmailx.Client, id string, maxBody int, metadataOnly bool) (*gmailx.EmailDetail, error) {
+	if metadataOnly {
+		d, err := c.GetMetadata(ctx, id)
+		if err == nil {
+			d.Body = "(message bodies aren't available in metadata-only mode)"
+		}
+		return d, err
+	}
+	return c.GetDetail(ctx, id, maxBody)
+}
@@ -1,23 +1,30 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
+	"gmail-tui/internal/auth"
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
 	"gmail-tui/internal/store"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
-const credentialsFile = "credentials.json"
-
-const gmailReadonlyScope = "https://www.googleapis.com/auth/gmail.readonly"
-
 type screen int
 
 const (
@@ -26,32 +33,64 @@ const (
 	screenDetail
 	screenSearch
 	screenLabels
+	screenVacation
+	screenFilters
+	screenSignature
+	screenCompose
+	screenProfile
+	screenAttachments
+	screenSetup
+	screenSummary
 )
 
 type emailItem struct {
 	id      string
 	subject string
 	from    string
+	to      string
 	date    string
 	snippet string
+	unread  bool
+	// threadID is the message's conversation and threadSize how many
+	// messages it has, 0 until the sizes are loaded.
+	threadID   string
+	threadSize int
 }
 
-// Title returns the email subject for display in the list.
-func (e emailItem) Title() string { return e.subject }
+// Title returns the email subject for display in the list, marked with a dot when unread.
+func (e emailItem) Title() string {
+	if e.unread {
+		return "● " + e.subject
+	}
+	return e.subject
+}
 
 // Description returns a formatted string with sender and date information.
 func (e emailItem) Description() string { return e.from + "  |  " + e.date }
 
 // FilterValue returns all searchable text fields concatenated for filtering in the list.
-func (e emailItem) FilterValue() string { return e.subject + " " + e.from + " " + e.date }
+func (e emailItem) FilterValue() string { return e.subject + " " + e.from + " " + e.to + " " + e.date }
 
 type labelItem struct {
-	id   string
-	name string
+	id        string
+	name      string
+	textColor string
+	bgColor   string
 }
 
-// Title returns the label name for display in the list.
-func (l labelItem) Title() string { return l.name }
+// Title returns the label name for display in the list, after a swatch in the
+// label's Gmail color. Labels without a custom color get a blank swatch so
+// names stay aligned.
+func (l labelItem) Title() string {
+	if l.bgColor == "" {
+		return "  " + l.name
+	}
+	swatch := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(l.textColor)).
+		Background(lipgloss.Color(l.bgColor)).
+		Render("●")
+	return swatch + " " + l.name
+}
 
 // Description returns an empty string as labels don't need descriptions.
 func (l labelItem) Description() string { return "" }
@@ -59,31 +98,296 @@ func (l labelItem) Description() string { return "" }
 // FilterValue returns the label name for filtering in the list.
 func (l labelItem) FilterValue() string { return l.name }
 
+// clientFactory creates the Gmail client used by commands, passing opts on to
+// gmailx.New, e.g. to turn on dry-run mode. It is a field on the model so the
+// API can be swapped out, e.g. for a client pointed at a fake server.
+type clientFactory func(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token, opts ...gmailx.Option) (*gmailx.Client, error)
+
+// savedToken returns a function loading the token saved in ts, for the login
+// flow to tell whether a refresh token is already held.
+func savedToken(ts *store.TokenStore) func() *oauth2.Token {
+	return func() *oauth2.Token {
+		if ts == nil {
+			return nil
+		}
+		tok, _ := ts.Load()
+		return tok
+	}
+}
+
+// profileAccount returns a function looking up the address of the account a
+// token belongs to in its Gmail profile, for the login flow to tell whether a
+// new login is for the account of the saved token.
+func profileAccount(newClient clientFactory) auth.AccountFunc {
+	return func(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token) (string, error) {
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return "", err
+		}
+		p, err := c.Profile(ctx)
+		return p.Email, err
+	}
+}
+
+// apiClient returns the factory for clients of the Gmail API, or of the API
+// at endpoint when it is set. Every client it creates is paced by quota and
+// reports retried token refreshes on events.
+func apiClient(endpoint string, quota *gmailx.QuotaLimiter, events chan<- gmailx.RefreshEvent) clientFactory {
+	return func(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token, opts ...gmailx.Option) (*gmailx.Client, error) {
+		opts = append([]gmailx.Option{gmailx.WithEndpoint(endpoint), gmailx.WithQuotaLimiter(quota), gmailx.WithRefreshEvents(events)}, opts...)
+		return gmailx.New(ctx, cfg, tok, opts...)
+	}
+}
+
 type model struct {
 	err error
 
-	cfg   *oauth2.Config
-	token *oauth2.Token
-	store *store.TokenStore
+	settings config.Config
+
+	// ctx is the root context for every command. cancel is called on quit so that
+	// in-flight API calls and login waits stop instead of outliving the program.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cfg       *oauth2.Config
+	token     *oauth2.Token
+	store     *store.TokenStore
+	newClient clientFactory
+	// quota paces the requests of every client newClient creates, since
+	// they all spend the same account's quota.
+	quota *gmailx.QuotaLimiter
+	// refreshEvents receives the reports of retried token refreshes from
+	// the clients newClient creates, for the status line.
+	refreshEvents chan gmailx.RefreshEvent
+	// flow is the login mechanism the l key uses, chosen by the login_flow
+	// setting. Like newClient it can be replaced, e.g. by a fake.
+	flow auth.Flow
+	// reload rebuilds the effective settings from the config file, environment
+	// and command-line flags, for reloading without a restart.
+	reload func() (config.Config, error)
+	cache  *store.Cache
+
+	// offline is set when the last fetch fell back to cached data because the
+	// network was unreachable. Write actions are disabled while offline.
+	offline bool
+
+	// hideRead keeps read messages out of the inbox list, and flagFilter
+	// every message without that local flag; hiddenRows holds the rows they
+	// hide so they can be shown again without a fetch.
+	hideRead   bool
+	flagFilter string
+	hiddenRows []hiddenRow
+	// flags are the local flags on messages; nil if they couldn't be loaded.
+	flags *store.Flags
+
+	// refreshSeq identifies the current auto-refresh timer, and offlineFetches
+	// counts consecutive fetches that failed for lack of network, stretching
+	// the auto-refresh interval.
+	refreshSeq     int
+	offlineFetches int
+
+	// lastInput is when a key was last pressed, for idle logout, and idleSeq
+	// identifies the current idle timer.
+	lastInput time.Time
+	idleSeq   int
 
 	clientReady bool
 
+	// device is the pending device-flow login, if any, and devicePolling is set
+	// while a command is polling for its approval.
+	device        *auth.DeviceCode
+	devicePolling bool
+
 	screen screen
 
-	inbox  list.Model
-	labels list.Model
+	inbox   list.Model
+	labels  list.Model
+	filters list.Model
+	// attachments lists the open message's attachments on screenAttachments.
+	attachments list.Model
+
+	filterExpanded bool
 
 	detailVP viewport.Model
 	detailID string
+	detail   *gmailx.EmailDetail
+	// loadingFull is the ID of the truncated message whose full body is
+	// being loaded in the background, shown with bodySpinner.
+	loadingFull string
+	bodySpinner spinner.Model
+
+	// openRefOpen shows the prompt for opening a message by link or ID,
+	// typed into openInput.
+	openRefOpen bool
+	openInput   textinput.Model
+
+	// threadView is set while the detail screen shows the whole thread
+	// instead of the single selected message, which is kept in thread.
+	threadView bool
+	thread     *gmailx.Thread
+
+	// expandQuotes shows quoted reply text in full in the detail view. It starts
+	// from the expand_quotes setting for each message.
+	expandQuotes bool
+
+	// detailText is the unstyled content of the detail viewport. While a find
+	// query is set its matches are highlighted, findLines holds the line of each
+	// matching line and findIdx the current one. finding is set while the find
+	// input has focus.
+	detailText string
+	findInput  textinput.Model
+	finding    bool
+	findQuery  string
+	findLines  []int
+	findIdx    int
+
+	// attachIdx is the attachment of the open message that o opens.
+	attachIdx int
+
+	// showHeaders prefixes the detail view with every raw message header.
+	showHeaders bool
+	// bodyView is the rendering of the open message's body being shown.
+	bodyView bodyView
+
+	// accountIndex is the signed-in account's position in Gmail's web UI
+	// (mail.google.com/mail/u/N), used when building permalinks.
+	accountIndex int
+
+	// splitView shows a live preview of the selected message next to the inbox
+	// on terminals at least splitMinWidth wide.
+	splitView  bool
+	previewVP  viewport.Model
+	previewID  string
+	previewSeq int
+
+	// previewCancel stops the preview fetch in flight, and previewSem limits
+	// how many preview fetches run at once.
+	previewCancel context.CancelFunc
+	previewSem    chan struct{}
 
 	searchInput textinput.Model
-	query       string
-	status      string
+	// setupInput takes the path of a downloaded OAuth client file on the
+	// first-run setup wizard.
+	setupInput textinput.Model
+	query      string
+	status     string
+
+	// labelID restricts the inbox listing to the label picked on the labels
+	// screen, named labelName; empty lists the inbox.
+	labelID   string
+	labelName string
+	// labelsAt is when the labels shown on the labels screen and the label
+	// quick-pick were fetched; zero until they first are.
+	labelsAt time.Time
+	// selected holds the IDs of the inbox messages picked with x, and
+	// summaryVP shows the headers fetched for them.
+	selected  map[string]bool
+	summaryVP viewport.Model
+
+	// expandedID is the inbox row whose snippet is expanded in place, and
+	// expandSeq identifies the timer that will expand the next one.
+	expandedID string
+	expandSeq  int
+	// startLabel is the label from StartOptions, until it is resolved after
+	// logging in.
+	startLabel string
+	// everywhere searches all mail, spam and trash included, instead of the
+	// inbox. searchEverywhere is its pending value on the search screen,
+	// applied along with the query.
+	everywhere       bool
+	searchEverywhere bool
+
+	// pageTokens holds the tokens of the inbox pages visited after the first,
+	// the last being the current page. nextPageToken and resultEstimate come
+	// from the current page's listing.
+	pageTokens     []string
+	nextPageToken  string
+	resultEstimate int64
+
+	// pendingSend is the message sent from the compose screen that hasn't been
+	// delivered yet, and sendSeq identifies its undo timer.
+	pendingSend *pendingSend
+	sendSeq     int
+
+	// unreadCounts are the unread message counts shown in the labels
+	// sidebar, by label ID.
+	unreadCounts map[string]int64
+
+	// archiveAll is the running archive-all job, if any, and archiveSeq
+	// identifies it so replies from a job abandoned at logout are ignored.
+	archiveAll *archiveAllJob
+	archiveSeq int
+
+	// confirm, when set, is a yes/no prompt that must be answered before
+	// any other key is handled.
+	confirm *confirmPrompt
+
+	// jumpBuf holds the digits typed so far for a jump-to-row command in the inbox.
+	jumpBuf string
+
+	vacation   *gmailx.VacationSettings
+	vacEnabled bool
+	vacInputs  []textinput.Model
+	vacFocus   int
+
+	signature *gmailx.Signature
+	sigInput  textarea.Model
+
+	composeInputs []textinput.Model
+	composeBody   textarea.Model
+	composeFocus  int
+	// composeFormat is the format the message being composed will be sent
+	// in, one of the gmailx Format constants.
+	composeFormat string
+	// contacts are the correspondents learned from the signed-in account's
+	// mail, suggested in the To field; nil until the account is known or if
+	// they couldn't be loaded. suggestIdx is the selected suggestion.
+	contacts   *store.Contacts
+	suggestIdx int
+	// reply links the message being composed to the one it answers; nil for
+	// a new message.
+	reply *replyContext
+
+	// templates are the compose templates loaded at startup, and tmpl is the
+	// one being inserted, if any.
+	templates []mailTemplate
+	tmpl      *templateInsert
+
+	// inboxStream is the inbox fetch whose rows are currently arriving, and
+	// inboxLoaded counts how many of them have been added to the list.
+	inboxStream *inboxStream
+	inboxLoaded int
+
+	// palette is the command palette, shown over the current screen while
+	// paletteOpen is set.
+	palette     list.Model
+	paletteOpen bool
+
+	// labelPick is the label quick-pick, shown over the inbox while
+	// labelPickOpen is set.
+	labelPick     list.Model
+	labelPickOpen bool
+
+	// showHelp displays every key of the current screen until any key is pressed.
+	showHelp bool
+
+	// account is the signed-in address, shown in the header in its accent
+	// color; accountBanner is set while the account-changed banner is showing.
+	account       string
+	accountBanner bool
+
+	// profile is the signed-in mailbox's profile, shown on the profile screen.
+	profile *gmailx.AccountProfile
 
 	width  int
 	height int
 }
 
+type confirmPrompt struct {
+	text  string
+	onYes tea.Cmd
+}
+
 var (
 	box   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(1, 2)
 	pad   = lipgloss.NewStyle().Padding(1, 2)
@@ -91,11 +395,19 @@ var (
 	faint = lipgloss.NewStyle().Faint(true)
 )
 
-// NewModel creates and initializes a new application model with default values.
+// NewModel creates and initializes a new application model from the effective settings.
 // It sets up the inbox list, search input, detail viewport, and token store.
+// reload is used to re-read the settings when the user asks for it; it may be nil,
+// in which case only the config file and environment are re-read.
+// start sets the label and query the inbox opens with.
 // Returns the model in the authentication screen state.
-func NewModel() model {
-	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+func NewModel(settings config.Config, reload func() (config.Config, error), start StartOptions) model {
+	flags, err := store.LoadFlags()
+	if err != nil {
+		slog.Warn("failed to load local flags", "err", err)
+	}
+
+	l := list.New([]list.Item{}, newEmailDelegate(settings.ShowSnippets, settings.SnippetLength, false, settings.FullAddresses, settings.CompactRows, flags), 0, 0)
 	l.Title = "Inbox"
 	l.SetShowHelp(true)
 
@@ -103,37 +415,150 @@ func NewModel() model {
 	labels.Title = "Labels"
 	labels.SetShowHelp(true)
 
+	filters := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	filters.Title = "Filters"
+	filters.SetShowHelp(true)
+
+	attachments := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	attachments.SetShowHelp(true)
+
 	si := textinput.New()
-	si.Placeholder = "Gmail search query (example: from:someone newer_than:7d)"
+	si.Placeholder = "Gmail search query (example: from:someone newer_than:7d att:pdf)"
 	si.Prompt = "/ "
 	si.Width = 60
 
 	vp := viewport.New(0, 0)
 
 	ts, _ := store.NewTokenStore()
+	cache, _ := store.NewCache()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	quota := gmailx.NewQuotaLimiter(settings.QuotaPerSecond)
+	refreshEvents := make(chan gmailx.RefreshEvent, 8)
+	newClient := apiClient(settings.APIEndpoint, quota, refreshEvents)
 
-	return model{
-		screen:      screenAuth,
-		inbox:       l,
-		labels:      labels,
-		searchInput: si,
-		detailVP:    vp,
-		store:       ts,
-		status:      "Press l to login in browser",
+	flow, err := auth.FlowByName(settings.LoginFlow, settings.ForceConsent, savedToken(ts), profileAccount(newClient))
+	if err != nil {
+		flow = auth.LoopbackFlow{}
 	}
+
+	templates, err := loadTemplates()
+	if err != nil {
+		slog.Warn("failed to load compose templates", "err", err)
+	}
+
+	m := model{
+		ctx:           ctx,
+		cancel:        cancel,
+		settings:      settings,
+		reload:        reload,
+		splitView:     settings.SplitView,
+		lastInput:     time.Now(),
+		screen:        screenAuth,
+		inbox:         l,
+		labels:        labels,
+		filters:       filters,
+		attachments:   attachments,
+		searchInput:   si,
+		detailVP:      vp,
+		findInput:     newFindInput(),
+		openInput:     newOpenInput(),
+		bodySpinner:   spinner.New(spinner.WithSpinner(spinner.Dot)),
+		setupInput:    newSetupInput(),
+		previewVP:     viewport.New(0, 0),
+		summaryVP:     viewport.New(0, 0),
+		previewSem:    make(chan struct{}, max(settings.PreviewMaxInFlight, 1)),
+		vacInputs:     newVacationInputs(),
+		sigInput:      newSignatureInput(),
+		composeInputs: newComposeInputs(),
+		palette:       newPalette(),
+		labelPick:     newLabelPick(),
+		composeBody:   newComposeBody(),
+		templates:     templates,
+		store:         ts,
+		newClient:     newClient,
+		quota:         quota,
+		refreshEvents: refreshEvents,
+		flow:          flow,
+		cache:         cache,
+		flags:         flags,
+		query:         start.Query,
+		startLabel:    start.Label,
+	}
+	m.inbox.SetDelegate(m.inboxDelegate())
+	return m
 }
 
-// loadOAuthConfig reads the credentials.json file and creates an OAuth2 configuration
-// for Gmail API access with read-only scope. Returns an error if the file is missing
-// or cannot be parsed.
-func loadOAuthConfig() (*oauth2.Config, error) {
-	b, err := os.ReadFile(credentialsFile)
+// LoadOAuthConfig reads the credentials file at path and creates an OAuth2 configuration
+// for Gmail API access requesting scopes, usually from RequestedScopes. The file is
+// validated first so that a missing file, malformed JSON and the wrong kind of OAuth
+// client each get an error that says how to fix it. When path doesn't exist, the
+// file saved by the setup wizard is used instead, if there is one.
+func LoadOAuthConfig(path string, scopes []string) (*oauth2.Config, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Fall back to the file the setup wizard saved.
+		if saved, serr := store.CredentialsPath(); serr == nil && saved != path {
+			if b, err = os.ReadFile(saved); err == nil {
+				path = saved
+			}
+		}
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, missingCredentialsError{path: path}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read credentials file %s: %w", path, err)
+	}
+	client, err := validateCredentials(b)
 	if err != nil {
-		return nil, errors.New("missing credentials.json in project root")
+		return nil, fmt.Errorf("credentials file %s: %w", path, err)
 	}
-	cfg, err := google.ConfigFromJSON(b, gmailReadonlyScope)
+	cfg, err := google.ConfigFromJSON(b, scopes...)
 	if err != nil {
 		return nil, err
 	}
+	// ConfigFromJSON takes the first redirect URI, which may not be one the
+	// loopback login can serve.
+	cfg.RedirectURL, err = auth.LoopbackRedirect(client.RedirectURIs)
+	if err != nil {
+		return nil, fmt.Errorf("credentials file %s: %w", path, err)
+	}
 	return cfg, nil
 }
+
+// credentialsClient is the part of a Google OAuth client file that is checked
+// before use.
+type credentialsClient struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURIs []string `json:"redirect_uris"`
+}
+
+// validateCredentials checks that b is a Desktop app ("installed") OAuth client
+// that can use the loopback redirect and returns it, or an actionable error
+// otherwise. See auth.LoopbackRedirect for which redirect URIs work.
+func validateCredentials(b []byte) (*credentialsClient, error) {
+	var f struct {
+		Installed *credentialsClient `json:"installed"`
+		Web       *credentialsClient `json:"web"`
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("not valid JSON (%v); download it again from Google Cloud Console", err)
+	}
+	if f.Installed == nil {
+		if f.Web != nil {
+			return nil, errors.New("this looks like a Web application client; create and download a Desktop app OAuth client instead")
+		}
+		return nil, errors.New("not an OAuth client file; download a Desktop app OAuth client from Google Cloud Console")
+	}
+	c := f.Installed
+	if c.ClientID == "" || c.ClientSecret == "" {
+		return nil, errors.New("client_id or client_secret is missing; download the file again")
+	}
+	if _, err := auth.LoopbackRedirect(c.RedirectURIs); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
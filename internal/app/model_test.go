@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gmail-tui/internal/store"
+)
+
+// writeCredentials writes contents to a credentials file in a temporary
+// directory and returns its path.
+func writeCredentials(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOAuthConfigDesktopClient(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		name     string
+		redirect string
+		want     string
+	}{
+		{"bare localhost", `"http://localhost"`, "http://127.0.0.1"},
+		{"fixed port", `"http://127.0.0.1:8085"`, "http://127.0.0.1:8085"},
+		{"out of band first", `"urn:ietf:wg:oauth:2.0:oob", "http://localhost"`, "http://127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCredentials(t, `{"installed":{"client_id":"id","client_secret":"secret",
+				"auth_uri":"https://accounts.google.com/o/oauth2/auth","token_uri":"https://oauth2.googleapis.com/token",
+				"redirect_uris":[`+tt.redirect+`]}}`)
+
+			cfg, err := LoadOAuthConfig(path, []string{gmailReadonlyScope})
+			if err != nil {
+				t.Fatalf("LoadOAuthConfig() error = %v", err)
+			}
+			if cfg.ClientID != "id" || cfg.RedirectURL != tt.want {
+				t.Errorf("config = client %q redirect %q, want id and %s", cfg.ClientID, cfg.RedirectURL, tt.want)
+			}
+			if len(cfg.Scopes) != 1 || cfg.Scopes[0] != gmailReadonlyScope {
+				t.Errorf("Scopes = %v, want the readonly scope", cfg.Scopes)
+			}
+		})
+	}
+}
+
+func TestLoadOAuthConfigErrors(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{"malformed JSON", `{"installed": {`, "not valid JSON"},
+		{"web client", `{"web":{"client_id":"id","client_secret":"secret","redirect_uris":["https://example.com/cb"]}}`, "Web application client"},
+		{"not a client", `{"type":"service_account"}`, "not an OAuth client file"},
+		{"missing secret", `{"installed":{"client_id":"id","redirect_uris":["http://localhost"]}}`, "client_secret is missing"},
+		{"no loopback redirect", `{"installed":{"client_id":"id","client_secret":"secret","redirect_uris":["https://example.com/cb"]}}`, "no http://localhost redirect URI"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeCredentials(t, tt.contents)
+
+			_, err := LoadOAuthConfig(path, []string{gmailReadonlyScope})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadOAuthConfig() error = %v, want one saying %q", err, tt.want)
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("LoadOAuthConfig() error = %v, want it to name the file", err)
+			}
+		})
+	}
+}
+
+func TestLoadOAuthConfigMissingFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "credentials.json")
+
+	_, err := LoadOAuthConfig(path, []string{gmailReadonlyScope})
+	var missing missingCredentialsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("LoadOAuthConfig() error = %v, want a missingCredentialsError", err)
+	}
+	if missing.path != path {
+		t.Errorf("missing path = %q, want %q", missing.path, path)
+	}
+}
+
+func TestLoadOAuthConfigFallsBackToSavedFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saved, err := store.SaveCredentials([]byte(`{"installed":{"client_id":"saved","client_secret":"secret","redirect_uris":["http://localhost"]}}`))
+	if err != nil {
+		t.Fatalf("SaveCredentials() error = %v", err)
+	}
+
+	cfg, err := LoadOAuthConfig(filepath.Join(t.TempDir(), "credentials.json"), []string{gmailReadonlyScope})
+	if err != nil {
+		t.Fatalf("LoadOAuthConfig() error = %v, want the file saved at %s", err, saved)
+	}
+	if cfg.ClientID != "saved" {
+		t.Errorf("ClientID = %q, want the saved client", cfg.ClientID)
+	}
+}
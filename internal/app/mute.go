@@ -0,0 +1,102 @@
+package app
+
+import (
+	"slices"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type threadMutedMsg struct {
+	threadID string
+	mute     bool
+	dryRun   bool
+	err      error
+}
+
+// isMuted reports whether the open message's thread is muted.
+func (m model) isMuted() bool {
+	return m.detail != nil && slices.Contains(m.detail.LabelIDs, "MUTED")
+}
+
+// muteCmd creates a command that mutes or unmutes a thread. Uses the configured
+// timeout for the API call.
+func (m model) muteCmd(threadID string, mute bool) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return threadMutedMsg{threadID: threadID, mute: mute, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return threadMutedMsg{threadID: threadID, mute: mute, err: err}
+		}
+		if mute {
+			err = c.MuteThread(ctx, threadID)
+		} else {
+			err = c.UnmuteThread(ctx, threadID)
+		}
+		return threadMutedMsg{threadID: threadID, mute: mute, dryRun: dryRun, err: err}
+	}
+}
+
+// toggleMute mutes the open message's thread, or unmutes it if it is muted.
+func (m *model) toggleMute() tea.Cmd {
+	if m.detail == nil || m.detail.ThreadID == "" {
+		return nil
+	}
+	if m.offline {
+		m.status = "Offline — can't change messages"
+		return nil
+	}
+	mute := !m.isMuted()
+	m.status = "Unmuting thread..."
+	if mute {
+		m.status = "Muting thread..."
+	}
+	return m.muteCmd(m.detail.ThreadID, mute)
+}
+
+// threadMuted records the outcome of a mute or unmute. A muted thread is also
+// archived, so its row leaves the inbox.
+func (m *model) threadMuted(msg threadMutedMsg) {
+	verb := "unmute thread"
+	if msg.mute {
+		verb = "mute thread"
+	}
+	switch {
+	case msg.err != nil:
+		m.status = "Couldn't " + verb + ": " + msg.err.Error()
+		return
+	case msg.dryRun:
+		m.status = dryRunStatus(verb, 1)
+		return
+	}
+	if m.detail != nil && m.detail.ThreadID == msg.threadID {
+		m.detail.LabelIDs = slices.DeleteFunc(m.detail.LabelIDs, func(l string) bool { return l == "MUTED" || (msg.mute && l == "INBOX") })
+		if msg.mute {
+			m.detail.LabelIDs = append(m.detail.LabelIDs, "MUTED")
+		}
+		if !m.threadView {
+			m.setDetailText(m.detailContent())
+		}
+	}
+	if msg.mute {
+		m.status = "Thread muted and archived; replies will skip the inbox"
+		if m.detail != nil && m.labelID == "" && !m.everywhere {
+			m.removeRow(m.detail.ID)
+		}
+		return
+	}
+	m.status = "Thread unmuted"
+}
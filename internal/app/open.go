@@ -0,0 +1,115 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gmail-tui/internal/auth"
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openDir is the temporary directory opened attachments are written to. It is
+// created on first use and removed by cleanupOpened when the program quits.
+var openDir struct {
+	sync.Mutex
+	path string
+}
+
+// openedAttachmentDir returns the temporary directory for opened attachments,
+// creating it if needed.
+func openedAttachmentDir() (string, error) {
+	openDir.Lock()
+	defer openDir.Unlock()
+	if openDir.path == "" {
+		dir, err := os.MkdirTemp("", "gmail-tui-")
+		if err != nil {
+			return "", err
+		}
+		openDir.path = dir
+	}
+	return openDir.path, nil
+}
+
+// cleanupOpened removes every attachment written for opening.
+func cleanupOpened() {
+	openDir.Lock()
+	defer openDir.Unlock()
+	if openDir.path != "" {
+		os.RemoveAll(openDir.path)
+		openDir.path = ""
+	}
+}
+
+type attachmentOpenedMsg struct {
+	name string
+	err  error
+}
+
+// openAttachmentCmd creates a command that downloads an attachment to a
+// temporary file and opens it with the system's default application.
+// Uses the configured timeout for the download.
+func (m model) openAttachmentCmd(msgID string, a gmailx.Attachment) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return attachmentOpenedMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return attachmentOpenedMsg{err: err}
+		}
+		data, err := c.GetAttachment(ctx, msgID, a)
+		if err != nil {
+			return attachmentOpenedMsg{err: err}
+		}
+		dir, err := openedAttachmentDir()
+		if err != nil {
+			return attachmentOpenedMsg{err: err}
+		}
+		// Each attachment gets its own directory so the file keeps its name,
+		// which the default application may use to pick a handler.
+		sub, err := os.MkdirTemp(dir, "")
+		if err != nil {
+			return attachmentOpenedMsg{err: err}
+		}
+		name := filepath.Base(a.Filename)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			name = "attachment"
+		}
+		path := filepath.Join(sub, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return attachmentOpenedMsg{err: err}
+		}
+		return attachmentOpenedMsg{name: a.Filename, err: auth.Open(path)}
+	}
+}
+
+// openAttachment opens the selected attachment of the message in the detail
+// view, asking for confirmation first when it could run a program.
+func (m model) openAttachment() (tea.Model, tea.Cmd) {
+	if m.detail == nil || len(m.detail.Attachments) == 0 {
+		m.status = "No attachments"
+		return m, nil
+	}
+	a := m.detail.Attachments[m.attachIdx]
+	if gmailx.IsExecutable(a) {
+		m.confirm = &confirmPrompt{
+			text:  a.Filename + " may run a program when opened. Open it anyway?",
+			onYes: m.openAttachmentCmd(m.detailID, a),
+		}
+		return m, nil
+	}
+	m.status = "Opening " + a.Filename + "..."
+	return m, m.openAttachmentCmd(m.detailID, a)
+}
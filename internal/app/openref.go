@@ -0,0 +1,103 @@
+package app
+
+import (
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type openRefMsg struct {
+	detail *gmailx.EmailDetail
+	err    error
+}
+
+// newOpenInput creates the input for opening a message by link or ID.
+func newOpenInput() textinput.Model {
+	in := textinput.New()
+	in.Prompt = "open: "
+	in.Placeholder = "Gmail link, message ID or Message-ID"
+	in.Width = 60
+	return in
+}
+
+// startOpenRef shows the prompt for opening a message by link or ID.
+func (m *model) startOpenRef() tea.Cmd {
+	m.openRefOpen = true
+	m.openInput.SetValue("")
+	return m.openInput.Focus()
+}
+
+// openRefCmd creates a command that resolves ref and fetches the message it
+// names, wherever it is filed. Uses the configured timeout for the API calls.
+func (m model) openRefCmd(ref gmailx.MessageRef) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	maxBody := m.settings.MaxBodyBytes
+	metadataOnly := m.metadataOnly()
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return openRefMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return openRefMsg{err: err}
+		}
+		id := ref.ID
+		if ref.MessageID != "" {
+			if id, err = c.FindByMessageID(ctx, ref.MessageID); err != nil {
+				return openRefMsg{err: err}
+			}
+		}
+		d, err := getDetail(ctx, c, id, maxBody, metadataOnly)
+		if gmailx.IsNotFound(err) {
+			return openRefMsg{err: errNoMessage(id)}
+		}
+		return openRefMsg{detail: d, err: err}
+	}
+}
+
+// errNoMessage reports an ID that doesn't name a message in the mailbox.
+type errNoMessage string
+
+func (e errNoMessage) Error() string { return "no message with ID " + string(e) }
+
+// updateOpenRef handles keys while the open prompt is shown: enter looks the
+// message up, esc cancels.
+func (m model) updateOpenRef(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.openRefOpen = false
+		m.openInput.Blur()
+		return m, nil
+	case "enter":
+		ref, err := gmailx.ParseMessageRef(m.openInput.Value())
+		if err != nil {
+			m.status = err.Error()
+			return m, nil
+		}
+		m.openRefOpen = false
+		m.openInput.Blur()
+		m.status = "Opening message..."
+		return m, m.openRefCmd(ref)
+	}
+	var cmd tea.Cmd
+	m.openInput, cmd = m.openInput.Update(msg)
+	return m, cmd
+}
+
+// openRefView renders the open prompt.
+func (m model) openRefView() string {
+	body := "Open a message\n\n" + m.openInput.View() + "\n"
+	if m.status != "" {
+		body += "\n" + m.status + "\n"
+	}
+	return body + "\n" + faint.Render("enter open • esc cancel")
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type paletteItem struct {
+	binding key.Binding
+}
+
+// Title returns the action's short description.
+func (p paletteItem) Title() string { return p.binding.Help().Desc }
+
+// Description returns the keys bound to the action.
+func (p paletteItem) Description() string { return strings.Join(p.binding.Keys(), ", ") }
+
+// FilterValue matches on both the description and the key.
+func (p paletteItem) FilterValue() string { return p.Title() + " " + p.Description() }
+
+// newPalette creates the list backing the command palette.
+func newPalette() list.Model {
+	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	l.Title = "Commands"
+	l.SetShowHelp(false)
+	return l
+}
+
+// openPalette shows the command palette with the actions of the current screen,
+// already in filtering mode so the user can start typing immediately.
+func (m *model) openPalette() tea.Cmd {
+	var items []list.Item
+	for _, b := range m.activeBindings() {
+		// Bindings whose help names a range, like 0-9, need more input than
+		// a single replayed key.
+		if !b.Enabled() || b.Help().Key != b.Keys()[0] {
+			continue
+		}
+		items = append(items, paletteItem{binding: b})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	m.palette.ResetFilter()
+	m.palette.ResetSelected()
+	cmd := m.palette.SetItems(items)
+	m.paletteOpen = true
+	var filterCmd tea.Cmd
+	m.palette, filterCmd = m.palette.Update(keyMsgFor("/"))
+	return tea.Batch(cmd, filterCmd)
+}
+
+// updatePalette handles key presses while the palette is open. enter runs the
+// highlighted action by replaying its key on the underlying screen and esc
+// closes the palette.
+func (m model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.paletteOpen = false
+		return m, nil
+	case "enter":
+		it, ok := m.palette.SelectedItem().(paletteItem)
+		m.paletteOpen = false
+		if !ok {
+			return m, nil
+		}
+		return m.Update(keyMsgFor(it.binding.Keys()[0]))
+	}
+	var cmd tea.Cmd
+	m.palette, cmd = m.palette.Update(msg)
+	return m, cmd
+}
+
+// paletteView renders the command palette.
+func (m model) paletteView() string {
+	return m.palette.View() + "\n" + faint.Render("type to filter • enter run • esc close")
+}
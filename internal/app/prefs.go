@@ -0,0 +1,118 @@
+package app
+
+import (
+	"slices"
+
+	"gmail-tui/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Layout preferences, such as split view and snippet visibility, are durable:
+// toggling one updates m.settings for the current session and writes just that
+// field back to the config file, so it is restored on the next run. Transient
+// state like the search query is deliberately never saved.
+
+type prefsSavedMsg struct{ err error }
+
+// savePrefCmd creates a command that applies fn to the config file.
+func savePrefCmd(fn func(*config.Config)) tea.Cmd {
+	return func() tea.Msg {
+		return prefsSavedMsg{err: config.Update(fn)}
+	}
+}
+
+// toggleSnippets shows or hides snippets in the inbox list and returns a command
+// that persists the choice.
+func (m *model) toggleSnippets() tea.Cmd {
+	m.settings.ShowSnippets = !m.settings.ShowSnippets
+	m.inbox.SetDelegate(m.inboxDelegate())
+	show := m.settings.ShowSnippets
+	return savePrefCmd(func(c *config.Config) { c.ShowSnippets = show })
+}
+
+// toggleCompactRows switches the inbox list between one and two lines per
+// message and returns a command that persists the choice.
+func (m *model) toggleCompactRows() tea.Cmd {
+	m.settings.CompactRows = !m.settings.CompactRows
+	m.inbox.SetDelegate(m.inboxDelegate())
+	compact := m.settings.CompactRows
+	return savePrefCmd(func(c *config.Config) { c.CompactRows = compact })
+}
+
+// toggleSplitView turns the preview pane on or off and returns a command that
+// persists the choice along with the preview for the newly selected row.
+func (m *model) toggleSplitView() tea.Cmd {
+	m.splitView = !m.splitView
+	m.previewID = ""
+	m.resize()
+	split := m.splitView
+	return tea.Batch(m.schedulePreview(), savePrefCmd(func(c *config.Config) { c.SplitView = split }))
+}
+
+// toggleSidebar shows or hides the labels sidebar and returns a command that
+// persists the choice along with loading what the sidebar shows.
+func (m *model) toggleSidebar() tea.Cmd {
+	m.settings.LabelsSidebar = !m.settings.LabelsSidebar
+	m.resize()
+	show := m.settings.LabelsSidebar
+	if show && !m.sidebarActive() {
+		m.status = "Widen the terminal to see the labels sidebar"
+	}
+	return tea.Batch(m.sidebarCmd(), savePrefCmd(func(c *config.Config) { c.LabelsSidebar = show }))
+}
+
+// toggleThreadOrder reverses the order of the open thread's messages and
+// returns a command that persists the choice.
+func (m *model) toggleThreadOrder() tea.Cmd {
+	m.settings.ThreadNewestFirst = !m.settings.ThreadNewestFirst
+	m.setDetailText(m.threadContent())
+	m.detailVP.GotoTop()
+	newest := m.settings.ThreadNewestFirst
+	return savePrefCmd(func(c *config.Config) { c.ThreadNewestFirst = newest })
+}
+
+// toggleFullAddresses switches between display names and full addresses in
+// the inbox, the preview and the open message, and returns a command that persists the
+// choice.
+func (m *model) toggleFullAddresses() tea.Cmd {
+	m.settings.FullAddresses = !m.settings.FullAddresses
+	m.inbox.SetDelegate(m.inboxDelegate())
+	if m.detail != nil {
+		m.refreshDetailText()
+	}
+	full := m.settings.FullAddresses
+	if full {
+		m.status = "Showing full addresses"
+	} else {
+		m.status = "Showing display names"
+	}
+	save := savePrefCmd(func(c *config.Config) { c.FullAddresses = full })
+	if m.splitView {
+		m.previewID = ""
+		return tea.Batch(m.schedulePreview(), save)
+	}
+	return save
+}
+
+// headerDetails are the HeaderDetail settings in the order v cycles through.
+var headerDetails = []string{"minimal", "standard", "full"}
+
+// cycleHeaderDetail shows the next, or wrapping around the fewest, headers
+// above the open message and preview, and returns a command that persists the
+// choice.
+func (m *model) cycleHeaderDetail() tea.Cmd {
+	i := slices.Index(headerDetails, m.settings.HeaderDetail)
+	detail := headerDetails[(i+1)%len(headerDetails)]
+	m.settings.HeaderDetail = detail
+	if m.detail != nil {
+		m.refreshDetailText()
+	}
+	m.status = "Headers: " + detail
+	save := savePrefCmd(func(c *config.Config) { c.HeaderDetail = detail })
+	if m.splitView {
+		m.previewID = ""
+		return tea.Batch(m.schedulePreview(), save)
+	}
+	return save
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// grantedScopes returns the OAuth scopes the token was granted, as reported
+// by the token endpoint when it was issued. Tokens loaded from disk don't keep
+// that response, so the scopes requested by the OAuth config, or by the
+// settings before it is loaded, are returned instead, with granted false.
+func (m model) grantedScopes() (scopes []string, granted bool) {
+	if m.token != nil {
+		if s, ok := m.token.Extra("scope").(string); ok && s != "" {
+			return strings.Fields(s), true
+		}
+	}
+	if m.cfg != nil {
+		return m.cfg.Scopes, false
+	}
+	scopes, _ = RequestedScopes(m.settings)
+	return scopes, false
+}
+
+// updateProfile handles keys on the profile screen.
+func (m model) updateProfile(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "b", "esc":
+		m.screen = screenInbox
+		m.status = ""
+		return m, nil
+	case "r":
+		m.status = "Loading profile..."
+		return m, m.fetchProfileCmd()
+	}
+	return m, nil
+}
+
+// profileView renders the signed-in account's details and scopes.
+func (m model) profileView() string {
+	var b strings.Builder
+	b.WriteString(bold.Render("Account") + "\n\n")
+	if p := m.profile; p != nil {
+		fmt.Fprintf(&b, "Email:      %s\n", p.Email)
+		fmt.Fprintf(&b, "Messages:   %d\n", p.MessagesTotal)
+		fmt.Fprintf(&b, "Threads:    %d\n", p.ThreadsTotal)
+		fmt.Fprintf(&b, "History ID: %d\n", p.HistoryID)
+	}
+	scopes, granted := m.grantedScopes()
+	if granted {
+		b.WriteString("\nGranted scopes:\n")
+	} else {
+		b.WriteString("\nRequested scopes:\n")
+	}
+	for _, s := range scopes {
+		b.WriteString("  " + s + "\n")
+	}
+	if m.metadataOnly() {
+		b.WriteString("\n" + faint.Render("metadata-only mode") + "\n")
+	}
+	if m.status != "" {
+		b.WriteString("\n" + faint.Render(m.status) + "\n")
+	}
+	return b.String() + "\n" + m.footer()
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// attributionRE matches the "On <date>, <sender> wrote:" line mail clients put
+// above quoted text. Clients often wrap it, so the match may span two lines.
+var attributionRE = regexp.MustCompile(`^On\s.*\bwrote:\s*$`)
+
+// foldRE matches the line collapseQuotes puts in place of a quote block.
+var foldRE = regexp.MustCompile(`^\[— \d+ lines of quoted text —\]$`)
+
+// isQuoted reports whether a body line is quoted reply text.
+func isQuoted(l string) bool {
+	return strings.HasPrefix(strings.TrimLeft(l, " \t"), ">")
+}
+
+// attributionLines returns how many lines starting at lines[i] form a quote
+// attribution, or 0 if there is none.
+func attributionLines(lines []string, i int) int {
+	l := strings.TrimSpace(lines[i])
+	if attributionRE.MatchString(l) {
+		return 1
+	}
+	if strings.HasPrefix(l, "On ") && i+1 < len(lines) && attributionRE.MatchString(l+" "+strings.TrimSpace(lines[i+1])) {
+		return 2
+	}
+	return 0
+}
+
+// collapseQuotes replaces each block of quoted reply text, together with its
+// attribution line, with a one-line fold giving the number of lines hidden.
+// Blank lines inside a block are folded with it; those around it are kept.
+// The fold is plain text; styleFolds dims it when the body is rendered.
+func collapseQuotes(body string) string {
+	lines := strings.Split(body, "\n")
+	var out []string
+	for i := 0; i < len(lines); {
+		j := i + attributionLines(lines, i)
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" && j > i {
+			j++
+		}
+		if j >= len(lines) || !isQuoted(lines[j]) {
+			out = append(out, lines[i])
+			i++
+			continue
+		}
+		end := j
+		for k := j; k < len(lines); k++ {
+			if isQuoted(lines[k]) {
+				end = k + 1
+			} else if strings.TrimSpace(lines[k]) != "" {
+				break
+			}
+		}
+		out = append(out, fmt.Sprintf("[— %d lines of quoted text —]", end-i))
+		i = end
+	}
+	return strings.Join(out, "\n")
+}
+
+// styleFolds dims the quote folds in text.
+func styleFolds(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		if foldRE.MatchString(l) {
+			lines[i] = faint.Render(l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// withQuotes returns d with its quoted reply text collapsed, unless expand is
+// set. d itself is not modified.
+func withQuotes(d *gmailx.EmailDetail, expand bool) *gmailx.EmailDetail {
+	if expand {
+		return d
+	}
+	c := *d
+	c.Body = collapseQuotes(d.Body)
+	return &c
+}
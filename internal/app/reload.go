@@ -0,0 +1,101 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"gmail-tui/internal/auth"
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/oauth2"
+)
+
+type settingsReloadedMsg struct {
+	settings config.Config
+	cfg      *oauth2.Config
+	err      error
+}
+
+// reloadSettingsCmd creates a command that re-reads the settings and the OAuth
+// credentials they point to, so edits take effect without a restart.
+func (m model) reloadSettingsCmd() tea.Cmd {
+	reload := m.reload
+	return func() tea.Msg {
+		if reload == nil {
+			reload = config.Load
+		}
+		settings, err := reload()
+		if err != nil {
+			return settingsReloadedMsg{err: err}
+		}
+		scopes, err := RequestedScopes(settings)
+		if err != nil {
+			return settingsReloadedMsg{err: err}
+		}
+		cfg, err := LoadOAuthConfig(settings.CredentialsPath, scopes)
+		if err != nil {
+			return settingsReloadedMsg{err: err}
+		}
+		return settingsReloadedMsg{settings: settings, cfg: cfg}
+	}
+}
+
+// applySettings switches to reloaded settings and credentials. Layout
+// settings, including whether to use the alternate screen, are applied in
+// place. If the OAuth client or the requested scopes changed, the saved token
+// no longer matches them, so the session is ended and the user is sent back
+// to log in again.
+func (m *model) applySettings(settings config.Config, cfg *oauth2.Config) tea.Cmd {
+	relogin := m.cfg != nil && m.token != nil &&
+		(cfg.ClientID != m.cfg.ClientID || !slices.Equal(cfg.Scopes, m.cfg.Scopes))
+	var screenCmd tea.Cmd
+	if settings.NoAltScreen != m.settings.NoAltScreen {
+		screenCmd = tea.EnterAltScreen
+		if settings.NoAltScreen {
+			screenCmd = tea.ExitAltScreen
+		}
+	}
+
+	idleChanged := settings.IdleLogoutMinutes != m.settings.IdleLogoutMinutes
+	if settings.QuotaPerSecond != m.settings.QuotaPerSecond {
+		m.quota = gmailx.NewQuotaLimiter(settings.QuotaPerSecond)
+	}
+	if settings.APIEndpoint != m.settings.APIEndpoint || settings.QuotaPerSecond != m.settings.QuotaPerSecond {
+		m.newClient = apiClient(settings.APIEndpoint, m.quota, m.refreshEvents)
+	}
+
+	m.err = nil
+	m.settings = settings
+	m.cfg = cfg
+	m.splitView = settings.SplitView
+	if flow, err := auth.FlowByName(settings.LoginFlow, settings.ForceConsent, savedToken(m.store), profileAccount(m.newClient)); err == nil {
+		m.flow = flow
+	}
+	m.inbox.SetDelegate(m.inboxDelegate())
+	m.previewID = ""
+	m.cancelPreview()
+	if n := max(settings.PreviewMaxInFlight, 1); cap(m.previewSem) != n {
+		m.previewSem = make(chan struct{}, n)
+	}
+	m.resize()
+	var idleCmd tea.Cmd
+	if idleChanged {
+		// Restart the idle timer for the new period, counting from now.
+		m.idleSeq++
+		m.lastInput = time.Now()
+		idleCmd = m.scheduleIdleCheck(m.idleLimit())
+	}
+
+	if relogin {
+		// The saved token was issued to the old client or for the old
+		// scopes, so its refresh token can't be carried over.
+		m.forgetToken()
+		m.endSession()
+		m.status = "Credentials changed — log in again"
+		return tea.Batch(screenCmd, idleCmd)
+	}
+	m.status = "Settings reloaded"
+	return tea.Batch(screenCmd, idleCmd, m.schedulePreview(), m.scheduleRefresh())
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// Reply quoting styles for the reply_quoting setting.
+const (
+	quoteTop    = "top"
+	quoteBottom = "bottom"
+	quoteNone   = "none"
+)
+
+// replyContext links the message being composed to the one it replies to.
+type replyContext struct {
+	threadID   string
+	messageID  string
+	references string
+}
+
+// attribution is the line introducing the quoted original in a reply, such as
+// "On Mon, 2 Jan 2006 at 15:04, Jane Doe <jane@example.com> wrote:". The date
+// falls back to the raw Date header when it couldn't be parsed.
+func attribution(d *gmailx.EmailDetail) string {
+	date := d.Date
+	if !d.Time.IsZero() {
+		date = d.Time.Local().Format("Mon, 2 Jan 2006 at 15:04")
+	}
+	sender := d.FromAddress
+	if d.FromName != "" {
+		sender = d.FromName + " <" + d.FromAddress + ">"
+	}
+	if date == "" {
+		return sender + " wrote:"
+	}
+	return "On " + date + ", " + sender + " wrote:"
+}
+
+// quoteText prefixes every line of body with "> ", or just ">" for blank
+// lines, dropping trailing blank lines.
+func quoteText(body string) string {
+	lines := strings.Split(strings.TrimRight(body, "\n"), "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// replyBody prefills a reply to d in the given quoting style: the attributed
+// quote below an empty line for top-posting, above one for bottom-posting,
+// or nothing at all.
+func replyBody(d *gmailx.EmailDetail, style string) string {
+	quote := attribution(d) + "\n" + quoteText(d.Body)
+	switch style {
+	case quoteNone:
+		return ""
+	case quoteBottom:
+		return quote + "\n\n"
+	default:
+		return "\n\n" + quote
+	}
+}
+
+// replySubject adds "Re: " to subject unless it already starts with it.
+func replySubject(subject string) string {
+	if len(subject) >= 3 && strings.EqualFold(subject[:3], "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+// startReply opens the compose screen with a reply to the open message,
+// addressed to its Reply-To or sender and quoted as the reply_quoting setting
+// says. The cursor is put where the reply goes.
+func (m *model) startReply() {
+	d := m.detail
+	if d == nil {
+		return
+	}
+	to := d.Header("Reply-To")
+	if to == "" {
+		to = d.From
+	}
+	m.startCompose(to)
+	m.composeInputs[composeSubject].SetValue(replySubject(d.Subject))
+	msgID := d.Header("Message-ID")
+	refs := strings.TrimSpace(d.Header("References") + " " + msgID)
+	m.reply = &replyContext{threadID: d.ThreadID, messageID: msgID, references: refs}
+
+	m.composeBody.SetValue(replyBody(d, m.settings.ReplyQuoting))
+	m.replyCursor()
+}
+
+// replyCursor focuses the body of a reply, with the cursor at the top unless
+// the quote is above it.
+func (m *model) replyCursor() {
+	if m.settings.ReplyQuoting != quoteBottom {
+		for m.composeBody.Line() > 0 {
+			m.composeBody.CursorUp()
+		}
+		m.composeBody.CursorStart()
+	}
+	m.focusCompose(composeBody)
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// original is the message the reply tests answer.
+func original() *gmailx.EmailDetail {
+	return &gmailx.EmailDetail{
+		ID:          "m1",
+		ThreadID:    "t1",
+		Subject:     "Lunch",
+		From:        "Jane Doe <jane@example.com>",
+		FromName:    "Jane Doe",
+		FromAddress: "jane@example.com",
+		Date:        "Tue, 05 Mar 2024 14:07:00 +0000",
+		Time:        time.Date(2024, 3, 5, 14, 7, 0, 0, time.Local),
+		Body:        "Noon?\n\nBring the notes.\n\n",
+	}
+}
+
+func TestAttribution(t *testing.T) {
+	d := original()
+	if got, want := attribution(d), "On Tue, 5 Mar 2024 at 14:07, Jane Doe <jane@example.com> wrote:"; got != want {
+		t.Errorf("attribution() = %q, want %q", got, want)
+	}
+
+	d.FromName = ""
+	d.Time = time.Time{}
+	if got, want := attribution(d), "On Tue, 05 Mar 2024 14:07:00 +0000, jane@example.com wrote:"; got != want {
+		t.Errorf("attribution() without a name or parsed date = %q, want %q", got, want)
+	}
+
+	d.Date = ""
+	if got, want := attribution(d), "jane@example.com wrote:"; got != want {
+		t.Errorf("attribution() without a date = %q, want %q", got, want)
+	}
+}
+
+func TestReplyBodyStyles(t *testing.T) {
+	quote := "On Tue, 5 Mar 2024 at 14:07, Jane Doe <jane@example.com> wrote:\n" +
+		"> Noon?\n" +
+		">\n" +
+		"> Bring the notes."
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{quoteTop, "\n\n" + quote},
+		{quoteBottom, quote + "\n\n"},
+		{quoteNone, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			if got := replyBody(original(), tt.style); got != tt.want {
+				t.Errorf("replyBody(%s) = %q, want %q", tt.style, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartReplyPlacesCursor(t *testing.T) {
+	tests := []struct {
+		style    string
+		wantLine int
+	}{
+		{quoteTop, 0},
+		{quoteBottom, 5},
+		{quoteNone, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			m, _ := testModel(t)
+			m.settings.ReplyQuoting = tt.style
+			m.detail = original()
+
+			m.startReply()
+			if m.screen != screenCompose {
+				t.Fatalf("screen = %v, want compose", m.screen)
+			}
+			if got := m.composeInputs[composeSubject].Value(); got != "Re: Lunch" {
+				t.Errorf("subject = %q, want Re: Lunch", got)
+			}
+			if got, want := m.composeBody.Value(), replyBody(original(), tt.style); got != want {
+				t.Errorf("body = %q, want %q", got, want)
+			}
+			if got := m.composeBody.Line(); got != tt.wantLine {
+				t.Errorf("cursor on line %d, want %d", got, tt.wantLine)
+			}
+			if m.reply == nil || m.reply.threadID != "t1" {
+				t.Errorf("reply = %+v, want it linked to thread t1", m.reply)
+			}
+		})
+	}
+}
+
+func TestReplySubject(t *testing.T) {
+	for subject, want := range map[string]string{
+		"Lunch":     "Re: Lunch",
+		"Re: Lunch": "Re: Lunch",
+		"RE: Lunch": "RE: Lunch",
+		"":          "Re: ",
+	} {
+		if got := replySubject(subject); got != want {
+			t.Errorf("replySubject(%q) = %q, want %q", subject, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"fmt"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type reportedMsg struct {
+	id string
+	// forwardedTo is the address the message was forwarded to, if any.
+	forwardedTo string
+	dryRun      bool
+	err         error
+}
+
+// reportBody is the text of a phishing report, above the attached original.
+func reportBody(d *gmailx.EmailDetail) string {
+	return fmt.Sprintf("Reported as phishing. The original message is attached.\n\nFrom:    %s\nDate:    %s\nSubject: %s\n", d.From, d.Date, d.Subject)
+}
+
+// reportCmd creates a command that forwards a message as an attachment to
+// the address to, unless it is empty, and then moves it to spam. Nothing is
+// forwarded in dry-run mode. Uses the configured timeout for all the calls.
+func (m model) reportCmd(d *gmailx.EmailDetail, to string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	timeout := m.settings.TimeoutSeconds
+	cache := m.cache
+	id := d.ID
+	subject := "Phishing report: " + d.Subject
+	body := reportBody(d)
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return reportedMsg{id: id, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return reportedMsg{id: id, err: err}
+		}
+		if dryRun {
+			return reportedMsg{id: id, dryRun: true, err: c.Spam(ctx, []string{id})}
+		}
+		if to != "" {
+			raw, err := c.GetRaw(ctx, id)
+			if err != nil {
+				return reportedMsg{id: id, err: fmt.Errorf("fetch original: %w", err)}
+			}
+			out := &gmailx.OutgoingMessage{To: to, Subject: subject, Body: body, Attached: raw}
+			if _, err := c.Send(ctx, out); err != nil {
+				return reportedMsg{id: id, err: fmt.Errorf("forward to %s: %w", to, err)}
+			}
+		}
+		if err := c.Spam(ctx, []string{id}); err != nil {
+			return reportedMsg{id: id, forwardedTo: to, err: err}
+		}
+		uncacheRemoved(cache, []string{id}, true)
+		return reportedMsg{id: id, forwardedTo: to}
+	}
+}
+
+// reportPhishing asks to report the open message as phishing: move it to
+// spam and, when report_address is set and sending is allowed, forward it
+// there first.
+func (m *model) reportPhishing() {
+	if m.detail == nil {
+		return
+	}
+	if m.offline {
+		m.status = "Offline — can't change messages"
+		return
+	}
+	to := m.settings.ReportAddress
+	if !m.can(capSend) {
+		to = ""
+	}
+	text := "Report this message as phishing? It will be moved to spam."
+	if to != "" {
+		text = "Report this message as phishing? It will be forwarded to " + to + " and moved to spam."
+	}
+	m.confirm = &confirmPrompt{text: text, onYes: m.reportCmd(m.detail, to)}
+}
+
+// reported records the outcome of a report. The message is in spam now, so
+// its row leaves the list and the view returns to it.
+func (m *model) reported(msg reportedMsg) tea.Cmd {
+	switch {
+	case msg.err != nil:
+		m.status = "Couldn't report the message: " + msg.err.Error()
+		return nil
+	case msg.dryRun:
+		m.status = dryRunStatus("report as phishing", 1)
+		return nil
+	}
+	m.status = "Reported as phishing and moved to spam"
+	if msg.forwardedTo != "" {
+		m.status = "Reported as phishing: forwarded to " + msg.forwardedTo + " and moved to spam"
+	}
+	m.removeRow(msg.id)
+	if m.detail != nil && m.detail.ID == msg.id && m.screen == screenDetail {
+		m.screen = screenInbox
+	}
+	return m.schedulePreview()
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hiddenRow is an inbox row taken out of the list by a row filter: hiding read
+// messages or showing one local flag. pos is its position among all loaded
+// rows, where it goes back when the filter is lifted.
+type hiddenRow struct {
+	pos  int
+	item list.Item
+}
+
+// rowHidden reports whether the active row filters keep it out of the list.
+func (m model) rowHidden(it list.Item) bool {
+	e, ok := it.(emailItem)
+	if !ok {
+		return false
+	}
+	if m.hideRead && !e.unread {
+		return true
+	}
+	return m.flagFilter != "" && m.flags.Get(e.id) != m.flagFilter
+}
+
+// setInboxItems replaces the inbox rows with items, leaving out those the row
+// filters hide.
+func (m *model) setInboxItems(items []list.Item) tea.Cmd {
+	m.hiddenRows = nil
+	if !m.hideRead && m.flagFilter == "" {
+		return m.inbox.SetItems(items)
+	}
+	var shown []list.Item
+	for i, it := range items {
+		if m.rowHidden(it) {
+			m.hiddenRows = append(m.hiddenRows, hiddenRow{pos: i, item: it})
+		} else {
+			shown = append(shown, it)
+		}
+	}
+	return m.inbox.SetItems(shown)
+}
+
+// addStreamedRow puts the next row of an inbox fetch into the list, or aside if
+// the row filters hide it. The first row replaces the rows of the previous
+// fetch.
+func (m *model) addStreamedRow(it list.Item) tea.Cmd {
+	if m.inboxLoaded == 0 {
+		return m.setInboxItems([]list.Item{it})
+	}
+	if m.rowHidden(it) {
+		m.hiddenRows = append(m.hiddenRows, hiddenRow{pos: m.inboxLoaded, item: it})
+		return nil
+	}
+	return m.inbox.InsertItem(m.inboxLoaded-len(m.hiddenRows), it)
+}
+
+// refilter applies the current row filters to every loaded row, hidden or not.
+// Nothing is fetched. Rows are only re-checked here and as they load, so one
+// marked read or reflagged stays where it is until the filters change.
+func (m *model) refilter() {
+	items := m.inbox.Items()
+	for _, h := range m.hiddenRows {
+		pos := min(h.pos, len(items))
+		items = append(items[:pos], append([]list.Item{h.item}, items[pos:]...)...)
+	}
+	m.setInboxItems(items)
+}
+
+// toggleHideRead hides the loaded read messages or brings them back.
+func (m *model) toggleHideRead() {
+	m.hideRead = !m.hideRead
+	m.refilter()
+}
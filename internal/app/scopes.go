@@ -0,0 +1,153 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gmail-tui/internal/config"
+)
+
+const (
+	gmailReadonlyScope = "https://www.googleapis.com/auth/gmail.readonly"
+	gmailSettingsScope = "https://www.googleapis.com/auth/gmail.settings.basic"
+	gmailModifyScope   = "https://www.googleapis.com/auth/gmail.modify"
+	gmailMetadataScope = "https://www.googleapis.com/auth/gmail.metadata"
+	gmailSendScope     = "https://www.googleapis.com/auth/gmail.send"
+	gmailComposeScope  = "https://www.googleapis.com/auth/gmail.compose"
+	gmailFullScope     = "https://mail.google.com/"
+)
+
+// scopeNames maps the short names accepted in the scopes setting to scopes.
+var scopeNames = map[string]string{
+	"readonly": gmailReadonlyScope,
+	"modify":   gmailModifyScope,
+	"send":     gmailSendScope,
+	"compose":  gmailComposeScope,
+	"settings": gmailSettingsScope,
+	"metadata": gmailMetadataScope,
+	"full":     gmailFullScope,
+}
+
+// RequestedScopes returns the OAuth scopes to request for settings: the
+// scopes setting, resolved from short names such as "modify" (full scope URLs
+// are accepted too), or only the metadata scope in metadata-only mode. All of
+// them are requested at login, so enabling a feature later doesn't need
+// another consent as long as its scope was listed.
+func RequestedScopes(settings config.Config) ([]string, error) {
+	if settings.MetadataOnly {
+		return []string{gmailMetadataScope}, nil
+	}
+	var scopes []string
+	for _, name := range settings.Scopes {
+		s, ok := scopeNames[name]
+		if strings.HasPrefix(name, "https://") {
+			s, ok = name, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown scope %q (use readonly, modify, send, compose, settings, metadata, full or a scope URL)", name)
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("no scopes configured")
+	}
+	return scopes, nil
+}
+
+// capability is a group of features that needs one of a set of scopes.
+type capability int
+
+const (
+	// capRead covers message bodies, threads, attachments and search, which
+	// the metadata scope can't do.
+	capRead capability = iota + 1
+	// capModify covers changing labels: archiving, marking read, muting.
+	capModify
+	// capSend covers composing and sending mail.
+	capSend
+	// capSettings covers filters, the signature and the vacation responder.
+	capSettings
+)
+
+// capabilityScopes lists the scopes that grant each capability.
+var capabilityScopes = map[capability][]string{
+	capRead:     {gmailReadonlyScope, gmailModifyScope, gmailFullScope},
+	capModify:   {gmailModifyScope, gmailFullScope},
+	capSend:     {gmailSendScope, gmailComposeScope, gmailModifyScope, gmailFullScope},
+	capSettings: {gmailSettingsScope, gmailFullScope},
+}
+
+// capabilityScopeNames names the usual scope for each capability, for
+// explaining why a key is unavailable.
+var capabilityScopeNames = map[capability]string{
+	capRead:     "readonly",
+	capModify:   "modify",
+	capSend:     "send",
+	capSettings: "settings",
+}
+
+// keyCapabilities are the keys, per screen, that need a capability. Search and
+// category filtering rely on the q parameter, which the metadata scope rejects
+// (label filtering uses label IDs, which it allows).
+var keyCapabilities = map[screen]map[string]capability{
+	screenInbox: {
+		"/": capRead, "a": capRead, "f": capRead, "G": capRead,
+		"ctrl+r": capModify, "ctrl+a": capModify, "ctrl+e": capModify, "e": capModify,
+		"c": capSend, "C": capSend,
+		"F": capSettings, "S": capSettings, "V": capSettings,
+	},
+	screenDetail: {
+		"t": capRead, "X": capRead, "E": capRead, "o": capRead, "a": capRead, "A": capRead, "s": capRead,
+		"m": capModify, "!": capModify,
+		"R": capSend,
+		"1": capSend, "2": capSend, "3": capSend, "4": capSend, "5": capSend,
+		"6": capSend, "7": capSend, "8": capSend, "9": capSend,
+	},
+}
+
+// can reports whether the token was granted a scope for c. Before a token
+// exists, or when it doesn't say what it was granted, the requested scopes
+// are assumed.
+func (m model) can(c capability) bool {
+	scopes, _ := m.grantedScopes()
+	for _, s := range scopes {
+		if slices.Contains(capabilityScopes[c], s) {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataOnly reports whether message content is out of reach, so details
+// have to be loaded as headers only.
+func (m model) metadataOnly() bool {
+	return !m.can(capRead)
+}
+
+// keyUnavailable reports whether key on the current screen needs a scope that
+// wasn't granted.
+func (m model) keyUnavailable(key string) bool {
+	c, ok := keyCapabilities[m.screen][key]
+	return ok && !m.can(c)
+}
+
+// keyBlocked reports whether key is unavailable on the current screen for
+// lack of a scope, setting a status that explains why and how to add the
+// scope.
+func (m *model) keyBlocked(key string) bool {
+	if !m.keyUnavailable(key) {
+		return false
+	}
+	if m.settings.MetadataOnly {
+		m.status = "Not available in metadata-only mode"
+	} else {
+		name := capabilityScopeNames[keyCapabilities[m.screen][key]]
+		scopes := append(slices.Clone(m.settings.Scopes), name)
+		m.status = fmt.Sprintf("Not available: needs the %s scope — run with --scopes %s (or add it to scopes in config.json) and log in again",
+			name, strings.Join(scopes, ","))
+	}
+	return true
+}
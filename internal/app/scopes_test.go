@@ -0,0 +1,106 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"gmail-tui/internal/config"
+
+	"golang.org/x/oauth2"
+)
+
+func TestRequestedScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		meta    bool
+		want    []string
+		wantErr bool
+	}{
+		{"default", config.Default().Scopes, false, []string{gmailReadonlyScope}, false},
+		{"names and URLs", []string{"modify", "send", gmailModifyScope}, false, []string{gmailModifyScope, gmailSendScope}, false},
+		{"metadata only", []string{"modify"}, true, []string{gmailMetadataScope}, false},
+		{"unknown name", []string{"everything"}, false, nil, true},
+		{"none", nil, false, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := config.Default()
+			settings.Scopes = tt.scopes
+			settings.MetadataOnly = tt.meta
+
+			got, err := RequestedScopes(settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RequestedScopes() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("RequestedScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// grantedModel returns a model logged in with a token granted scopes.
+func grantedModel(t *testing.T, scopes ...string) model {
+	t.Helper()
+	m, _ := testModel(t)
+	tok := (&oauth2.Token{AccessToken: "test"}).WithExtra(map[string]any{"scope": strings.Join(scopes, " ")})
+	m, _ = step(t, m, tokenLoadedMsg{tok: tok})
+	return m
+}
+
+func TestCanFollowsGrantedScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		want    map[capability]bool
+	}{
+		{"readonly", []string{gmailReadonlyScope},
+			map[capability]bool{capRead: true, capModify: false, capSend: false, capSettings: false}},
+		{"modify", []string{gmailModifyScope},
+			map[capability]bool{capRead: true, capModify: true, capSend: true, capSettings: false}},
+		{"metadata and send", []string{gmailMetadataScope, gmailSendScope},
+			map[capability]bool{capRead: false, capModify: false, capSend: true, capSettings: false}},
+		{"full", []string{gmailFullScope},
+			map[capability]bool{capRead: true, capModify: true, capSend: true, capSettings: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := grantedModel(t, tt.granted...)
+			for c, want := range tt.want {
+				if got := m.can(c); got != want {
+					t.Errorf("can(%s) = %v, want %v", capabilityScopeNames[c], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyBlockedExplainsMissingScope(t *testing.T) {
+	m := grantedModel(t, gmailReadonlyScope)
+
+	m, cmd := step(t, m, press("c"))
+	if m.screen != screenInbox || cmd != nil {
+		t.Errorf("after c: screen %v, cmd %v, want compose blocked", m.screen, cmd)
+	}
+	want := "Not available: needs the send scope — run with --scopes readonly,send (or add it to scopes in config.json) and log in again"
+	if m.status != want {
+		t.Errorf("status = %q, want %q", m.status, want)
+	}
+
+	m, _ = step(t, m, press("/"))
+	if m.screen != screenSearch {
+		t.Errorf("after /: screen %v, want search, which readonly allows", m.screen)
+	}
+}
+
+func TestKeyBlockedInMetadataOnlyMode(t *testing.T) {
+	m := grantedModel(t, gmailMetadataScope)
+	m.settings.MetadataOnly = true
+
+	m, _ = step(t, m, press("/"))
+	if m.screen != screenInbox || m.status != "Not available in metadata-only mode" {
+		t.Errorf("after /: screen %v, status %q, want search blocked", m.screen, m.status)
+	}
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchEstimateMsg carries Gmail's estimate of a search's size, checked before
+// the search is run.
+type searchEstimateMsg struct {
+	query      string
+	everywhere bool
+	estimate   int64
+	err        error
+}
+
+// searchConfirmedMsg runs a search the user agreed to load despite its size.
+type searchConfirmedMsg struct {
+	query      string
+	everywhere bool
+}
+
+// submitSearch runs the search entered on the search screen. When a size
+// threshold is configured, Gmail's estimate of the result count is fetched
+// first so a huge search can be confirmed before anything is loaded.
+func (m *model) submitSearch(query string, everywhere bool) tea.Cmd {
+	m.screen = screenInbox
+	if m.settings.ConfirmSearchOver <= 0 || query == "" {
+		return m.applySearch(query, everywhere)
+	}
+	m.status = "Counting results..."
+	return m.estimateSearchCmd(query, everywhere)
+}
+
+// applySearch replaces the inbox listing with the results of a search and
+// fetches their first page.
+func (m *model) applySearch(query string, everywhere bool) tea.Cmd {
+	m.labelID, m.labelName = "", ""
+	m.everywhere = everywhere
+	m.setQuery(query)
+	m.status = ""
+	return m.fetchInboxCmd()
+}
+
+// estimateSearchCmd creates a command that asks Gmail how many messages a search
+// matches. Uses the configured timeout for the API call.
+func (m model) estimateSearchCmd(query string, everywhere bool) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		msg := searchEstimateMsg{query: query, everywhere: everywhere}
+		if cfg == nil || tok == nil {
+			msg.err = errMissingCfg{}
+			return msg
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		msg.estimate, msg.err = c.EstimateResults(ctx, gmailx.ExpandQuery(query), "", everywhere)
+		return msg
+	}
+}
+
+// searchEstimated runs a search whose size estimate came back, asking first if
+// it is over the configured threshold. A failed estimate doesn't hold the
+// search up; the fetch reports the error if there is one.
+func (m *model) searchEstimated(msg searchEstimateMsg) tea.Cmd {
+	if msg.err != nil {
+		slog.Warn("failed to estimate search results", "err", msg.err)
+		return m.applySearch(msg.query, msg.everywhere)
+	}
+	if msg.estimate <= m.settings.ConfirmSearchOver {
+		return m.applySearch(msg.query, msg.everywhere)
+	}
+	m.status = ""
+	confirmed := searchConfirmedMsg{query: msg.query, everywhere: msg.everywhere}
+	m.confirm = &confirmPrompt{
+		text:  fmt.Sprintf("~%s results — load anyway? (only the first page will be fetched)", groupDigits(msg.estimate)),
+		onYes: func() tea.Msg { return confirmed },
+	}
+	return nil
+}
+
+// groupDigits formats n with commas between groups of three digits.
+func groupDigits(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
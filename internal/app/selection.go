@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// headerSummaryMsg carries the headers fetched for the selected messages,
+// keyed by ID; ids keeps the order they are listed in.
+type headerSummaryMsg struct {
+	ids     []string
+	details map[string]*gmailx.EmailDetail
+	err     error
+}
+
+// toggleSelected adds the message under the cursor to the selection, or takes
+// it out, and moves to the next row so several can be picked in a row.
+func (m *model) toggleSelected() {
+	it, ok := m.inbox.SelectedItem().(emailItem)
+	if !ok {
+		return
+	}
+	sel := maps.Clone(m.selected)
+	if sel == nil {
+		sel = map[string]bool{}
+	}
+	if sel[it.id] {
+		delete(sel, it.id)
+	} else {
+		sel[it.id] = true
+	}
+	m.setSelected(sel)
+	m.inbox.CursorDown()
+}
+
+// setSelected replaces the selection and reports its size.
+func (m *model) setSelected(sel map[string]bool) {
+	m.selected = sel
+	m.inbox.SetDelegate(m.inboxDelegate())
+	m.status = ""
+	if len(sel) > 0 {
+		m.status = fmt.Sprintf("%d selected (H headers)", len(sel))
+	}
+}
+
+// pruneSelection drops selected messages that are no longer loaded, e.g.
+// after moving to another page or label.
+func (m *model) pruneSelection() {
+	if len(m.selected) == 0 {
+		return
+	}
+	sel := map[string]bool{}
+	for _, e := range m.loadedItems() {
+		if m.selected[e.id] {
+			sel[e.id] = true
+		}
+	}
+	if len(sel) != len(m.selected) {
+		m.selected = sel
+		m.inbox.SetDelegate(m.inboxDelegate())
+	}
+}
+
+// selectedIDs returns the selected messages in the order they are listed.
+func (m model) selectedIDs() []string {
+	var ids []string
+	for _, e := range m.loadedItems() {
+		if m.selected[e.id] {
+			ids = append(ids, e.id)
+		}
+	}
+	return ids
+}
+
+// headerSummaryCmd creates a command that fetches the headers of the selected
+// messages, a few at a time. Uses the configured timeout for the API calls.
+func (m model) headerSummaryCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	ids := m.selectedIDs()
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return headerSummaryMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return headerSummaryMsg{err: err}
+		}
+		details, err := c.GetMetadataEach(ctx, ids)
+		return headerSummaryMsg{ids: ids, details: details, err: err}
+	}
+}
+
+// headerSummaryLoaded shows the fetched headers on the summary screen.
+func (m *model) headerSummaryLoaded(msg headerSummaryMsg) {
+	if msg.err != nil {
+		m.status = ""
+		m.err = msg.err
+		return
+	}
+	m.status = ""
+	m.summaryVP.SetContent(m.headerSummary(msg.ids, msg.details))
+	m.summaryVP.GotoTop()
+	m.screen = screenSummary
+}
+
+// headerSummary renders the headers of each message in ids, numbered in list
+// order, with label IDs shown by name where the labels are known.
+func (m model) headerSummary(ids []string, details map[string]*gmailx.EmailDetail) string {
+	names := map[string]string{}
+	for _, it := range m.labels.Items() {
+		if l, ok := it.(labelItem); ok {
+			names[l.id] = l.name
+		}
+	}
+	var b strings.Builder
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		d, ok := details[id]
+		if !ok {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, faint.Render("(couldn't load "+id+")"))
+			continue
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, bold.Render(d.Subject))
+		fmt.Fprintf(&b, "   From:   %s\n", d.From)
+		fmt.Fprintf(&b, "   To:     %s\n", d.To)
+		if d.Cc != "" {
+			fmt.Fprintf(&b, "   Cc:     %s\n", d.Cc)
+		}
+		fmt.Fprintf(&b, "   Date:   %s\n", d.Date)
+		var labels []string
+		for _, l := range d.LabelIDs {
+			if n, ok := names[l]; ok {
+				l = n
+			}
+			labels = append(labels, l)
+		}
+		fmt.Fprintf(&b, "   Labels: %s\n", strings.Join(labels, ", "))
+	}
+	return b.String()
+}
+
+// updateSummary handles keys on the header summary screen; the rest scroll it.
+func (m model) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "b", "esc":
+		m.screen = screenInbox
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.summaryVP, cmd = m.summaryVP.Update(msg)
+	return m, cmd
+}
+
+// summaryView renders the header summary screen.
+func (m model) summaryView() string {
+	h := bold.Render(fmt.Sprintf("Headers of %d selected messages", len(m.selectedIDs()))) + "\n" + m.footer()
+	return h + "\n\n" + m.summaryVP.View()
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"gmail-tui/internal/gmail/gmailtest"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestHeaderSummaryOfSelectedMessages(t *testing.T) {
+	m, s := testModel(t)
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("m1", "Invoice", "billing@example.com", "a", "INBOX"),
+		gmailtest.Message("m2", "Newsletter", "news@example.com", "b", "INBOX"),
+		gmailtest.Message("m3", "Trip plans", "ana@example.com", "c", "INBOX", "Label_1"),
+	}
+	m = loadInbox(t, loggedIn(t, m))
+
+	m, _ = step(t, m, press("H"))
+	if m.status != "Select messages with x first" {
+		t.Errorf("H without a selection: status %q, want a hint", m.status)
+	}
+
+	// Select m1, skip m2, select m3.
+	m, _ = step(t, m, press("x"))
+	m.inbox.CursorDown()
+	m, _ = step(t, m, press("x"))
+	if got := m.selectedIDs(); len(got) != 2 || got[0] != "m1" || got[1] != "m3" {
+		t.Fatalf("selected = %v, want [m1 m3]", got)
+	}
+	if m.status != "2 selected (H headers)" {
+		t.Errorf("status = %q, want the selection count", m.status)
+	}
+
+	m, cmd := step(t, m, press("H"))
+	if cmd == nil {
+		t.Fatal("H returned no command, want the headers fetched")
+	}
+	m, _ = step(t, m, cmd())
+	if m.screen != screenSummary || m.err != nil {
+		t.Fatalf("screen %v, err %v, want the summary", m.screen, m.err)
+	}
+	view := m.summaryVP.View()
+	for _, want := range []string{"1. ", "Invoice", "billing@example.com", "2. ", "Trip plans", "Label_1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("summary doesn't contain %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "Newsletter") {
+		t.Errorf("summary lists an unselected message:\n%s", view)
+	}
+
+	m, _ = step(t, m, press("b"))
+	if m.screen != screenInbox {
+		t.Errorf("after b: screen %v, want the inbox", m.screen)
+	}
+}
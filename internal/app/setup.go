@@ -0,0 +1,111 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gmail-tui/internal/store"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// missingCredentialsError reports that there is no OAuth client file, neither
+// at the configured path nor where the setup wizard saves one. The app shows
+// the wizard instead of failing.
+type missingCredentialsError struct {
+	path string
+}
+
+// Error returns the error message for a missing credentials file.
+func (e missingCredentialsError) Error() string {
+	return "missing credentials file " + e.path + ": download a Desktop app OAuth client from Google Cloud Console and save it there, or pass --credentials"
+}
+
+// setupSteps explains how to create the OAuth client the app signs in with.
+var setupSteps = []string{
+	"Open https://console.cloud.google.com/ and create or pick a project.",
+	"Under APIs & Services > Library, enable the Gmail API.",
+	"Under APIs & Services > OAuth consent screen, configure the app and add\n   your address as a test user.",
+	"Under APIs & Services > Credentials, choose Create credentials >\n   OAuth client ID, with application type Desktop app.",
+	"Download the client's JSON file and enter its path below.",
+}
+
+// newSetupInput creates the input for the path of a downloaded client file.
+func newSetupInput() textinput.Model {
+	in := textinput.New()
+	in.Prompt = "credentials file: "
+	in.Placeholder = "~/Downloads/client_secret_....json"
+	in.Width = 60
+	return in
+}
+
+// showSetup switches to the first-run setup wizard.
+func (m *model) showSetup() tea.Cmd {
+	m.err = nil
+	m.screen = screenSetup
+	m.status = ""
+	return m.setupInput.Focus()
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory and
+// drops the quotes terminals add around paths dropped onto them.
+func expandHome(path string) string {
+	path = strings.Trim(strings.TrimSpace(path), `"'`)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// updateSetup handles keys on the setup wizard: enter checks the entered file
+// and, if it is a usable Desktop app client, copies it into the data directory
+// and loads it.
+func (m model) updateSetup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.setupInput, cmd = m.setupInput.Update(msg)
+		return m, cmd
+	}
+	path := expandHome(m.setupInput.Value())
+	if path == "" {
+		return m, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		m.status = "Couldn't read the file: " + err.Error()
+		return m, nil
+	}
+	if _, err := validateCredentials(b); err != nil {
+		m.status = "That file won't work: " + err.Error()
+		return m, nil
+	}
+	saved, err := store.SaveCredentials(b)
+	if err != nil {
+		m.status = "Couldn't save the credentials: " + err.Error()
+		return m, nil
+	}
+	m.settings.CredentialsPath = saved
+	m.setupInput.Blur()
+	m.status = "Checking credentials..."
+	return m, m.loadCfgCmd()
+}
+
+// setupView renders the setup wizard.
+func (m model) setupView() string {
+	var b strings.Builder
+	b.WriteString(bold.Render("Welcome! Let's connect your Gmail account.") + "\n\n")
+	b.WriteString("No OAuth client file was found. To create one:\n\n")
+	for i, s := range setupSteps {
+		b.WriteString(string(rune('1'+i)) + ". " + s + "\n")
+	}
+	b.WriteString("\n" + m.setupInput.View() + "\n")
+	if m.status != "" {
+		b.WriteString("\n" + m.status + "\n")
+	}
+	b.WriteString("\n" + faint.Render("enter use this file • ctrl+c quit"))
+	return b.String()
+}
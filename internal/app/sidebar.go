@@ -0,0 +1,186 @@
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sidebarWidth is the width of the labels sidebar, without its border.
+const sidebarWidth = 24
+
+// sidebarMinWidth is the narrowest terminal that shows the labels sidebar;
+// below it the sidebar stays hidden until the terminal is widened.
+const sidebarMinWidth = 90
+
+// sidebarSystemLabels are the system labels listed in the sidebar, after the
+// inbox and before the user's own labels, with the names they are shown by.
+var sidebarSystemLabels = []labelItem{
+	{id: "STARRED", name: "Starred"},
+	{id: "IMPORTANT", name: "Important"},
+	{id: "SENT", name: "Sent"},
+	{id: "DRAFT", name: "Drafts"},
+}
+
+type unreadCountsMsg struct {
+	counts map[string]int64
+	err    error
+}
+
+// sidebarActive reports whether the inbox is currently rendered with the
+// labels sidebar.
+func (m model) sidebarActive() bool {
+	return m.settings.LabelsSidebar && m.width >= sidebarMinWidth
+}
+
+// sidebarEntries returns the labels listed in the sidebar: the inbox, the
+// system labels that exist, then the user's labels by name. The inbox entry
+// has an empty ID, as the unfiltered inbox does.
+func (m model) sidebarEntries() []labelItem {
+	items := m.labels.Items()
+	entries := []labelItem{{name: "Inbox"}}
+	for _, s := range sidebarSystemLabels {
+		if slices.ContainsFunc(items, func(it list.Item) bool {
+			l, ok := it.(labelItem)
+			return ok && l.id == s.id
+		}) {
+			entries = append(entries, s)
+		}
+	}
+	var user []labelItem
+	for _, it := range items {
+		if l, ok := it.(labelItem); ok && strings.HasPrefix(l.id, "Label_") {
+			user = append(user, l)
+		}
+	}
+	slices.SortFunc(user, func(a, b labelItem) int {
+		return cmp.Compare(strings.ToLower(a.name), strings.ToLower(b.name))
+	})
+	return append(entries, user...)
+}
+
+// sidebarIndex returns the position of the label the inbox is filtered by
+// among entries, or -1 if it isn't listed.
+func (m model) sidebarIndex(entries []labelItem) int {
+	id := m.labelID
+	if id == "INBOX" {
+		id = ""
+	}
+	return slices.IndexFunc(entries, func(l labelItem) bool { return l.id == id })
+}
+
+// sidebarView renders the labels sidebar, marking the label the inbox is
+// filtered by and showing each label's unread count.
+func (m model) sidebarView(height int) string {
+	entries := m.sidebarEntries()
+	active := m.sidebarIndex(entries)
+	var lines []string
+	for i, l := range entries {
+		count := ""
+		id := cmp.Or(l.id, "INBOX")
+		if n := m.unreadCounts[id]; n > 0 {
+			count = fmt.Sprint(n)
+		}
+		marker := "  "
+		if i == active {
+			marker = "▸ "
+		}
+		line := marker + fitWidth(l.name, sidebarWidth-len(marker)-len(count)-1) + " " + count
+		if i == active {
+			line = bold.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(entries) == 1 && m.labelsAt.IsZero() {
+		lines = append(lines, faint.Render("  loading labels..."))
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	return lipgloss.NewStyle().
+		Width(sidebarWidth).
+		Height(height).
+		Border(lipgloss.NormalBorder(), false, true, false, false).
+		Render(strings.Join(lines, "\n"))
+}
+
+// sidebarCmd loads what the sidebar shows when it is on: the labels, the
+// first time, then their unread counts.
+func (m model) sidebarCmd() tea.Cmd {
+	if !m.settings.LabelsSidebar {
+		return nil
+	}
+	if m.labelsAt.IsZero() {
+		fetch := m.fetchLabelsCmd()
+		return func() tea.Msg {
+			msg := fetch().(labelsMsg)
+			msg.sidebar = true
+			return msg
+		}
+	}
+	return m.unreadCountsCmd()
+}
+
+// sidebarLoaded keeps labels fetched for the sidebar and loads their counts.
+func (m *model) sidebarLoaded(msg labelsMsg) tea.Cmd {
+	if msg.err != nil {
+		slog.Warn("failed to load labels for the sidebar", "err", msg.err)
+		return nil
+	}
+	m.labels.SetItems(msg.items)
+	m.labelsAt = time.Now()
+	return m.unreadCountsCmd()
+}
+
+// unreadCountsCmd creates a command that fetches the unread counts of the
+// labels listed in the sidebar. Uses the configured timeout for the calls.
+func (m model) unreadCountsCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	var ids []string
+	for _, l := range m.sidebarEntries() {
+		ids = append(ids, cmp.Or(l.id, "INBOX"))
+	}
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return unreadCountsMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return unreadCountsMsg{err: err}
+		}
+		counts, err := c.UnreadCounts(ctx, ids)
+		return unreadCountsMsg{counts: counts, err: err}
+	}
+}
+
+// switchSidebarLabel filters the inbox by the sidebar entry delta places
+// from the current one, wrapping around, and fetches it.
+func (m *model) switchSidebarLabel(delta int) tea.Cmd {
+	entries := m.sidebarEntries()
+	i := max(m.sidebarIndex(entries), 0)
+	l := entries[((i+delta)%len(entries)+len(entries))%len(entries)]
+	name := l.name
+	if l.id == "" {
+		name = ""
+	}
+	m.setLabel(l.id, name)
+	m.status = ""
+	return m.fetchInboxCmd()
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"strings"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// signatureMsg carries a fetched signature. compose is set when it was
+// fetched for appending to composed messages rather than for editing.
+type signatureMsg struct {
+	sig     *gmailx.Signature
+	compose bool
+	err     error
+}
+
+type signatureSavedMsg struct {
+	sig *gmailx.Signature
+	err error
+}
+
+// newSignatureInput creates the multi-line editor used on the signature screen.
+func newSignatureInput() textarea.Model {
+	ta := textarea.New()
+	ta.Placeholder = "Your signature"
+	ta.ShowLineNumbers = false
+	ta.SetWidth(60)
+	ta.SetHeight(8)
+	return ta
+}
+
+// fetchSignatureCmd creates a command that fetches the primary send-as signature.
+// Uses the configured timeout for the API call.
+func (m model) fetchSignatureCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return signatureMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return signatureMsg{err: err}
+		}
+		sig, err := c.GetSignature(ctx)
+		if err != nil {
+			return signatureMsg{err: err}
+		}
+		return signatureMsg{sig: sig}
+	}
+}
+
+// composeSignatureCmd fetches the signature appended to composed messages,
+// unless that is turned off or the settings scope wasn't granted.
+func (m model) composeSignatureCmd() tea.Cmd {
+	if m.settings.NoSignature || !m.can(capSettings) {
+		return nil
+	}
+	fetch := m.fetchSignatureCmd()
+	return func() tea.Msg {
+		msg := fetch().(signatureMsg)
+		msg.compose = true
+		return msg
+	}
+}
+
+// signatureBlock returns what is appended to a composed message: the
+// signature below the standard "-- " delimiter, or "" when there is none to
+// append or body already ends with it.
+func (m model) signatureBlock(body string) string {
+	if m.settings.NoSignature || m.signature == nil {
+		return ""
+	}
+	text := strings.TrimSpace(m.signature.Text)
+	if text == "" || strings.Contains(body, "\n-- \n"+text) {
+		return ""
+	}
+	return "\n-- \n" + text
+}
+
+// saveSignatureCmd creates a command that writes the signature back to Gmail.
+// Uses the configured timeout for the API call.
+func (m model) saveSignatureCmd(sig *gmailx.Signature) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return signatureSavedMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return signatureSavedMsg{err: err}
+		}
+		if err := c.UpdateSignature(ctx, sig); err != nil {
+			return signatureSavedMsg{err: err}
+		}
+		return signatureSavedMsg{sig: sig}
+	}
+}
+
+// updateSignature handles key presses on the signature editor.
+// ctrl+s saves the edited text and esc returns to the inbox without saving.
+func (m model) updateSignature(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.sigInput.Blur()
+		m.screen = screenInbox
+		return m, nil
+	case "ctrl+s":
+		if m.offline {
+			m.status = "Offline — changes can't be saved until the connection is back"
+			return m, nil
+		}
+		if m.signature == nil {
+			return m, nil
+		}
+		sig := &gmailx.Signature{Email: m.signature.Email, Text: m.sigInput.Value()}
+		m.status = "Saving signature..."
+		return m, m.saveSignatureCmd(sig)
+	}
+	var cmd tea.Cmd
+	m.sigInput, cmd = m.sigInput.Update(msg)
+	return m, cmd
+}
+
+// signatureView renders the signature editor.
+func (m model) signatureView() string {
+	body := "Signature"
+	if m.signature != nil {
+		body += " for " + m.signature.Email
+	}
+	body += "\n\n" + m.sigInput.View() + "\n"
+	if m.status != "" {
+		body += "\n" + m.status + "\n"
+	}
+	return body + "\n" + m.footer()
+}
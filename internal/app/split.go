@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// splitMinWidth is the narrowest terminal that renders the inbox and preview
+// side by side; below it split view falls back to the plain list.
+const splitMinWidth = 120
+
+type previewTickMsg struct {
+	seq int
+	id  string
+}
+
+type previewMsg struct {
+	id      string
+	content string
+	err     error
+}
+
+// splitActive reports whether the inbox is currently rendered with a preview pane.
+func (m model) splitActive() bool {
+	return m.splitView && m.width >= splitMinWidth
+}
+
+// frameHeight is how many lines the padding and border around every screen
+// take, counting the newline after them.
+const frameHeight = 7
+
+// bodyHeight returns how many lines are left for a screen's list or viewport
+// below header and the blank line after it, so the screen fits the terminal.
+func (m model) bodyHeight(header string) int {
+	return max(m.height-frameHeight-lipgloss.Height(header)-1, 1)
+}
+
+// resize lays out the lists and viewports for the current terminal size,
+// leaving room for the labels sidebar when it is shown and giving the inbox
+// list half of what remains when split view is active. The inbox, the open
+// message and the attachments get what their headers leave, which changes
+// with the status lines, so it is called after every update. Nothing is
+// drawn beyond the terminal's height, which matters when running inline,
+// where lines pushed above the top can't be redrawn.
+func (m *model) resize() {
+	w, h := m.width-6, m.height-10
+	m.labels.SetSize(w, h)
+	m.filters.SetSize(w, h)
+	m.attachments.SetSize(w, m.bodyHeight(m.titleView()+"\n"+m.attachmentsHeader()))
+	m.palette.SetSize(w, h-2)
+	m.labelPick.SetSize(w, h-2)
+	m.detailVP.Width = w
+	m.detailVP.Height = m.bodyHeight(m.detailHeader())
+	m.summaryVP.Width = w
+	m.summaryVP.Height = h - 2
+	if m.screen == screenCompose {
+		m.fitComposeBody()
+	}
+	h = m.bodyHeight(m.inboxHeader())
+	if m.sidebarActive() {
+		w -= sidebarWidth + 2
+	}
+	if m.splitActive() {
+		listW := w / 2
+		m.inbox.SetSize(listW, h)
+		m.previewVP.Width = w - listW - 3
+		m.previewVP.Height = h
+		return
+	}
+	if m.expandedID != "" {
+		h -= m.inboxDelegate().expandedExtraLines()
+	}
+	m.inbox.SetSize(w, h)
+}
+
+// schedulePreview starts the debounce timer for previewing the selected row.
+// Each call bumps the sequence number so that only the last tick, fired after the
+// cursor has stopped moving, triggers a fetch, and cancels the fetch for the
+// previously previewed row if it is still pending. Without split view the
+// row's snippet is expanded in place instead, if enabled.
+func (m *model) schedulePreview() tea.Cmd {
+	if !m.splitActive() {
+		return m.scheduleExpand()
+	}
+	it, ok := m.inbox.SelectedItem().(emailItem)
+	if !ok || it.id == m.previewID {
+		return nil
+	}
+	m.cancelPreview()
+	m.previewSeq++
+	seq := m.previewSeq
+	debounce := time.Duration(m.settings.PreviewDebounceMS) * time.Millisecond
+	return tea.Tick(debounce, func(time.Time) tea.Msg {
+		return previewTickMsg{seq: seq, id: it.id}
+	})
+}
+
+// cancelPreview stops the pending preview fetch, if any.
+func (m *model) cancelPreview() {
+	if m.previewCancel != nil {
+		m.previewCancel()
+		m.previewCancel = nil
+	}
+}
+
+// fetchPreviewCmd creates a command that fetches a message for the preview pane.
+// Uses the configured timeout for the API call. The fetch waits for a slot in
+// the preview limiter first and gives up silently if it is cancelled, which
+// happens when the cursor moves to another row.
+func (m *model) fetchPreviewCmd(id string) tea.Cmd {
+	cfg := m.cfg
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	maxBody := m.settings.MaxBodyBytes
+	metadataOnly := m.metadataOnly()
+	expandQuotes := m.settings.ExpandQuotes
+	fullAddresses := m.settings.FullAddresses
+	headers := m.settings.HeaderDetail
+	sem := m.previewSem
+
+	m.cancelPreview()
+	root, stop := context.WithCancel(m.ctx)
+	m.previewCancel = stop
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return previewMsg{id: id, err: errMissingCfg{}}
+		}
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-root.Done():
+			return previewMsg{id: id, err: root.Err()}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return previewMsg{id: id, err: err}
+		}
+		d, err := getDetail(ctx, c, id, maxBody, metadataOnly)
+		if err != nil {
+			return previewMsg{id: id, err: err}
+		}
+		return previewMsg{id: id, content: formatDetail(withAddresses(withQuotes(d, expandQuotes), fullAddresses), headers)}
+	}
+}
+
+// inboxListView renders the inbox list, joined with the preview pane when
+// split view is active and after the labels sidebar when it is shown.
+func (m model) inboxListView() string {
+	view := m.inbox.View()
+	if m.splitActive() {
+		preview := lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder(), false, false, false, true).
+			PaddingLeft(1).
+			Render(m.previewVP.View())
+		view = lipgloss.JoinHorizontal(lipgloss.Top, view, " ", preview)
+	}
+	if !m.sidebarActive() {
+		return view
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, m.sidebarView(lipgloss.Height(view)), " ", view)
+}
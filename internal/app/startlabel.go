@@ -0,0 +1,72 @@
+package app
+
+import (
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StartOptions choose what the inbox shows when the app starts, from the
+// command line.
+type StartOptions struct {
+	// Label restricts the inbox to the label with this name or ID. It is
+	// resolved once logged in; a label that doesn't exist is an error.
+	Label string
+	// Query is a Gmail search applied to the inbox, or to Label when set.
+	Query string
+}
+
+// startLabelMsg carries the label StartOptions.Label was resolved to.
+type startLabelMsg struct {
+	label gmailx.Label
+	err   error
+}
+
+// openInboxCmd loads the inbox after logging in, resolving the start label
+// first if there is one.
+func (m model) openInboxCmd() tea.Cmd {
+	if m.startLabel != "" {
+		return m.resolveStartLabelCmd()
+	}
+	return m.fetchInboxCmd()
+}
+
+// resolveStartLabelCmd looks up the start label by name.
+// Uses the configured timeout for the API call.
+func (m model) resolveStartLabelCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	name := m.startLabel
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return startLabelMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return startLabelMsg{err: err}
+		}
+		label, err := c.ResolveLabel(ctx, name)
+		return startLabelMsg{label: label, err: err}
+	}
+}
+
+// startLabelResolved restricts the inbox to the resolved start label, keeping
+// the start query, and loads it. A label that couldn't be resolved is shown
+// as an error.
+func (m *model) startLabelResolved(msg startLabelMsg) tea.Cmd {
+	m.startLabel = ""
+	if msg.err != nil {
+		m.err = msg.err
+		return nil
+	}
+	m.labelID, m.labelName = msg.label.ID, msg.label.Name
+	m.inbox.SetDelegate(m.inboxDelegate())
+	return m.fetchInboxCmd()
+}
@@ -0,0 +1,215 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gmail-tui/internal/store"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mailTemplate is a reusable message loaded from the templates directory.
+// A template file starts with optional "To:" and "Subject:" header lines,
+// then a blank line, then the body. Any part may contain {{placeholders}},
+// which are asked for when the template is inserted.
+type mailTemplate struct {
+	name    string
+	to      string
+	subject string
+	body    string
+}
+
+// Title returns the template's file name without its extension.
+func (t mailTemplate) Title() string { return t.name }
+
+// Description returns the template's subject.
+func (t mailTemplate) Description() string { return t.subject }
+
+// FilterValue returns the name and subject for filtering in the picker.
+func (t mailTemplate) FilterValue() string { return t.name + " " + t.subject }
+
+var placeholderRE = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// parseTemplate reads a template file's contents. Lines before the first blank
+// line are headers only if every one of them is a To or Subject header;
+// otherwise the whole file is the body.
+func parseTemplate(name, s string) mailTemplate {
+	t := mailTemplate{name: name}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	head, body, found := strings.Cut(s, "\n\n")
+	if !found {
+		t.body = s
+		return t
+	}
+	var to, subject string
+	for _, l := range strings.Split(head, "\n") {
+		k, v, ok := strings.Cut(l, ":")
+		switch {
+		case ok && strings.EqualFold(strings.TrimSpace(k), "to"):
+			to = strings.TrimSpace(v)
+		case ok && strings.EqualFold(strings.TrimSpace(k), "subject"):
+			subject = strings.TrimSpace(v)
+		default:
+			t.body = s
+			return t
+		}
+	}
+	t.to, t.subject, t.body = to, subject, body
+	return t
+}
+
+// loadTemplates reads every *.txt file in the templates directory, sorted by
+// name. A missing directory means there are no templates.
+func loadTemplates() ([]mailTemplate, error) {
+	dir, err := store.TemplatesDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	var out []mailTemplate
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, parseTemplate(strings.TrimSuffix(filepath.Base(p), ".txt"), string(b)))
+	}
+	return out, nil
+}
+
+// placeholders returns the distinct placeholder names used in a template, in
+// order of first appearance.
+func (t mailTemplate) placeholders() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, s := range []string{t.to, t.subject, t.body} {
+		for _, m := range placeholderRE.FindAllStringSubmatch(s, -1) {
+			if !seen[m[1]] {
+				seen[m[1]] = true
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+// fill substitutes the placeholder values into the template.
+func (t mailTemplate) fill(values map[string]string) mailTemplate {
+	sub := func(s string) string {
+		return placeholderRE.ReplaceAllStringFunc(s, func(p string) string {
+			return values[placeholderRE.FindStringSubmatch(p)[1]]
+		})
+	}
+	t.to, t.subject, t.body = sub(t.to), sub(t.subject), sub(t.body)
+	return t
+}
+
+// templateInsert is a template being inserted into the compose screen: first
+// picked from a list, then its placeholders asked for one at a time.
+type templateInsert struct {
+	picker list.Model
+	picked *mailTemplate
+	names  []string
+	values map[string]string
+	input  textinput.Model
+	asking int
+}
+
+// openTemplatePicker starts inserting a template into the message being composed.
+func (m *model) openTemplatePicker() {
+	if len(m.templates) == 0 {
+		dir, _ := store.TemplatesDir()
+		m.status = "No templates found in " + dir
+		return
+	}
+	items := make([]list.Item, len(m.templates))
+	for i, t := range m.templates {
+		items[i] = t
+	}
+	l := list.New(items, list.NewDefaultDelegate(), m.width-6, m.height-10)
+	l.Title = "Templates"
+	l.SetShowHelp(false)
+	l.SetFilteringEnabled(false)
+	m.tmpl = &templateInsert{picker: l}
+}
+
+// updateTemplate handles keys while a template is being inserted: choosing it
+// in the picker, then entering each placeholder value. esc cancels.
+func (m model) updateTemplate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	ti := m.tmpl
+	if msg.String() == "esc" {
+		m.tmpl = nil
+		m.status = "Template not inserted"
+		return m, nil
+	}
+	if ti.picked == nil {
+		if msg.String() != "enter" {
+			var cmd tea.Cmd
+			ti.picker, cmd = ti.picker.Update(msg)
+			return m, cmd
+		}
+		t, ok := ti.picker.SelectedItem().(mailTemplate)
+		if !ok {
+			return m, nil
+		}
+		ti.picked = &t
+		ti.names = t.placeholders()
+		ti.values = map[string]string{}
+		ti.input = textinput.New()
+		ti.input.Width = 40
+	} else if msg.String() == "enter" {
+		ti.values[ti.names[ti.asking]] = ti.input.Value()
+		ti.asking++
+	} else {
+		var cmd tea.Cmd
+		ti.input, cmd = ti.input.Update(msg)
+		return m, cmd
+	}
+
+	if ti.asking < len(ti.names) {
+		ti.input.Prompt = ti.names[ti.asking] + ": "
+		ti.input.SetValue("")
+		return m, ti.input.Focus()
+	}
+	m.applyTemplate(ti.picked.fill(ti.values))
+	m.tmpl = nil
+	return m, nil
+}
+
+// applyTemplate fills the compose fields from a template. Header fields the
+// template leaves empty keep what was already typed.
+func (m *model) applyTemplate(t mailTemplate) {
+	if t.to != "" {
+		m.composeInputs[composeTo].SetValue(t.to)
+	}
+	if t.subject != "" {
+		m.composeInputs[composeSubject].SetValue(t.subject)
+	}
+	m.composeBody.SetValue(t.body)
+	m.status = "Inserted template " + t.name
+	m.focusCompose(m.composeFocus)
+}
+
+// templateView renders the template picker or the current placeholder prompt.
+func (m model) templateView() string {
+	ti := m.tmpl
+	if ti.picked == nil {
+		return ti.picker.View() + "\n" + faint.Render("enter insert • esc cancel")
+	}
+	return "Template " + ti.picked.name + "\n\n" + ti.input.View() + "\n\n" +
+		faint.Render("enter next • esc cancel")
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type threadMsg struct {
+	thread *gmailx.Thread
+	err    error
+}
+
+// fetchThreadCmd creates a command that fetches every message in a thread.
+// Uses the configured timeout for the whole thread, since its messages load in parallel.
+func (m model) fetchThreadCmd(threadID string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	maxBody := m.settings.MaxBodyBytes
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return threadMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return threadMsg{err: err}
+		}
+		t, err := c.GetThread(ctx, threadID, maxBody)
+		if err != nil {
+			return threadMsg{err: err}
+		}
+		return threadMsg{thread: t}
+	}
+}
+
+// threadOrder returns the indices of msgs sorted by date, oldest first, or
+// newest first when newestFirst is set. A message without a parseable date
+// takes the date of the one before it in the API's order, so it stays next to
+// it, and ties keep the API's order.
+func threadOrder(msgs []gmailx.EmailDetail, newestFirst bool) []int {
+	order := make([]int, len(msgs))
+	keys := make([]time.Time, len(msgs))
+	var last time.Time
+	for i, d := range msgs {
+		order[i] = i
+		if !d.Time.IsZero() {
+			last = d.Time
+		}
+		keys[i] = last
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if newestFirst {
+			return keys[b].Compare(keys[a])
+		}
+		return keys[a].Compare(keys[b])
+	})
+	return order
+}
+
+// formatThread renders all messages of a thread one after another in date
+// order, separated by a rule, with a note about any messages that couldn't be
+// loaded. Quoted reply text is folded unless expandQuotes is set, senders
+// and recipients are shown by display name unless fullAddresses is set, and
+// each message's headers are shown as the HeaderDetail setting headers says.
+func formatThread(t *gmailx.Thread, expandQuotes, newestFirst, fullAddresses bool, headers string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Thread: %d messages", len(t.Messages)+len(t.Failed))
+	if newestFirst {
+		b.WriteString(", newest first")
+	}
+	b.WriteString("\n")
+	if len(t.Failed) > 0 {
+		fmt.Fprintf(&b, "(%d messages failed to load)\n", len(t.Failed))
+	}
+	for _, i := range threadOrder(t.Messages, newestFirst) {
+		b.WriteString("\n" + strings.Repeat("─", 40) + "\n\n")
+		b.WriteString(formatDetail(withAddresses(withQuotes(&t.Messages[i], expandQuotes), fullAddresses), headers))
+	}
+	return b.String()
+}
+
+// threadContent renders the open thread for the detail viewport.
+func (m model) threadContent() string {
+	return formatThread(m.thread, m.expandQuotes, m.settings.ThreadNewestFirst, m.settings.FullAddresses, m.settings.HeaderDetail)
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"slices"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// threadSizesMsg carries the number of messages in each thread of the loaded
+// inbox rows, by thread ID.
+type threadSizesMsg struct {
+	sizes map[string]int
+	err   error
+}
+
+// loadedItems returns every loaded inbox row, including those hidden by a
+// row filter.
+func (m model) loadedItems() []emailItem {
+	var out []emailItem
+	for _, it := range m.inbox.Items() {
+		if e, ok := it.(emailItem); ok {
+			out = append(out, e)
+		}
+	}
+	for _, h := range m.hiddenRows {
+		if e, ok := h.item.(emailItem); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// threadSizesCmd creates a command that fetches the sizes of the threads of
+// the loaded inbox rows, so conversations can be told apart from one-off
+// messages. Uses the configured timeout for the calls.
+func (m model) threadSizesCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	var ids []string
+	for _, e := range m.loadedItems() {
+		if e.threadID != "" && !slices.Contains(ids, e.threadID) {
+			ids = append(ids, e.threadID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return threadSizesMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return threadSizesMsg{err: err}
+		}
+		sizes, err := c.ThreadSizes(ctx, ids)
+		return threadSizesMsg{sizes: sizes, err: err}
+	}
+}
+
+// setThreadSizes records thread sizes on the loaded inbox rows, hidden ones
+// included, and in the cached rows so they show offline too.
+func (m *model) setThreadSizes(sizes map[string]int) {
+	sized := func(it list.Item) list.Item {
+		if e, ok := it.(emailItem); ok && sizes[e.threadID] > 0 {
+			e.threadSize = sizes[e.threadID]
+			return e
+		}
+		return it
+	}
+	items := m.inbox.Items()
+	for i, it := range items {
+		items[i] = sized(it)
+	}
+	m.inbox.SetItems(items)
+	for i, h := range m.hiddenRows {
+		m.hiddenRows[i].item = sized(h.item)
+	}
+	editCachedRows(m.cache, func(rows []gmailx.EmailRow) []gmailx.EmailRow {
+		for i, r := range rows {
+			if n := sizes[r.ThreadID]; n > 0 {
+				rows[i].ThreadSize = n
+			}
+		}
+		return rows
+	})
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Triage actions that can be configured for the mark-and-next key.
+const (
+	triageArchive = "archive"
+	triageRead    = "read"
+	triageTrash   = "trash"
+)
+
+type triagedMsg struct {
+	action string
+	id     string
+	dryRun bool
+	err    error
+}
+
+// triageVerbs describes each triage action for status messages.
+var triageVerbs = map[string]string{
+	triageArchive: "archive",
+	triageRead:    "mark as read",
+	triageTrash:   "trash",
+}
+
+// dryRunStatus is the status shown instead of applying a change in dry-run mode.
+func dryRunStatus(verb string, n int) string {
+	return fmt.Sprintf("DRY RUN: would %s %d message(s)", verb, n)
+}
+
+// triageCmd creates a command that applies the triage action to one message.
+// Uses the configured timeout for the API call.
+func (m model) triageCmd(action, id string) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	dryRun := m.settings.DryRun
+	timeout := m.settings.TimeoutSeconds
+	cache := m.cache
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return triagedMsg{action: action, id: id, err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok, gmailx.WithDryRun(dryRun))
+		if err != nil {
+			return triagedMsg{action: action, id: id, err: err}
+		}
+		ids := []string{id}
+		switch action {
+		case triageRead:
+			err = c.MarkRead(ctx, ids)
+		case triageTrash:
+			err = c.Trash(ctx, ids)
+		default:
+			err = c.Archive(ctx, ids)
+		}
+		if err == nil && !dryRun {
+			if action == triageRead {
+				cacheRead(cache, ids)
+			} else {
+				uncacheRemoved(cache, ids, action == triageTrash)
+			}
+		}
+		return triagedMsg{action: action, id: id, dryRun: dryRun, err: err}
+	}
+}
+
+// triageNext applies the configured triage action to the selected message and
+// moves on to the next one. The list is updated optimistically: archived and
+// trashed rows are removed so the next row slides under the cursor, and read
+// rows are marked and the cursor advances. A failure reloads the inbox. In
+// dry-run mode nothing changes, so the row is left as it is and the cursor
+// just advances.
+func (m *model) triageNext() tea.Cmd {
+	if m.offline {
+		m.status = "Offline — can't change messages"
+		return nil
+	}
+	it, ok := m.inbox.SelectedItem().(emailItem)
+	if !ok {
+		return nil
+	}
+	action := m.settings.TriageAction
+	switch {
+	case action != triageRead && action != triageTrash && action != triageArchive:
+		m.status = fmt.Sprintf("Unknown triage_action %q (use archive, read or trash)", action)
+		return nil
+	case m.settings.DryRun:
+		m.inbox.CursorDown()
+	case action == triageRead:
+		m.setRead([]string{it.id})
+		m.inbox.CursorDown()
+	default:
+		m.inbox.RemoveItem(m.inbox.Index())
+	}
+	m.status = ""
+	return tea.Batch(m.triageCmd(action, it.id), m.schedulePreview())
+}
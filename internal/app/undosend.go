@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pendingSend is a message that was sent from the compose screen but not yet
+// delivered. Until sending is set it is only waiting out the undo window. The
+// compose fields keep its contents until it is delivered, so undoing or a
+// failed send can reopen it as it was.
+type pendingSend struct {
+	out     *gmailx.OutgoingMessage
+	seq     int
+	sending bool
+}
+
+// sendDueMsg reports that the undo window of the pending send seq has elapsed.
+type sendDueMsg struct {
+	seq int
+}
+
+// queueSend sends out after the undo window, leaving the compose screen in the
+// meantime; with no window it is sent at once from the compose screen.
+func (m *model) queueSend(out *gmailx.OutgoingMessage) tea.Cmd {
+	m.sendSeq++
+	m.pendingSend = &pendingSend{out: out, seq: m.sendSeq}
+	wait := time.Duration(m.settings.UndoSendSeconds) * time.Second
+	if wait <= 0 {
+		m.pendingSend.sending = true
+		m.status = "Sending..."
+		return m.sendCmd(out)
+	}
+	m.leaveCompose()
+	m.status = fmt.Sprintf("Sending in %ds… press u to cancel", m.settings.UndoSendSeconds)
+	seq := m.sendSeq
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return sendDueMsg{seq: seq}
+	})
+}
+
+// sendDue sends the pending message once its undo window has elapsed, unless
+// it was cancelled in the meantime.
+func (m *model) sendDue(msg sendDueMsg) tea.Cmd {
+	p := m.pendingSend
+	if p == nil || p.seq != msg.seq || p.sending {
+		return nil
+	}
+	p.sending = true
+	m.status = "Sending..."
+	return m.sendCmd(p.out)
+}
+
+// undoSend cancels the message waiting out its undo window and reopens it on
+// the compose screen.
+func (m *model) undoSend() {
+	m.pendingSend = nil
+	m.reopenCompose()
+	m.status = "Send cancelled"
+}
+
+// reopenCompose returns to the compose screen with the fields as they were
+// left, focusing the body.
+func (m *model) reopenCompose() {
+	m.screen = screenCompose
+	m.focusCompose(composeBody)
+}
+
+// leaveCompose blurs the compose fields and returns to the inbox without
+// clearing them.
+func (m *model) leaveCompose() {
+	for i := range m.composeInputs {
+		m.composeInputs[i].Blur()
+	}
+	m.composeBody.Blur()
+	m.screen = screenInbox
+}
+
+// composeBusy reports whether a message is still being sent, setting a status
+// that explains why a new one can't be started yet. Its contents are kept in
+// the compose fields until it is delivered.
+func (m *model) composeBusy() bool {
+	if m.pendingSend == nil {
+		return false
+	}
+	if m.pendingSend.sending {
+		m.status = "Wait for the message being sent to go out"
+	} else {
+		m.status = "A message is about to be sent — press u to cancel it, or wait"
+	}
+	return true
+}
+
+// undoableSend reports whether a message is waiting out its undo window.
+func (m model) undoableSend() bool {
+	return m.pendingSend != nil && !m.pendingSend.sending
+}
@@ -2,11 +2,20 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gmail-tui/internal/auth"
 	gmailx "gmail-tui/internal/gmail"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"golang.org/x/oauth2"
 )
@@ -35,18 +44,37 @@ type tokenMsg struct {
 }
 
 type inboxMsg struct {
-	items []list.Item
-	err   error
+	items   []list.Item
+	offline bool
+	err     error
 }
 
 type detailMsg struct {
-	content string
+	detail  *gmailx.EmailDetail
+	offline bool
 	err     error
+	// goneID is set instead of err when the message no longer exists.
+	goneID string
 }
 
+type cachedInboxMsg struct {
+	items []list.Item
+}
+
+// inboxCacheKey is the cache entry holding the most recently fetched inbox rows.
+const inboxCacheKey = "inbox"
+
+// detailCacheKey returns the cache entry holding a viewed message's details.
+func detailCacheKey(id string) string { return "msg-" + id }
+
 type labelsMsg struct {
 	items []list.Item
 	err   error
+	// pick is set when the labels were fetched for the label quick-pick
+	// rather than the labels screen, and sidebar when they were fetched
+	// for the labels sidebar.
+	pick    bool
+	sidebar bool
 }
 
 type loginDoneMsg struct {
@@ -57,14 +85,75 @@ type loginDoneMsg struct {
 // This is called once when the Bubble Tea program starts. Returns a batch command
 // that executes both loading operations in parallel.
 func (m model) Init() tea.Cmd {
-	return tea.Batch(m.loadCfgCmd(), m.loadTokenCmd())
+	return tea.Batch(m.loadCfgCmd(), m.loadTokenCmd(), m.loadCachedInboxCmd(), waitRefreshEvent(m.refreshEvents), scheduleTokenHeartbeat(), m.scheduleIdleCheck(m.idleLimit()))
+}
+
+type refreshEventMsg struct {
+	ev gmailx.RefreshEvent
+}
+
+// waitRefreshEvent creates a command that delivers the next report of a token
+// refresh being retried from events.
+func waitRefreshEvent(events <-chan gmailx.RefreshEvent) tea.Cmd {
+	return func() tea.Msg {
+		return refreshEventMsg{ev: <-events}
+	}
 }
 
-// loadCfgCmd creates a command that loads the OAuth configuration from credentials.json.
+// loadCachedInboxCmd creates a command that loads the inbox rows cached by the last
+// successful fetch, so recently seen mail shows up immediately and stays readable offline.
+// Cache entries beyond the configured size and age are evicted first.
+func (m model) loadCachedInboxCmd() tea.Cmd {
+	cache := m.cache
+	maxEntries := m.settings.CacheMaxEntries
+	maxAge := time.Duration(m.settings.CacheMaxAgeDays) * 24 * time.Hour
+	return func() tea.Msg {
+		if cache == nil {
+			return nil
+		}
+		if n, err := cache.Prune(maxEntries, maxAge); err != nil {
+			slog.Warn("failed to prune cache", "err", err)
+		} else if n > 0 {
+			slog.Debug("pruned cache", "removed", n)
+		}
+		var rows []gmailx.EmailRow
+		if err := cache.Get(inboxCacheKey, &rows); err != nil {
+			return nil
+		}
+		return cachedInboxMsg{items: rowsToItems(rows)}
+	}
+}
+
+// rowsToItems converts Gmail inbox rows into list items for display in the TUI.
+func rowsToItems(rows []gmailx.EmailRow) []list.Item {
+	items := make([]list.Item, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, emailItem{
+			id:         r.ID,
+			subject:    r.Subject,
+			from:       r.From,
+			to:         r.To,
+			date:       r.Date,
+			snippet:    r.Snippet,
+			unread:     r.Unread,
+			threadID:   r.ThreadID,
+			threadSize: r.ThreadSize,
+		})
+	}
+	return items
+}
+
+// loadCfgCmd creates a command that loads the OAuth configuration from the configured credentials file.
 // Returns a cfgMsg with the configuration on success, or an errMsg on failure.
 func (m model) loadCfgCmd() tea.Cmd {
+	path := m.settings.CredentialsPath
+	settings := m.settings
 	return func() tea.Msg {
-		cfg, err := loadOAuthConfig()
+		scopes, err := RequestedScopes(settings)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		cfg, err := LoadOAuthConfig(path, scopes)
 		if err != nil {
 			return errMsg{err: err}
 		}
@@ -90,127 +179,333 @@ func (m model) loadTokenCmd() tea.Cmd {
 		}
 		tok, err := m.store.Load()
 		if err != nil {
+			var dc auth.DeviceCode
+			if m.store.LoadPending(&dc) == nil && !dc.Expired() {
+				return tokenLoadedMsg{tok: nil, err: err, pending: &dc}
+			}
+			_ = m.store.ClearPending()
 			return tokenLoadedMsg{tok: nil, err: err}
 		}
 		return tokenLoadedMsg{tok: tok, err: nil}
 	}
 }
 
+// tokenLoadedMsg carries a saved or newly obtained token. When no token exists but a
+// device login was interrupted, pending holds the device code so polling can resume.
 type tokenLoadedMsg struct {
-	tok *oauth2.Token
-	err error
+	tok     *oauth2.Token
+	err     error
+	pending *auth.DeviceCode
 }
 
-// loginCmd initiates the OAuth2 login flow using a local loopback server.
-// Opens the user's browser to Google's authentication page, waits for authorization,
-// and saves the resulting token to disk for future use.
+type deviceCodeMsg struct {
+	dc *auth.DeviceCode
+}
+
+// loginCmd signs in with the configured login flow.
 func (m model) loginCmd() tea.Cmd {
-	return func() tea.Msg {
-		if m.cfg == nil {
+	return m.loginWith(m.flow)
+}
+
+// loginWith signs in with flow and saves the resulting token to disk for
+// future use. A device flow's code is persisted so that relaunching the app
+// resumes polling for it, and is shown through a deviceCodeMsg.
+func (m model) loginWith(flow auth.Flow) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	st := m.store
+
+	prompts := make(chan *auth.DeviceCode, 1)
+	if df, ok := flow.(auth.DeviceFlow); ok {
+		df.Prompt = func(dc *auth.DeviceCode) {
+			if st != nil {
+				_ = st.SavePending(dc)
+			}
+			prompts <- dc
+		}
+		flow = df
+	}
+
+	login := func() tea.Msg {
+		defer close(prompts)
+		if cfg == nil {
 			return errMsg{err: errMissingCfg{}}
 		}
-		tok, err := auth.LoopbackLogin(m.cfg)
+		tok, err := flow.Login(root, cfg)
 		if err != nil {
+			var rErr *oauth2.RetrieveError
+			if st != nil && errors.As(err, &rErr) {
+				_ = st.ClearPending()
+			}
 			return loginDoneMsg{err: err}
 		}
-		if m.store != nil {
-			_ = m.store.Save(tok)
+		if st != nil {
+			_ = st.Save(tok)
+			_ = st.ClearPending()
 		}
 		return tokenLoadedMsg{tok: tok, err: nil}
 	}
+	return tea.Batch(login, waitDeviceCode(prompts))
 }
 
-type errMissingCfg struct{}
-
-// Error returns the error message for missing OAuth configuration.
-func (e errMissingCfg) Error() string { return "missing oauth config" }
+// waitDeviceCode reports the code of a device login started by loginWith, or
+// nothing if the login ends without one.
+func waitDeviceCode(prompts <-chan *auth.DeviceCode) tea.Cmd {
+	return func() tea.Msg {
+		dc, ok := <-prompts
+		if !ok {
+			return nil
+		}
+		return deviceCodeMsg{dc: dc}
+	}
+}
 
-// fetchInboxCmd creates a command that fetches up to 25 emails from the Gmail inbox.
-// Uses the current search query if one is set. Converts Gmail API responses into
-// list items for display in the TUI. Has a 20-second timeout for the API call.
-func (m model) fetchInboxCmd() tea.Cmd {
+// pollDeviceCmd polls for the user's approval of a device code and saves the resulting
+// token. The pending login is cleared once a token is obtained or the code can no longer
+// be used; it is kept on transient failures so a later launch can resume it.
+func (m model) pollDeviceCmd(dc *auth.DeviceCode) tea.Cmd {
 	cfg := m.cfg
-	tok := m.token
-	q := m.query
+	root := m.ctx
+	st := m.store
 
 	return func() tea.Msg {
-		if cfg == nil || tok == nil {
-			return inboxMsg{err: errMissingCfg{}}
-		}
-		ctx, cancel := gmailx.HumanTimeoutCtx(context.Background(), 20)
-		defer cancel()
-
-		c, err := gmailx.New(ctx, cfg, tok)
+		tok, err := auth.PollDeviceLogin(root, cfg, dc)
 		if err != nil {
-			return inboxMsg{err: err}
+			var rErr *oauth2.RetrieveError
+			if st != nil && (dc.Expired() || errors.As(err, &rErr)) {
+				_ = st.ClearPending()
+			}
+			return loginDoneMsg{err: err}
 		}
-		rows, err := c.ListInbox(ctx, 25, q)
-		if err != nil {
-			return inboxMsg{err: err}
-		}
-		items := make([]list.Item, 0, len(rows))
-		for _, r := range rows {
-			items = append(items, emailItem{
-				id:      r.ID,
-				subject: r.Subject,
-				from:    r.From,
-				date:    r.Date,
-				snippet: r.Snippet,
-			})
+		if st != nil {
+			_ = st.Save(tok)
+			_ = st.ClearPending()
 		}
-		return inboxMsg{items: items, err: nil}
+		return tokenLoadedMsg{tok: tok, err: nil}
+	}
+}
+
+// startDevicePolling begins polling for the pending device code once the OAuth
+// config is available. It is a no-op if polling is already running.
+func (m *model) startDevicePolling() tea.Cmd {
+	if m.device == nil || m.cfg == nil || m.devicePolling {
+		return nil
+	}
+	m.devicePolling = true
+	return m.pollDeviceCmd(m.device)
+}
+
+type errMissingCfg struct{}
+
+// Error returns the error message for missing OAuth configuration.
+func (e errMissingCfg) Error() string { return "missing oauth config" }
+
+// formatDetail formats the email headers and body into a readable string
+// for display in the detail view. headers is the config's HeaderDetail and
+// picks which headers are shown above the body.
+func formatDetail(d *gmailx.EmailDetail, headers string) string {
+	content := ""
+	content += "Subject: " + d.Subject + "\n"
+	content += "From:    " + d.From + "\n"
+	if headers != "minimal" {
+		content += formatMoreHeaders(d, headers == "full")
+	}
+	if slices.Contains(d.LabelIDs, "MUTED") {
+		content += "Thread:  muted\n"
+	}
+	content += "\nSnippet:\n" + d.Snippet + "\n"
+	if len(d.Attachments) > 0 {
+		content += "\nAttachments:\n"
+		for _, a := range d.Attachments {
+			content += "  " + attachmentGlyph(a) + " " + a.Filename + " (" + formatSize(a.Size) + ")\n"
+		}
+	}
+	content += "\nBody:\n"
+	if d.HTMLFallback {
+		content += "(HTML-only message with no readable text; shown with its tags stripped — press s for the HTML source)\n\n"
+	}
+	content += d.Body + "\n"
+	if d.Truncated > 0 {
+		content += fmt.Sprintf("\n… (truncated, %d bytes omitted — press X to load full)\n", d.Truncated)
+	}
+	return content
+}
+
+// formatMoreHeaders renders the headers shown after the subject and sender,
+// including Cc, Reply-To and Message-ID when full is set.
+func formatMoreHeaders(d *gmailx.EmailDetail, full bool) string {
+	content := ""
+	if d.To != "" {
+		content += "To:      " + d.To + "\n"
+	}
+	if full && d.Cc != "" {
+		content += "Cc:      " + d.Cc + "\n"
+	}
+	if v := d.Header("Reply-To"); full && v != "" {
+		content += "Reply-To: " + v + "\n"
 	}
+	if d.Date != "" {
+		content += "Date:    " + d.Date + "\n"
+	}
+	if v := d.Header("Message-ID"); full && v != "" {
+		content += "Message-ID: " + v + "\n"
+	}
+	if d.Charset != "" && d.Charset != "utf-8" {
+		content += "Charset: " + d.Charset + "\n"
+	}
+	if d.SizeEstimate > 0 {
+		content += "Size:    " + formatSize(d.SizeEstimate) + "\n"
+	}
+	return content
+}
+
+// formatSize renders a byte count in the largest unit that keeps it above 1.
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// attachmentGlyph returns a small symbol for an attachment's kind.
+func attachmentGlyph(a gmailx.Attachment) string {
+	switch gmailx.AttachmentKind(a) {
+	case "pdf":
+		return "▤"
+	case "image":
+		return "◩"
+	case "spreadsheet":
+		return "▦"
+	case "archive":
+		return "▣"
+	case "calendar":
+		return "◷"
+	}
+	return "◆"
+}
+
+// formatHeaders lists every raw header of a message, one "Name: value" per line.
+func formatHeaders(d *gmailx.EmailDetail) string {
+	var b strings.Builder
+	b.WriteString("Raw headers:\n")
+	for _, h := range d.Headers {
+		b.WriteString(h.Name + ": " + h.Value + "\n")
+	}
+	return b.String()
+}
+
+// refreshDetailText re-renders the open thread or message, after a setting
+// that affects how it is shown has changed.
+func (m *model) refreshDetailText() {
+	if m.threadView && m.thread != nil {
+		m.setDetailText(m.threadContent())
+	} else {
+		m.setDetailText(m.detailContent())
+	}
+}
+
+// showDetail opens d in the detail view, resetting the per-message view state.
+func (m *model) showDetail(d *gmailx.EmailDetail) {
+	m.err = nil
+	m.status = ""
+	if n := d.TrackersStripped; n > 0 {
+		m.status = fmt.Sprintf("Stripped %d tracking element(s) from this message", n)
+	}
+	m.detailID = d.ID
+	m.detail = d
+	m.loadingFull = ""
+	m.learnContacts(d.ID, d.From, d.To, d.Time)
+	m.saveContacts()
+	m.attachIdx = 0
+	m.threadView = false
+	m.thread = nil
+	m.expandQuotes = m.settings.ExpandQuotes
+	m.bodyView = bodyCleaned
+	m.findQuery = ""
+	m.findInput.SetValue("")
+	m.setDetailText(m.detailContent())
+	m.screen = screenDetail
+}
+
+// detailContent renders the open message for the detail viewport in the
+// selected body view, prefixed with the raw headers panel when it is toggled on.
+func (m model) detailContent() string {
+	if m.detail == nil {
+		return ""
+	}
+	d := withAddresses(withBodyView(m.detail, m.bodyView, m.expandQuotes), m.settings.FullAddresses)
+	if m.showHeaders {
+		return formatHeaders(m.detail) + "\n" + formatDetail(d, m.settings.HeaderDetail)
+	}
+	return formatDetail(d, m.settings.HeaderDetail)
 }
 
 // fetchDetailCmd creates a command that fetches the full details of a specific email by ID.
 // Formats the email headers and body into a readable string for display in the detail view.
-// Has a 20-second timeout for the API call.
+// Uses the configured timeout for the API call. Viewed messages are cached on disk and
+// served from the cache when the network is unreachable.
 func (m model) fetchDetailCmd(id string) tea.Cmd {
+	return m.detailCmd(id, m.settings.MaxBodyBytes)
+}
+
+// detailCmd fetches a message like fetchDetailCmd with an explicit body cap;
+// a maxBody of 0 loads the full body.
+func (m model) detailCmd(id string, maxBody int) tea.Cmd {
 	cfg := m.cfg
+	root := m.ctx
 	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+	metadataOnly := m.metadataOnly()
+	cache := m.cache
 
 	return func() tea.Msg {
-		ctx, cancel := gmailx.HumanTimeoutCtx(context.Background(), 20)
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
 		defer cancel()
 
-		c, err := gmailx.New(ctx, cfg, tok)
+		c, err := newClient(ctx, cfg, tok)
 		if err != nil {
 			return detailMsg{err: err}
 		}
-		d, err := c.GetDetail(ctx, id)
+		d, err := getDetail(ctx, c, id, maxBody, metadataOnly)
+		if gmailx.IsNotFound(err) {
+			return detailMsg{goneID: id}
+		}
 		if err != nil {
+			var cached gmailx.EmailDetail
+			if gmailx.IsNetworkError(err) && cache != nil && cache.Get(detailCacheKey(id), &cached) == nil {
+				return detailMsg{detail: &cached, offline: true}
+			}
 			return detailMsg{err: err}
 		}
-		content := ""
-		content += "Subject: " + d.Subject + "\n"
-		content += "From:    " + d.From + "\n"
-		if d.To != "" {
-			content += "To:      " + d.To + "\n"
+		if cache != nil {
+			_ = cache.Put(detailCacheKey(id), d)
 		}
-		if d.Date != "" {
-			content += "Date:    " + d.Date + "\n"
-		}
-		content += "\nSnippet:\n" + d.Snippet + "\n"
-		content += "\nBody:\n" + d.Body + "\n"
-		return detailMsg{content: content, err: nil}
+		return detailMsg{detail: d, err: nil}
 	}
 }
 
 // fetchLabelsCmd creates a command that fetches all Gmail labels for the user's account.
 // Labels include both system labels (INBOX, SENT, TRASH, etc.) and custom user-created labels.
-// Has a 20-second timeout for the API call.
+// Uses the configured timeout for the API call.
 func (m model) fetchLabelsCmd() tea.Cmd {
 	cfg := m.cfg
+	root := m.ctx
 	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
 
 	return func() tea.Msg {
 		if cfg == nil || tok == nil {
 			return labelsMsg{err: errMissingCfg{}}
 		}
-		ctx, cancel := gmailx.HumanTimeoutCtx(context.Background(), 20)
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
 		defer cancel()
 
-		c, err := gmailx.New(ctx, cfg, tok)
+		c, err := newClient(ctx, cfg, tok)
 		if err != nil {
 			return labelsMsg{err: err}
 		}
@@ -221,103 +516,910 @@ func (m model) fetchLabelsCmd() tea.Cmd {
 		items := make([]list.Item, 0, len(labels))
 		for _, label := range labels {
 			items = append(items, labelItem{
-				id:   label.ID,
-				name: label.Name,
+				id:        label.ID,
+				name:      label.Name,
+				textColor: label.TextColor,
+				bgColor:   label.BackgroundColor,
 			})
 		}
 		return labelsMsg{items: items, err: nil}
 	}
 }
 
+// finishJump completes or cancels a pending jump-to-row command in the inbox.
+// enter moves the selection to the typed 1-based row number (clamped to the list),
+// esc cancels, and any other key cancels and is then handled as usual.
+func (m model) finishJump(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	n, _ := strconv.Atoi(m.jumpBuf)
+	m.jumpBuf = ""
+	switch msg.String() {
+	case "enter":
+		if total := len(m.inbox.Items()); total > 0 {
+			n = max(1, min(n, total))
+			m.inbox.Select(n - 1)
+		}
+		return m, nil
+	case "esc":
+		return m, nil
+	}
+	return m.Update(msg)
+}
+
+// typing reports whether the current screen is a text-entry screen, or a list
+// filter or the command palette is taking input, where printable keys such as q
+// belong to the focused input rather than global shortcuts.
+func (m model) typing() bool {
+	if m.labels.FilterState() == list.Filtering || m.filters.FilterState() == list.Filtering ||
+		m.attachments.FilterState() == list.Filtering {
+		return true
+	}
+	return m.paletteOpen || m.labelPickOpen || m.openRefOpen || m.finding || m.screen == screenSearch || m.screen == screenVacation || m.screen == screenSignature ||
+		m.screen == screenCompose || m.screen == screenSetup
+}
+
 // Update handles all incoming messages and updates the application state accordingly.
 // This is the main event handler that processes window resizes, keyboard input,
 // and async command results. Returns the updated model and any new commands to execute.
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case tea.KeyMsg, tea.MouseMsg:
+		m.lastInput = time.Now()
+	}
+	next, cmd := m.update(msg)
+	nm, ok := next.(model)
+	if !ok {
+		return next, cmd
+	}
+	if gmailx.IsReauthRequired(nm.err) {
+		nm.requireLogin()
+	}
+	if nm.height > 0 {
+		// Status lines come and go, so fit the lists and viewports to what
+		// the headers leave now.
+		nm.resize()
+	}
+	return nm, cmd
+}
+
+// requireLogin ends the session after the refresh token was rejected and
+// returns to the login screen.
+func (m *model) requireLogin() {
+	slog.Warn("refresh token rejected; logging in again", "err", m.err)
+	m.err = nil
+	m.forgetToken()
+	m.endSession()
+	m.status = "Session expired — press l to log in again"
+}
+
+// forgetToken deletes the saved token, so the next login doesn't resume it
+// and asks for a new refresh token.
+func (m *model) forgetToken() {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Delete(); err != nil {
+		slog.Warn("failed to delete the saved token", "err", err)
+	}
+}
+
+// endSession drops the session token and everything loaded or typed with it,
+// and returns to the login screen. A message waiting out its undo window is
+// cancelled, so it can't be sent, or reopened, after the session ended.
+func (m *model) endSession() {
+	if m.inboxStream != nil {
+		m.inboxStream.cancel()
+		m.inboxStream = nil
+	}
+	m.token = nil
+	m.account = ""
+	m.profile = nil
+	m.saveContacts()
+	m.contacts = nil
+	m.setLabel("", "")
+	m.searchInput.SetValue("")
+	m.searchEverywhere = false
+	m.expandedID = ""
+	m.clearFind()
+	m.labels.SetItems(nil)
+	m.labelsAt = time.Time{}
+	m.unreadCounts = nil
+	m.archiveAll = nil
+	m.selected = nil
+	m.summaryVP.SetContent("")
+	m.inbox.SetDelegate(m.inboxDelegate())
+	m.setInboxItems(nil)
+	m.detail = nil
+	m.detailID = ""
+	m.detailText = ""
+	m.thread = nil
+	m.threadView = false
+	m.detailVP.SetContent("")
+	m.attachments.SetItems(nil)
+	m.previewID = ""
+	m.previewVP.SetContent("")
+	m.cancelPreview()
+	m.pendingSend = nil
+	m.sendSeq++
+	m.reply = nil
+	m.tmpl = nil
+	m.composeInputs = newComposeInputs()
+	m.composeBody = newComposeBody()
+	m.vacation = nil
+	m.vacEnabled = false
+	m.vacInputs = newVacationInputs()
+	m.signature = nil
+	m.sigInput = newSignatureInput()
+	m.filters.SetItems(nil)
+	m.confirm = nil
+	m.screen = screenAuth
+}
+
+// update is Update without the session-expiry check.
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.inbox.SetSize(msg.Width-6, msg.Height-10)
-		m.labels.SetSize(msg.Width-6, msg.Height-10)
-		m.detailVP.Width = msg.Width - 6
-		m.detailVP.Height = msg.Height - 10
+		m.resize()
+		return m, m.schedulePreview()
+
+	case previewTickMsg:
+		if msg.seq != m.previewSeq || !m.splitActive() {
+			return m, nil
+		}
+		m.previewID = msg.id
+		m.previewVP.SetContent(faint.Render("Loading preview..."))
+		cmd := m.fetchPreviewCmd(msg.id)
+		return m, cmd
+
+	case previewMsg:
+		if msg.id != m.previewID || errors.Is(msg.err, context.Canceled) {
+			return m, nil
+		}
+		if msg.err != nil {
+			m.previewVP.SetContent("Preview failed: " + msg.err.Error())
+			return m, nil
+		}
+		m.previewVP.SetContent(styleFolds(msg.content))
+		m.previewVP.GotoTop()
 		return m, nil
 
 	case cfgMsg:
 		m.cfg = msg.cfg
-		return m, nil
+		if m.screen == screenSetup {
+			m.status = "Credentials saved to " + m.settings.CredentialsPath
+			if m.token != nil {
+				m.screen = screenInbox
+				return m, tea.Batch(m.openInboxCmd(), m.fetchProfileCmd(), m.sidebarCmd(), m.composeSignatureCmd())
+			}
+			m.screen = screenAuth
+		}
+		return m, m.startDevicePolling()
 
 	case tokenLoadedMsg:
+		if msg.tok != nil && msg.err == nil && m.screen == screenSetup {
+			// Carry on to the inbox once the wizard has credentials.
+			m.token = msg.tok
+			return m, nil
+		}
 		if msg.tok != nil && msg.err == nil {
 			m.token = msg.tok
+			m.lastInput = time.Now()
+			m.device = nil
+			m.devicePolling = false
 			m.screen = screenInbox
 			m.status = "Logged in"
-			return m, m.fetchInboxCmd()
+			return m, tea.Batch(m.openInboxCmd(), m.fetchProfileCmd(), m.sidebarCmd(), m.composeSignatureCmd())
+		}
+		if msg.pending != nil {
+			m.device = msg.pending
+			m.status = "Resuming device login..."
+			return m, m.startDevicePolling()
+		}
+		return m, nil
+
+	case deviceCodeMsg:
+		// The login flow that produced the code is already polling for it.
+		m.device = msg.dc
+		m.devicePolling = true
+		m.status = "Waiting for approval..."
+		return m, nil
+
+	case cachedInboxMsg:
+		if len(m.inbox.Items()) == 0 {
+			m.setInboxItems(msg.items)
 		}
 		return m, nil
 
 	case inboxMsg:
+		if m.screen == screenSetup {
+			// Started before the credentials turned out to be missing, so it
+			// could only fail; the wizard fetches again when it is done.
+			return m, nil
+		}
+		var missing *gmailx.LabelNotFoundError
+		if errors.As(msg.err, &missing) {
+			m.setQuery("")
+			m.status = "Label " + missing.Label + " no longer exists — showing inbox"
+			return m, m.fetchInboxCmd()
+		}
+		if gmailx.IsNetworkError(msg.err) {
+			// Nothing cached to fall back on; keep whatever is shown.
+			m.offline = true
+			return m, m.recordFetch(true)
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
 		m.err = nil
-		m.inbox.SetItems(msg.items)
+		m.offline = msg.offline
+		m.setInboxItems(msg.items)
+		m.learnItems(msg.items)
+		m.saveContacts()
+		return m, tea.Batch(m.schedulePreview(), m.recordFetch(msg.offline))
+
+	case inboxStartMsg:
+		if m.inboxStream != nil {
+			m.inboxStream.cancel()
+		}
+		m.inboxStream = msg.stream
+		m.inboxLoaded = 0
+		m.nextPageToken = msg.nextPageToken
+		m.resultEstimate = msg.estimate
+		m.err = nil
+		m.offline = false
+		if msg.total == 0 {
+			m.setInboxItems(nil)
+		}
+		return m, tea.Batch(m.inbox.StartSpinner(), waitInboxStream(msg.stream), m.recordFetch(false))
+
+	case refreshTickMsg:
+		if msg.seq != m.refreshSeq {
+			return m, nil
+		}
+		if m.screen != screenInbox || m.inboxStream != nil || m.jumpBuf != "" {
+			return m, m.scheduleRefresh()
+		}
+		return m, m.fetchInboxCmd()
+
+	case inboxRowMsg:
+		if msg.stream != m.inboxStream {
+			return m, nil
+		}
+		it := rowsToItems([]gmailx.EmailRow{msg.row})[0]
+		m.learnContacts(msg.row.ID, msg.row.From, msg.row.To, msg.row.Time)
+		cmd := m.addStreamedRow(it)
+		if m.inboxLoaded == 0 {
+			cmd = tea.Batch(cmd, m.schedulePreview())
+		}
+		m.inboxLoaded++
+		return m, tea.Batch(cmd, waitInboxStream(msg.stream))
+
+	case inboxDoneMsg:
+		if msg.stream != m.inboxStream {
+			return m, nil
+		}
+		m.inboxStream = nil
+		m.inbox.StopSpinner()
+		m.saveContacts()
+		if m.inboxLoaded == 0 {
+			m.setInboxItems(nil)
+		}
+		m.pruneSelection()
+		return m, tea.Batch(m.schedulePreview(), m.threadSizesCmd())
+
+	case spinner.TickMsg:
+		if msg.ID == m.bodySpinner.ID() {
+			cmd := m.updateBodySpinner(msg)
+			return m, cmd
+		}
+		var cmd tea.Cmd
+		m.inbox, cmd = m.inbox.Update(msg)
+		return m, cmd
+
+	case fullBodyMsg:
+		m.fullBodyLoaded(msg)
 		return m, nil
 
 	case detailMsg:
+		if msg.goneID != "" {
+			m.removeRow(msg.goneID)
+			m.screen = screenInbox
+			m.status = "Message no longer exists"
+			return m, m.schedulePreview()
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
-		m.err = nil
-		m.detailVP.SetContent(msg.content)
-		m.screen = screenDetail
+		m.offline = msg.offline
+		m.showDetail(msg.detail)
+		return m, nil
+
+	case openRefMsg:
+		if msg.err != nil {
+			m.status = "Couldn't open the message: " + msg.err.Error()
+			return m, nil
+		}
+		m.offline = false
+		m.showDetail(msg.detail)
 		return m, nil
 
+	case threadMsg:
+		if msg.err != nil {
+			m.status = "Thread failed to load: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = ""
+		m.threadView = true
+		m.thread = msg.thread
+		m.setDetailText(m.threadContent())
+		m.detailVP.GotoTop()
+		return m, nil
+
+	case searchEstimateMsg:
+		return m, m.searchEstimated(msg)
+
+	case unreadCountsMsg:
+		if msg.err != nil {
+			slog.Warn("failed to load unread counts", "err", msg.err)
+			return m, nil
+		}
+		m.unreadCounts = msg.counts
+		return m, nil
+
+	case archiveAllEstimateMsg:
+		m.archiveAllEstimated(msg)
+		return m, nil
+
+	case archiveAllConfirmedMsg:
+		cmd := m.beginArchiveAll(msg)
+		return m, cmd
+
+	case archiveAllPageMsg:
+		cmd := m.archiveAllPaged(msg)
+		return m, cmd
+
+	case archiveAllChunkMsg:
+		cmd := m.archiveAllArchived(msg)
+		return m, cmd
+
+	case searchConfirmedMsg:
+		return m, m.applySearch(msg.query, msg.everywhere)
+
 	case labelsMsg:
+		if msg.pick {
+			return m, m.labelPickLoaded(msg)
+		}
+		if msg.sidebar {
+			cmd := m.sidebarLoaded(msg)
+			return m, cmd
+		}
 		if msg.err != nil {
 			m.err = msg.err
 			return m, nil
 		}
 		m.err = nil
 		m.labels.SetItems(msg.items)
+		m.labelsAt = time.Now()
 		m.screen = screenLabels
 		return m, nil
 
+	case filtersMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.filters.SetItems(msg.items)
+		m.filterExpanded = false
+		m.screen = screenFilters
+		return m, nil
+
+	case vacationMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.status = ""
+		m.setVacationInputs(msg.settings)
+		m.screen = screenVacation
+		return m, nil
+
+	case vacationSavedMsg:
+		if msg.err != nil {
+			m.status = "Save failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.vacation = msg.settings
+		m.status = "Vacation responder updated"
+		return m, nil
+
+	case signatureMsg:
+		if msg.compose {
+			if msg.err != nil {
+				slog.Warn("failed to load the signature", "err", msg.err)
+			} else if m.screen != screenSignature {
+				m.signature = msg.sig
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		m.err = nil
+		m.status = ""
+		m.signature = msg.sig
+		m.sigInput.SetValue(msg.sig.Text)
+		m.sigInput.Focus()
+		m.screen = screenSignature
+		return m, nil
+
+	case signatureSavedMsg:
+		if msg.err != nil {
+			m.status = "Save failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.signature = msg.sig
+		m.status = "Signature updated"
+		return m, nil
+
+	case idleTickMsg:
+		cmd := m.idleTick(msg)
+		return m, cmd
+
+	case tokenHeartbeatMsg:
+		return m, m.tokenHeartbeatCmd()
+
+	case tokenCheckedMsg:
+		cmd := m.tokenChecked(msg)
+		return m, cmd
+
+	case expandTickMsg:
+		m.expandRow(msg)
+		return m, nil
+
+	case startLabelMsg:
+		cmd := m.startLabelResolved(msg)
+		return m, cmd
+
+	case sendDueMsg:
+		return m, m.sendDue(msg)
+
+	case sentMsg:
+		if m.pendingSend == nil || m.pendingSend.seq != msg.seq {
+			// Sent from a session that has since ended.
+			return m, nil
+		}
+		m.pendingSend = nil
+		if msg.err != nil {
+			m.reopenCompose()
+			m.status = "Send failed: " + msg.err.Error()
+			return m, nil
+		}
+		m.learnContacts(msg.id, "", msg.to, time.Now())
+		m.saveContacts()
+		if m.screen == screenCompose {
+			// Sent at once, without an undo window; otherwise the compose
+			// screen was already left and another screen may be open now.
+			m.leaveCompose()
+		}
+		m.status = "Message sent"
+		return m, nil
+
+	case exportedMsg:
+		switch {
+		case msg.err != nil:
+			m.status = "Export failed: " + msg.err.Error()
+		case msg.pdf != "":
+			m.status = "Exported to " + msg.path + " and " + msg.pdf
+		default:
+			m.status = "Exported to " + msg.path
+		}
+		return m, nil
+
+	case attachmentOpenedMsg:
+		if msg.err != nil {
+			m.status = "Couldn't open attachment: " + msg.err.Error()
+			return m, nil
+		}
+		m.status = "Opened " + msg.name
+		return m, nil
+
+	case attachmentsSavedMsg:
+		m.status = savedStatus(msg)
+		return m, nil
+
+	case settingsReloadedMsg:
+		if msg.err != nil {
+			m.status = "Reload failed: " + msg.err.Error()
+			return m, nil
+		}
+		return m, m.applySettings(msg.settings, msg.cfg)
+
+	case prefsSavedMsg:
+		if msg.err != nil {
+			m.status = "Couldn't save preference: " + msg.err.Error()
+		}
+		return m, nil
+
+	case markedReadMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.dryRun {
+			m.status = dryRunStatus("mark as read", len(msg.ids))
+			return m, nil
+		}
+		m.setRead(msg.ids)
+		m.status = fmt.Sprintf("Marked %d messages as read", len(msg.ids))
+		return m, m.refreshRowsCmd(msg.ids)
+
+	case markedAllReadMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.dryRun {
+			m.status = dryRunStatus("mark as read", msg.count)
+			return m, nil
+		}
+		m.status = fmt.Sprintf("Marked %d messages as read", msg.count)
+		ids := m.unreadIDs()
+		m.setRead(ids)
+		return m, m.refreshRowsCmd(ids)
+
+	case triagedMsg:
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Triage (%s) failed: %v", msg.action, msg.err)
+			return m, m.fetchInboxCmd()
+		}
+		if msg.dryRun {
+			m.status = dryRunStatus(triageVerbs[msg.action], 1)
+		}
+		return m, nil
+
+	case threadMutedMsg:
+		m.threadMuted(msg)
+		return m, m.schedulePreview()
+
+	case reportedMsg:
+		cmd := m.reported(msg)
+		return m, cmd
+
+	case threadSizesMsg:
+		if msg.err != nil {
+			slog.Warn("failed to load thread sizes", "err", msg.err)
+			return m, nil
+		}
+		m.setThreadSizes(msg.sizes)
+		return m, nil
+
+	case rowsMsg:
+		m.replaceRows(msg.rows)
+		if msg.err != nil {
+			slog.Warn("failed to refresh rows", "err", msg.err)
+		}
+		return m, nil
+
+	case headerSummaryMsg:
+		m.headerSummaryLoaded(msg)
+		return m, nil
+
+	case profileMsg:
+		if msg.err != nil {
+			slog.Warn("failed to fetch account profile", "err", msg.err)
+			if m.screen == screenProfile {
+				m.status = "Couldn't load the profile: " + msg.err.Error()
+			}
+			return m, nil
+		}
+		m.profile = &msg.profile
+		if m.screen == screenProfile {
+			m.status = ""
+		}
+		return m, m.setAccount(msg.profile.Email)
+
+	case refreshEventMsg:
+		switch {
+		case !msg.ev.Done:
+			m.status = fmt.Sprintf("Reconnecting… (attempt %d)", msg.ev.Attempt+1)
+		case msg.ev.Err == nil:
+			m.status = "Reconnected"
+		}
+		return m, waitRefreshEvent(m.refreshEvents)
+
+	case accountBannerDoneMsg:
+		if msg.email == m.account {
+			m.accountBanner = false
+		}
+		return m, nil
+
 	case loginDoneMsg:
+		m.device = nil
+		m.devicePolling = false
+		if auth.IsAccessDenied(msg.err) {
+			slog.Info("login denied on the consent screen")
+			m.screen = screenAuth
+			m.status = "Login was cancelled or denied — press l to try again"
+			return m, nil
+		}
+		if msg.err != nil {
+			slog.Error("login failed", "err", msg.err)
+		}
 		m.err = msg.err
 		return m, nil
 
+	case list.FilterMatchesMsg:
+		// Results of a list's asynchronous filtering go to whichever list is filtering.
+		var cmd tea.Cmd
+		switch {
+		case m.paletteOpen:
+			m.palette, cmd = m.palette.Update(msg)
+		case m.labelPickOpen:
+			m.labelPick, cmd = m.labelPick.Update(msg)
+		case m.screen == screenLabels:
+			m.labels, cmd = m.labels.Update(msg)
+		case m.screen == screenFilters:
+			m.filters, cmd = m.filters.Update(msg)
+		case m.screen == screenAttachments:
+			m.attachments, cmd = m.attachments.Update(msg)
+		default:
+			m.inbox, cmd = m.inbox.Update(msg)
+		}
+		return m, cmd
+
 	case errMsg:
+		if errors.As(msg.err, &missingCredentialsError{}) {
+			return m, m.showSetup()
+		}
+		slog.Error("command failed", "err", msg.err)
 		m.err = msg.err
 		return m, nil
 
 	case tea.KeyMsg:
 		k := msg.String()
 
-		if k == "ctrl+c" || k == "q" {
+		quit := k == "ctrl+c" || (k == "q" && !m.typing())
+		if quit && m.pendingSend != nil && m.confirm == nil {
+			cancel := m.cancel
+			text := "A message is about to be sent. Quit and discard it? (y/n)"
+			if m.pendingSend.sending {
+				text = "A message is still being sent. Quit anyway? It may not go out. (y/n)"
+			}
+			m.confirm = &confirmPrompt{
+				text: text,
+				onYes: func() tea.Msg {
+					cancel()
+					cleanupOpened()
+					return tea.Quit()
+				},
+			}
+			return m, nil
+		}
+		if quit {
+			m.cancel()
+			cleanupOpened()
 			return m, tea.Quit
 		}
 
+		if m.confirm != nil {
+			c := m.confirm
+			m.confirm = nil
+			if k == "y" {
+				return m, c.onYes
+			}
+			m.status = "Cancelled"
+			return m, nil
+		}
+
+		if k == "u" && m.undoableSend() && !m.typing() {
+			m.undoSend()
+			return m, nil
+		}
+
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+		if m.paletteOpen {
+			return m.updatePalette(msg)
+		}
+		if m.labelPickOpen {
+			return m.updateLabelPick(msg)
+		}
+		if m.openRefOpen {
+			return m.updateOpenRef(msg)
+		}
+		if k == "?" && !m.typing() && m.screen != screenAuth {
+			m.showHelp = true
+			return m, nil
+		}
+		if (k == ":" || k == "ctrl+p") && !m.typing() && m.jumpBuf == "" {
+			return m, m.openPalette()
+		}
+
 		switch m.screen {
 		case screenAuth:
-			if k == "l" {
+			if m.device != nil {
+				return m, nil
+			}
+			switch k {
+			case "l":
 				m.err = nil
 				m.status = "Opening browser for login..."
+				if _, ok := m.flow.(auth.DeviceFlow); ok {
+					m.status = "Requesting device code..."
+				}
 				return m, m.loginCmd()
+			case "d":
+				m.err = nil
+				m.status = "Requesting device code..."
+				return m, m.loginWith(auth.DeviceFlow{})
+			case "R":
+				m.status = "Reloading settings..."
+				return m, m.reloadSettingsCmd()
 			}
 			return m, nil
 
 		case screenInbox:
+			if len(k) == 1 && k[0] >= '0' && k[0] <= '9' {
+				m.jumpBuf += k
+				return m, nil
+			}
+			if m.jumpBuf != "" {
+				return m.finishJump(msg)
+			}
+			if m.keyBlocked(k) {
+				return m, nil
+			}
 			switch k {
 			case "r":
-				return m, m.fetchInboxCmd()
+				return m, tea.Batch(m.fetchInboxCmd(), m.sidebarCmd())
+			case "L":
+				cmd := m.toggleSidebar()
+				return m, cmd
+			case "[", "]":
+				if !m.sidebarActive() {
+					return m, nil
+				}
+				delta := 1
+				if msg.String() == "[" {
+					delta = -1
+				}
+				cmd := m.switchSidebarLabel(delta)
+				return m, cmd
+			case "n":
+				cmd := m.changePage(1)
+				return m, cmd
+			case "p":
+				cmd := m.changePage(-1)
+				return m, cmd
 			case "g":
-				return m, m.fetchLabelsCmd()
+				cmd := m.openLabels()
+				return m, cmd
+			case "l":
+				return m, m.openLabelPick()
+			case "f":
+				cat := gmailx.NextCategory(gmailx.QueryCategory(m.query))
+				m.setQuery(gmailx.WithCategory(m.query, cat))
+				return m, m.fetchInboxCmd()
+			case "i":
+				m.screen = screenProfile
+				m.status = "Loading profile..."
+				return m, m.fetchProfileCmd()
+			case "ctrl+r":
+				if m.offline {
+					m.status = "Offline — can't mark messages read"
+					return m, nil
+				}
+				ids := m.unreadIDs()
+				if len(ids) == 0 {
+					m.status = "No unread messages loaded"
+					return m, nil
+				}
+				m.status = "Marking messages as read..."
+				return m, m.markReadCmd(ids)
+			case "x":
+				m.toggleSelected()
+				return m, nil
+			case "H":
+				if len(m.selected) == 0 {
+					m.status = "Select messages with x first"
+					return m, nil
+				}
+				if m.offline {
+					m.status = "Offline — can't fetch headers"
+					return m, nil
+				}
+				m.status = "Fetching headers..."
+				return m, m.headerSummaryCmd()
+			case "m":
+				m.cycleFlag()
+				return m, nil
+			case "M":
+				m.cycleFlagFilter()
+				return m, m.schedulePreview()
+			case "h":
+				m.toggleHideRead()
+				m.status = "Showing all messages"
+				if m.hideRead {
+					m.status = "Hiding read messages"
+				}
+				return m, m.schedulePreview()
+			case "D":
+				m.settings.DryRun = !m.settings.DryRun
+				m.status = "Dry run off"
+				if m.settings.DryRun {
+					m.status = "Dry run on — changes are reported, not applied"
+				}
+				return m, nil
+			case "ctrl+a":
+				if m.offline {
+					m.status = "Offline — can't mark messages read"
+					return m, nil
+				}
+				scope := "the inbox"
+				if m.query != "" {
+					scope = "\"" + m.query + "\""
+				}
+				m.confirm = &confirmPrompt{
+					text:  "Mark ALL unread messages in " + scope + " as read? This may touch thousands of messages.",
+					onYes: m.markAllReadCmd(),
+				}
+				return m, nil
+			case "ctrl+e":
+				if m.offline {
+					m.status = "Offline — can't archive messages"
+					return m, nil
+				}
+				cmd := m.startArchiveAll()
+				return m, cmd
+			case "v":
+				return m, m.toggleSplitView()
+			case "s":
+				return m, m.toggleSnippets()
+			case "@":
+				return m, m.toggleFullAddresses()
+			case "d":
+				return m, m.toggleCompactRows()
+			case "e":
+				return m, m.triageNext()
+			case "R":
+				m.status = "Reloading settings..."
+				return m, m.reloadSettingsCmd()
+			case "G":
+				m.status = ""
+				return m, m.startOpenRef()
+			case "a":
+				m.setQuery(gmailx.ToggleTerm(m.query, "has:attachment"))
+				return m, m.fetchInboxCmd()
+			case "c":
+				if !m.composeBusy() {
+					m.startCompose("")
+				}
+				return m, nil
+			case "C":
+				if it, ok := m.inbox.SelectedItem().(emailItem); ok && !m.composeBusy() {
+					m.startCompose(senderAddress(it.from))
+				}
+				return m, nil
+			case "F":
+				return m, m.fetchFiltersCmd()
+			case "S":
+				m.status = "Loading signature..."
+				return m, m.fetchSignatureCmd()
+			case "V":
+				m.status = "Loading vacation responder..."
+				return m, m.fetchVacationCmd()
 			case "/":
 				m.searchInput.SetValue(m.query)
 				m.searchInput.Focus()
+				m.searchEverywhere = m.everywhere
 				m.screen = screenSearch
 				return m, nil
 			case "enter":
@@ -330,17 +1432,141 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			var cmd tea.Cmd
 			m.inbox, cmd = m.inbox.Update(msg)
-			return m, cmd
+			return m, tea.Batch(cmd, m.schedulePreview())
 
 		case screenDetail:
+			if m.finding {
+				return m.updateFind(msg)
+			}
+			if m.keyBlocked(k) {
+				return m, nil
+			}
 			switch k {
 			case "b":
 				m.screen = screenInbox
 				return m, nil
+			case "/":
+				m.finding = true
+				m.findInput.SetValue(m.findQuery)
+				m.findInput.CursorEnd()
+				return m, m.findInput.Focus()
+			case "n":
+				m.findStep(1)
+				return m, nil
+			case "N":
+				m.findStep(-1)
+				return m, nil
+			case "esc":
+				if m.findQuery != "" {
+					m.clearFind()
+					m.status = ""
+				}
+				return m, nil
 			case "r":
 				if m.detailID != "" {
 					return m, m.fetchDetailCmd(m.detailID)
 				}
+			case "t":
+				if m.detail == nil || m.detail.ThreadID == "" {
+					return m, nil
+				}
+				if m.threadView {
+					m.status = "Loading message..."
+					return m, m.fetchDetailCmd(m.detailID)
+				}
+				m.status = "Loading thread..."
+				return m, m.fetchThreadCmd(m.detail.ThreadID)
+			case "X":
+				if m.threadView {
+					return m, nil
+				}
+				cmd := m.loadFullBody()
+				return m, cmd
+			case "E":
+				if m.detailID == "" {
+					return m, nil
+				}
+				m.status = "Exporting..."
+				return m, m.exportCmd(m.detailID)
+			case "a":
+				if m.detail == nil || len(m.detail.Attachments) == 0 || m.threadView {
+					return m, nil
+				}
+				m.attachIdx = (m.attachIdx + 1) % len(m.detail.Attachments)
+				a := m.detail.Attachments[m.attachIdx]
+				m.status = fmt.Sprintf("Attachment %d/%d: %s (o open • A list)", m.attachIdx+1, len(m.detail.Attachments), a.Filename)
+				return m, nil
+			case "A":
+				if m.detail == nil || len(m.detail.Attachments) == 0 || m.threadView {
+					return m, nil
+				}
+				m.showAttachments()
+				return m, nil
+			case "o":
+				if m.threadView {
+					return m, nil
+				}
+				return m.openAttachment()
+			case "z":
+				if m.detail == nil {
+					return m, nil
+				}
+				m.expandQuotes = !m.expandQuotes
+				m.refreshDetailText()
+				return m, nil
+			case "m":
+				return m, m.toggleMute()
+			case "!":
+				m.reportPhishing()
+				return m, nil
+			case "R":
+				if !m.composeBusy() {
+					m.startReply()
+				}
+				return m, nil
+			case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+				if !m.composeBusy() {
+					m.startCannedReply(int(k[0] - '0'))
+				}
+				return m, nil
+			case "O":
+				if !m.threadView || m.thread == nil {
+					return m, nil
+				}
+				cmd := m.toggleThreadOrder()
+				return m, cmd
+			case "s":
+				if m.detail == nil || m.threadView {
+					return m, nil
+				}
+				m.bodyView = m.bodyView.next()
+				m.setDetailText(m.detailContent())
+				m.status = "Body: " + bodyViewNames[m.bodyView]
+				return m, nil
+			case "@":
+				return m, m.toggleFullAddresses()
+			case "v":
+				cmd := m.cycleHeaderDetail()
+				return m, cmd
+			case "H":
+				if m.detail == nil || m.threadView {
+					return m, nil
+				}
+				m.showHeaders = !m.showHeaders
+				m.setDetailText(m.detailContent())
+				m.detailVP.GotoTop()
+				return m, nil
+			case "y":
+				if m.detail == nil || m.detail.ThreadID == "" {
+					return m, nil
+				}
+				link := gmailx.Permalink(m.accountIndex, m.detail.ThreadID)
+				if err := clipboard.WriteAll(link); err != nil {
+					m.status = "Couldn't copy to clipboard: " + link
+					return m, nil
+				}
+				m.status = "Copied " + link
+				return m, nil
 			}
 			var cmd tea.Cmd
 			m.detailVP, cmd = m.detailVP.Update(msg)
@@ -352,17 +1578,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.screen = screenInbox
 				m.searchInput.Blur()
 				return m, nil
+			case "ctrl+t":
+				m.searchEverywhere = !m.searchEverywhere
+				return m, nil
 			case "enter":
-				m.query = m.searchInput.Value()
 				m.searchInput.Blur()
-				m.screen = screenInbox
-				return m, m.fetchInboxCmd()
+				return m, m.submitSearch(m.searchInput.Value(), m.searchEverywhere)
 			}
 			var cmd tea.Cmd
 			m.searchInput, cmd = m.searchInput.Update(msg)
 			return m, cmd
 
 		case screenLabels:
+			if m.keyBlocked(k) {
+				return m, nil
+			}
 			switch k {
 			case "b":
 				m.screen = screenInbox
@@ -371,8 +1601,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, m.fetchLabelsCmd()
 			case "enter":
 				if it, ok := m.labels.SelectedItem().(labelItem); ok {
-					// Use label ID for filtering - Gmail search uses label IDs
-					m.query = "label:" + it.id
+					m.setLabel(it.id, it.name)
 					m.screen = screenInbox
 					return m, m.fetchInboxCmd()
 				}
@@ -381,6 +1610,30 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			var cmd tea.Cmd
 			m.labels, cmd = m.labels.Update(msg)
 			return m, cmd
+
+		case screenVacation:
+			return m.updateVacation(msg)
+
+		case screenFilters:
+			return m.updateFilters(msg)
+
+		case screenSignature:
+			return m.updateSignature(msg)
+
+		case screenCompose:
+			return m.updateCompose(msg)
+
+		case screenProfile:
+			return m.updateProfile(msg)
+
+		case screenSummary:
+			return m.updateSummary(msg)
+
+		case screenAttachments:
+			return m.updateAttachments(msg)
+
+		case screenSetup:
+			return m.updateSetup(msg)
 		}
 	}
 
@@ -0,0 +1,512 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"gmail-tui/internal/config"
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/gmail/gmailtest"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/gmail/v1"
+)
+
+// fakeFlow is a login flow that returns tok, or err, without a browser.
+type fakeFlow struct {
+	tok *oauth2.Token
+	err error
+}
+
+func (f fakeFlow) Login(context.Context, *oauth2.Config) (*oauth2.Token, error) {
+	return f.tok, f.err
+}
+
+// testModel returns a model whose data directory is in a temporary HOME and
+// whose client factory talks to a fake Gmail server, returned for the test to
+// fill. The model has an OAuth config but isn't logged in.
+func testModel(t *testing.T) (model, *gmailtest.Server) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	s := gmailtest.NewServer()
+	t.Cleanup(s.Close)
+
+	settings := config.Default()
+	scopes, err := RequestedScopes(settings)
+	if err != nil {
+		t.Fatalf("RequestedScopes() error = %v", err)
+	}
+	m := NewModel(settings, nil, StartOptions{})
+	t.Cleanup(m.cancel)
+	m.cfg = &oauth2.Config{ClientID: "test", Scopes: scopes}
+	m.newClient = func(ctx context.Context, _ *oauth2.Config, _ *oauth2.Token, opts ...gmailx.Option) (*gmailx.Client, error) {
+		return s.Client(ctx, opts...)
+	}
+	m.width, m.height = 100, 40
+	m.resize()
+	return m, s
+}
+
+// step feeds msg to m.Update and returns the updated model and command.
+func step(t *testing.T, m model, msg tea.Msg) (model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	nm, ok := next.(model)
+	if !ok {
+		t.Fatalf("Update(%T) returned a %T, want a model", msg, next)
+	}
+	return nm, cmd
+}
+
+// press builds the key message for a key named as tea.KeyMsg.String names it.
+func press(k string) tea.KeyMsg {
+	switch k {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "ctrl+c":
+		return tea.KeyMsg{Type: tea.KeyCtrlC}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// loggedIn returns m after a token was loaded.
+func loggedIn(t *testing.T, m model) model {
+	t.Helper()
+	m, _ = step(t, m, tokenLoadedMsg{tok: &oauth2.Token{AccessToken: "test"}})
+	return m
+}
+
+// loadInbox fetches the current inbox page from the fake server and streams
+// its rows into m, as the program would.
+func loadInbox(t *testing.T, m model) model {
+	t.Helper()
+	msg := m.fetchInboxCmd()()
+	start, ok := msg.(inboxStartMsg)
+	if !ok {
+		t.Fatalf("fetchInboxCmd() = %#v, want an inboxStartMsg", msg)
+	}
+	m, _ = step(t, m, start)
+	for {
+		msg := waitInboxStream(start.stream)()
+		m, _ = step(t, m, msg)
+		if _, done := msg.(inboxDoneMsg); done {
+			return m
+		}
+	}
+}
+
+// itemIDs returns the IDs of the inbox rows shown.
+func itemIDs(m model) []string {
+	var ids []string
+	for _, it := range m.inbox.Items() {
+		ids = append(ids, it.(emailItem).id)
+	}
+	return ids
+}
+
+func TestLoginKeyLogsInAndOpensInbox(t *testing.T) {
+	m, _ := testModel(t)
+	tok := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+	m.flow = fakeFlow{tok: tok}
+
+	m, cmd := step(t, m, press("l"))
+	if m.status != "Opening browser for login..." || cmd == nil {
+		t.Fatalf("after l: status %q, cmd %v, want a login in progress", m.status, cmd)
+	}
+	batch, ok := cmd().(tea.BatchMsg)
+	if !ok || len(batch) == 0 {
+		t.Fatalf("login command returned %#v, want a batch", batch)
+	}
+	msg := batch[0]()
+	loaded, ok := msg.(tokenLoadedMsg)
+	if !ok || loaded.tok != tok {
+		t.Fatalf("login returned %#v, want the flow's token", msg)
+	}
+	if saved, err := m.store.Load(); err != nil || saved.RefreshToken != "refresh" {
+		t.Errorf("saved token = %v, %v, want the flow's token", saved, err)
+	}
+
+	m, cmd = step(t, m, loaded)
+	if m.screen != screenInbox || m.status != "Logged in" || m.token != tok {
+		t.Errorf("after login: screen %v, status %q, want the inbox, logged in", m.screen, m.status)
+	}
+	if cmd == nil {
+		t.Error("after login: no command, want the inbox to be fetched")
+	}
+}
+
+func TestLoginFailureStaysOnAuthScreen(t *testing.T) {
+	m, _ := testModel(t)
+	m.flow = fakeFlow{err: errors.New("access denied")}
+
+	m, cmd := step(t, m, press("l"))
+	msg := cmd().(tea.BatchMsg)[0]()
+	m, _ = step(t, m, msg)
+	if m.screen != screenAuth || m.token != nil {
+		t.Errorf("after a failed login: screen %v, token %v, want the login screen and no token", m.screen, m.token)
+	}
+}
+
+func TestInboxMsg(t *testing.T) {
+	m, _ := testModel(t)
+	m = loggedIn(t, m)
+
+	rows := []gmailx.EmailRow{{ID: "a", Subject: "First"}, {ID: "b", Subject: "Second"}}
+	m, _ = step(t, m, inboxMsg{items: rowsToItems(rows)})
+	if got := itemIDs(m); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("inbox rows = %v, want [a b]", got)
+	}
+
+	failed := errors.New("backend error")
+	m, _ = step(t, m, inboxMsg{err: failed})
+	if !errors.Is(m.err, failed) {
+		t.Errorf("err = %v, want %v", m.err, failed)
+	}
+	if got := itemIDs(m); len(got) != 2 {
+		t.Errorf("inbox rows after an error = %v, want them kept", got)
+	}
+}
+
+func TestInboxStreamsRowsFromServer(t *testing.T) {
+	m, s := testModel(t)
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("m1", "Newest", "ana@example.com", "one", "INBOX", "UNREAD"),
+		gmailtest.Message("m2", "Middle", "bob@example.com", "two", "INBOX"),
+		gmailtest.Message("m3", "Archived", "cy@example.com", "three"),
+		gmailtest.Message("m4", "Oldest", "dee@example.com", "four", "INBOX"),
+	}
+	m = loggedIn(t, m)
+
+	m = loadInbox(t, m)
+	if got := itemIDs(m); len(got) != 3 || got[0] != "m1" || got[1] != "m2" || got[2] != "m4" {
+		t.Errorf("inbox rows = %v, want [m1 m2 m4]", got)
+	}
+	if m.inboxStream != nil || m.err != nil {
+		t.Errorf("after the stream: inboxStream %v, err %v, want both nil", m.inboxStream, m.err)
+	}
+}
+
+func TestEnterOpensDetailAndBReturns(t *testing.T) {
+	m, s := testModel(t)
+	s.Messages = []*gmail.Message{gmailtest.Message("m1", "Lunch?", "ana@example.com", "Noon at the usual place.", "INBOX")}
+	m = loadInbox(t, loggedIn(t, m))
+
+	m, cmd := step(t, m, press("enter"))
+	if m.status != "Loading message..." || m.detailID != "m1" || cmd == nil {
+		t.Fatalf("after enter: status %q, detailID %q, want m1 loading", m.status, m.detailID)
+	}
+	m, _ = step(t, m, cmd())
+	if m.screen != screenDetail || m.detail == nil || m.detail.Subject != "Lunch?" {
+		t.Fatalf("after loading: screen %v, detail %+v, want m1 open", m.screen, m.detail)
+	}
+
+	m, _ = step(t, m, press("b"))
+	if m.screen != screenInbox {
+		t.Errorf("after b: screen %v, want the inbox", m.screen)
+	}
+}
+
+func TestSearchSetsQuery(t *testing.T) {
+	m, _ := testModel(t)
+	m.settings.ConfirmSearchOver = 0
+	m = loggedIn(t, m)
+
+	m, _ = step(t, m, press("/"))
+	if m.screen != screenSearch {
+		t.Fatalf("after /: screen %v, want search", m.screen)
+	}
+	m, _ = step(t, m, press("from:ana"))
+	m, cmd := step(t, m, press("enter"))
+	if m.screen != screenInbox || m.query != "from:ana" || cmd == nil {
+		t.Errorf("after enter: screen %v, query %q, want the inbox searching from:ana", m.screen, m.query)
+	}
+
+	m, _ = step(t, m, press("/"))
+	m, _ = step(t, m, press("esc"))
+	if m.screen != screenInbox || m.query != "from:ana" {
+		t.Errorf("after esc: screen %v, query %q, want the inbox with the query kept", m.screen, m.query)
+	}
+}
+
+func TestSentMsg(t *testing.T) {
+	t.Run("sent from compose", func(t *testing.T) {
+		m, _ := testModel(t)
+		m = loggedIn(t, m)
+		m.screen = screenCompose
+		m.pendingSend = &pendingSend{out: &gmailx.OutgoingMessage{To: "ana@example.com"}, seq: 1}
+
+		m, _ = step(t, m, sentMsg{seq: 1, to: "ana@example.com", id: "s1"})
+		if m.screen != screenInbox || m.status != "Message sent" || m.pendingSend != nil {
+			t.Errorf("screen %v, status %q, pending %v, want the inbox and the send done", m.screen, m.status, m.pendingSend)
+		}
+	})
+
+	t.Run("sent after leaving compose", func(t *testing.T) {
+		m, _ := testModel(t)
+		m = loggedIn(t, m)
+		m.screen = screenLabels
+		m.pendingSend = &pendingSend{out: &gmailx.OutgoingMessage{To: "ana@example.com"}, seq: 1}
+
+		m, _ = step(t, m, sentMsg{seq: 1, to: "ana@example.com", id: "s1"})
+		if m.screen != screenLabels || m.status != "Message sent" {
+			t.Errorf("screen %v, status %q, want the labels screen kept", m.screen, m.status)
+		}
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		m, _ := testModel(t)
+		m = loggedIn(t, m)
+		m.pendingSend = &pendingSend{out: &gmailx.OutgoingMessage{To: "ana@example.com"}, seq: 1}
+
+		m, _ = step(t, m, sentMsg{seq: 1, err: errors.New("quota")})
+		if m.screen != screenCompose || m.status != "Send failed: quota" {
+			t.Errorf("screen %v, status %q, want the draft reopened", m.screen, m.status)
+		}
+	})
+
+	t.Run("from an ended session", func(t *testing.T) {
+		m, _ := testModel(t)
+		m = loggedIn(t, m)
+		m.pendingSend = &pendingSend{out: &gmailx.OutgoingMessage{To: "ana@example.com"}, seq: 1}
+		m.endSession()
+
+		m, _ = step(t, m, sentMsg{seq: 1, err: errors.New("unauthenticated")})
+		if m.screen != screenAuth || strings.HasPrefix(m.status, "Send failed") {
+			t.Errorf("screen %v, status %q, want the reply ignored on the login screen", m.screen, m.status)
+		}
+	})
+}
+
+func TestQuitCancelsInFlightCommands(t *testing.T) {
+	m, _ := testModel(t)
+	m = loggedIn(t, m)
+	// A server that never answers, so the fetch only ends when cancelled.
+	arrived := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- struct{}{}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	m.newClient = func(ctx context.Context, _ *oauth2.Config, _ *oauth2.Token, opts ...gmailx.Option) (*gmailx.Client, error) {
+		return gmailx.New(ctx, nil, nil, append([]gmailx.Option{gmailx.WithEndpoint(srv.URL), gmailx.WithHTTPClient(srv.Client())}, opts...)...)
+	}
+	before := runtime.NumGoroutine()
+
+	fetched := make(chan tea.Msg, 1)
+	fetch := m.fetchInboxCmd()
+	go func() { fetched <- fetch() }()
+	<-arrived
+
+	m, cmd := step(t, m, press("q"))
+	if cmd == nil {
+		t.Fatal("q returned no command, want tea.Quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("q didn't quit")
+	}
+	if !errors.Is(m.ctx.Err(), context.Canceled) {
+		t.Errorf("root context error = %v, want context.Canceled", m.ctx.Err())
+	}
+
+	select {
+	case msg := <-fetched:
+		if im, ok := msg.(inboxMsg); !ok || im.err == nil {
+			t.Errorf("fetch returned %#v, want it to fail on quitting", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetch still running after quitting")
+	}
+
+	// Let the connection and handler goroutines wind down.
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after quitting, want at most the %d before the fetch", n, before)
+	}
+}
+
+func TestOpeningDeletedMessageRemovesRow(t *testing.T) {
+	m, s := testModel(t)
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("gone", "Deleted elsewhere", "ana@example.com", "x", "INBOX"),
+		gmailtest.Message("kept", "Still here", "bob@example.com", "y", "INBOX"),
+	}
+	m = loadInbox(t, loggedIn(t, m))
+	s.Lock()
+	s.Errors["/gmail/v1/users/me/messages/gone"] = http.StatusNotFound
+	s.Unlock()
+
+	m, cmd := step(t, m, press("enter"))
+	msg := cmd()
+	if dm, ok := msg.(detailMsg); !ok || dm.goneID != "gone" || dm.err != nil {
+		t.Fatalf("detail fetch returned %#v, want the message reported gone", msg)
+	}
+	m, _ = step(t, m, msg)
+	if m.screen != screenInbox || m.status != "Message no longer exists" || m.err != nil {
+		t.Errorf("screen %v, status %q, err %v, want the inbox with a status", m.screen, m.status, m.err)
+	}
+	if got := itemIDs(m); len(got) != 1 || got[0] != "kept" {
+		t.Errorf("inbox rows = %v, want [kept]", got)
+	}
+}
+
+func TestDryRunIsFixedWhenCommandIsCreated(t *testing.T) {
+	m, _ := testModel(t)
+	m = loggedIn(t, m)
+
+	m, _ = step(t, m, press("D"))
+	cmd := m.markReadCmd([]string{"a"})
+	m, _ = step(t, m, press("D"))
+	if m.settings.DryRun {
+		t.Fatal("D twice left dry-run on")
+	}
+	// The fake server has no modify endpoint, so a real request would fail.
+	msg, ok := cmd().(markedReadMsg)
+	if !ok || msg.err != nil || !msg.dryRun {
+		t.Errorf("markReadCmd() made in dry-run mode = %+v, want a dry run with no error", msg)
+	}
+
+	msg, ok = m.markReadCmd([]string{"a"})().(markedReadMsg)
+	if !ok || msg.err == nil || msg.dryRun {
+		t.Errorf("markReadCmd() made after dry-run was turned off = %+v, want the request sent", msg)
+	}
+}
+
+func TestOnlyInvalidGrantForgetsToken(t *testing.T) {
+	rejected := func(code string) error {
+		return fmt.Errorf("refresh: %w", &oauth2.RetrieveError{
+			Response:  &http.Response{StatusCode: http.StatusUnauthorized},
+			ErrorCode: code,
+		})
+	}
+	tests := []struct {
+		name       string
+		err        func(m model, s *gmailtest.Server) error
+		wantForgot bool
+	}{
+		{"API call rejected", func(m model, s *gmailtest.Server) error {
+			s.Lock()
+			s.Errors["/gmail/v1/users/me/messages/batchModify"] = http.StatusUnauthorized
+			s.Unlock()
+			return m.markReadCmd([]string{"a"})().(markedReadMsg).err
+		}, false},
+		{"invalid client", func(model, *gmailtest.Server) error { return rejected("invalid_client") }, false},
+		{"invalid grant", func(model, *gmailtest.Server) error { return rejected("invalid_grant") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, s := testModel(t)
+			tok := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+			if err := m.store.Save(tok); err != nil {
+				t.Fatal(err)
+			}
+			m, _ = step(t, m, tokenLoadedMsg{tok: tok})
+
+			m, _ = step(t, m, markedReadMsg{err: tt.err(m, s)})
+			_, loadErr := m.store.Load()
+			if forgot := loadErr != nil; forgot != tt.wantForgot {
+				t.Errorf("saved token forgotten = %v, want %v", forgot, tt.wantForgot)
+			}
+			if tt.wantForgot {
+				if m.token != nil || m.screen != screenAuth {
+					t.Errorf("after invalid_grant: token %v, screen %v, want the login screen", m.token, m.screen)
+				}
+			} else if m.token != tok || m.err == nil {
+				t.Errorf("token %v, err %v, want the session kept and the error shown", m.token, m.err)
+			}
+		})
+	}
+}
+
+func TestEndSessionForgetsAccountState(t *testing.T) {
+	m, _ := testModel(t)
+	m = loggedIn(t, m)
+	m.setAccount("ana@example.com")
+	m.learnContacts("m1", "Bo <bo@example.com>", "", time.Now())
+	m.saveContacts()
+	m.setLabel("Label_1", "Work")
+	m.setQuery("from:bo")
+	m.everywhere = true
+	m.pageTokens = []string{"p2"}
+	m.expandedID = "m1"
+	m.searchInput.SetValue("from:bo")
+	m.findQuery = "bo"
+
+	m.endSession()
+	if m.contacts != nil {
+		t.Error("contacts kept after the session ended")
+	}
+	if m.query != "" || m.labelID != "" || m.labelName != "" || m.everywhere || m.pageTokens != nil ||
+		m.expandedID != "" || m.searchInput.Value() != "" || m.findQuery != "" {
+		t.Errorf("after endSession: query %q, label %q/%q, everywhere %v, pages %v, expanded %q, search %q, find %q, want all cleared",
+			m.query, m.labelID, m.labelName, m.everywhere, m.pageTokens, m.expandedID, m.searchInput.Value(), m.findQuery)
+	}
+
+	m = loggedIn(t, m)
+	m.setAccount("cy@example.com")
+	if got := m.contacts.Suggest("bo", maxSuggestions); len(got) != 0 {
+		t.Errorf("another account's suggestions = %v, want none of the previous account's contacts", got)
+	}
+	m.setAccount("ana@example.com")
+	if got := m.contacts.Suggest("bo", maxSuggestions); len(got) != 1 {
+		t.Errorf("suggestions after switching back = %v, want the contact learned before", got)
+	}
+}
+
+func TestInlineViewFitsTerminalHeight(t *testing.T) {
+	m, s := testModel(t)
+	s.Lock()
+	for i := range 60 {
+		id := fmt.Sprintf("m%02d", i)
+		s.Messages = append(s.Messages, gmailtest.Message(id, "Subject "+id, "Ana <ana@example.com>", "Hello", "INBOX", "UNREAD"))
+	}
+	s.Unlock()
+	m.settings.NoAltScreen = true
+	m = loggedIn(t, m)
+	m = loadInbox(t, m)
+
+	for _, height := range []int{24, 40} {
+		m, _ = step(t, m, tea.WindowSizeMsg{Width: 100, Height: height})
+		screens := []struct {
+			name string
+			open func(m model) model
+		}{
+			{"inbox", func(m model) model { m.screen = screenInbox; return m }},
+			{"inbox with status", func(m model) model {
+				m, _ = step(t, m, press("h"))
+				return m
+			}},
+			{"message", func(m model) model {
+				m, _ = step(t, m, detailMsg{detail: &gmailx.EmailDetail{ID: "m00", Body: strings.Repeat("line\n", 100)}})
+				return m
+			}},
+			{"compose", func(m model) model {
+				m.startCompose("")
+				m, _ = step(t, m, press("a"))
+				return m
+			}},
+		}
+		for _, sc := range screens {
+			m = sc.open(m)
+			if sc.name == "compose" && m.screen != screenCompose {
+				t.Fatalf("screen = %v, want compose", m.screen)
+			}
+			if got := lipgloss.Height(m.view()); got > height {
+				t.Errorf("%s at height %d: view is %d lines, want it to fit", sc.name, height, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const vacationDateLayout = "2006-01-02"
+
+const (
+	vacSubject = iota
+	vacMessage
+	vacStart
+	vacEnd
+	vacFieldCount
+)
+
+type vacationMsg struct {
+	settings *gmailx.VacationSettings
+	err      error
+}
+
+type vacationSavedMsg struct {
+	settings *gmailx.VacationSettings
+	err      error
+}
+
+// newVacationInputs creates the text inputs used by the vacation responder editor,
+// in the order given by the vac* field constants.
+func newVacationInputs() []textinput.Model {
+	inputs := make([]textinput.Model, vacFieldCount)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 60
+	}
+	inputs[vacSubject].Prompt = "Subject: "
+	inputs[vacMessage].Prompt = "Message: "
+	inputs[vacStart].Prompt = "Start:   "
+	inputs[vacStart].Placeholder = "YYYY-MM-DD (optional)"
+	inputs[vacEnd].Prompt = "End:     "
+	inputs[vacEnd].Placeholder = "YYYY-MM-DD (optional)"
+	return inputs
+}
+
+// fetchVacationCmd creates a command that fetches the current vacation responder settings.
+// Uses the configured timeout for the API call.
+func (m model) fetchVacationCmd() tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return vacationMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return vacationMsg{err: err}
+		}
+		s, err := c.GetVacation(ctx)
+		if err != nil {
+			return vacationMsg{err: err}
+		}
+		return vacationMsg{settings: s}
+	}
+}
+
+// saveVacationCmd creates a command that writes the given vacation responder settings.
+// Uses the configured timeout for the API call.
+func (m model) saveVacationCmd(s *gmailx.VacationSettings) tea.Cmd {
+	cfg := m.cfg
+	root := m.ctx
+	tok := m.token
+	newClient := m.newClient
+	timeout := m.settings.TimeoutSeconds
+
+	return func() tea.Msg {
+		if cfg == nil || tok == nil {
+			return vacationSavedMsg{err: errMissingCfg{}}
+		}
+		ctx, cancel := gmailx.HumanTimeoutCtx(root, timeout)
+		defer cancel()
+
+		c, err := newClient(ctx, cfg, tok)
+		if err != nil {
+			return vacationSavedMsg{err: err}
+		}
+		if err := c.UpdateVacation(ctx, s); err != nil {
+			return vacationSavedMsg{err: err}
+		}
+		return vacationSavedMsg{settings: s}
+	}
+}
+
+// parseVacationDate parses an optional YYYY-MM-DD date in local time.
+// An empty string yields the zero time.
+func parseVacationDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(vacationDateLayout, s, time.Local)
+}
+
+// vacationFromInputs validates the editor fields and builds the settings to save.
+// The end date is inclusive for the user, so it is converted to midnight of the
+// following day, which is how the API's exclusive end time is expressed.
+func (m model) vacationFromInputs() (*gmailx.VacationSettings, error) {
+	start, err := parseVacationDate(m.vacInputs[vacStart].Value())
+	if err != nil {
+		return nil, errors.New("start date must be YYYY-MM-DD")
+	}
+	end, err := parseVacationDate(m.vacInputs[vacEnd].Value())
+	if err != nil {
+		return nil, errors.New("end date must be YYYY-MM-DD")
+	}
+	if !end.IsZero() {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return nil, errors.New("end date must not be before start date")
+	}
+	return &gmailx.VacationSettings{
+		Enabled: m.vacEnabled,
+		Subject: strings.TrimSpace(m.vacInputs[vacSubject].Value()),
+		Message: m.vacInputs[vacMessage].Value(),
+		Start:   start,
+		End:     end,
+	}, nil
+}
+
+// setVacationInputs fills the editor fields from the given settings.
+func (m *model) setVacationInputs(s *gmailx.VacationSettings) {
+	m.vacation = s
+	m.vacEnabled = s.Enabled
+	m.vacInputs[vacSubject].SetValue(s.Subject)
+	m.vacInputs[vacMessage].SetValue(s.Message)
+	m.vacInputs[vacStart].SetValue("")
+	m.vacInputs[vacEnd].SetValue("")
+	if !s.Start.IsZero() {
+		m.vacInputs[vacStart].SetValue(s.Start.Format(vacationDateLayout))
+	}
+	if !s.End.IsZero() {
+		m.vacInputs[vacEnd].SetValue(s.End.AddDate(0, 0, -1).Format(vacationDateLayout))
+	}
+	m.focusVacationInput(vacSubject)
+}
+
+// focusVacationInput moves keyboard focus to the i-th editor field.
+func (m *model) focusVacationInput(i int) {
+	m.vacFocus = (i + vacFieldCount) % vacFieldCount
+	for j := range m.vacInputs {
+		if j == m.vacFocus {
+			m.vacInputs[j].Focus()
+		} else {
+			m.vacInputs[j].Blur()
+		}
+	}
+}
+
+// updateVacation handles key presses on the vacation responder editor.
+// tab/shift+tab move between fields, ctrl+t toggles the responder,
+// ctrl+s validates and saves, and esc returns to the inbox without saving.
+func (m model) updateVacation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.screen = screenInbox
+		return m, nil
+	case "tab", "down":
+		m.focusVacationInput(m.vacFocus + 1)
+		return m, nil
+	case "shift+tab", "up":
+		m.focusVacationInput(m.vacFocus - 1)
+		return m, nil
+	case "ctrl+t":
+		m.vacEnabled = !m.vacEnabled
+		return m, nil
+	case "ctrl+s":
+		if m.offline {
+			m.status = "Offline — changes can't be saved until the connection is back"
+			return m, nil
+		}
+		s, err := m.vacationFromInputs()
+		if err != nil {
+			m.status = "Invalid settings: " + err.Error()
+			return m, nil
+		}
+		m.status = "Saving vacation responder..."
+		return m, m.saveVacationCmd(s)
+	}
+	var cmd tea.Cmd
+	m.vacInputs[m.vacFocus], cmd = m.vacInputs[m.vacFocus].Update(msg)
+	return m, cmd
+}
+
+// vacationStatus describes the saved vacation responder state in one line,
+// including the active date range when one is set.
+func vacationStatus(s *gmailx.VacationSettings) string {
+	if s == nil {
+		return "unknown"
+	}
+	if !s.Enabled {
+		return "OFF"
+	}
+	out := "ON"
+	if !s.Start.IsZero() {
+		out += " from " + s.Start.Format(vacationDateLayout)
+	}
+	if !s.End.IsZero() {
+		out += " until " + s.End.AddDate(0, 0, -1).Format(vacationDateLayout)
+	}
+	return out
+}
+
+// vacationView renders the vacation responder editor.
+func (m model) vacationView() string {
+	enabled := "[ ] Auto-reply enabled"
+	if m.vacEnabled {
+		enabled = "[x] Auto-reply enabled"
+	}
+	body := "Vacation responder\n\n"
+	body += "Current: " + bold.Render(vacationStatus(m.vacation)) + "\n\n"
+	body += enabled + "\n"
+	for _, in := range m.vacInputs {
+		body += in.View() + "\n"
+	}
+	if m.status != "" {
+		body += "\n" + m.status + "\n"
+	}
+	return body + "\n" + m.footer()
+}
@@ -1,40 +1,200 @@
 package app
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
 
-// View renders the current application state into a string for terminal display.
-// Different screens (auth, inbox, detail, search) have different layouts and controls.
-// Returns the formatted string to be displayed by Bubble Tea.
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// View renders the current application state for Bubble Tea.
 func (m model) View() string {
-	title := bold.Render("Gmail TUI")
+	return m.view()
+}
+
+// errorText describes a failed command for the error screen, explaining the
+// API errors a user can do something about before the error itself.
+func errorText(err error) string {
+	var hint string
+	switch {
+	case errors.Is(err, gmailx.ErrInsufficientScope):
+		hint = "Gmail refused the request: the granted scopes don't allow it. Add the scope to scopes and log in again."
+	case errors.Is(err, gmailx.ErrRateLimited):
+		hint = "Gmail is rate limiting requests. Wait a minute, or lower quota_per_second, and try again."
+	case errors.Is(err, gmailx.ErrUnauthenticated):
+		hint = "Google rejected the app's credentials. The saved login was kept; if this persists, check the OAuth client in the credentials file."
+	case errors.Is(err, gmailx.ErrNotFound):
+		hint = "It no longer exists in Gmail; it may have been deleted."
+	}
+	if hint == "" {
+		return "Error: " + err.Error()
+	}
+	return hint + "\n\n" + faint.Render("Error: "+err.Error())
+}
+
+// view renders the current application state into a string for terminal display.
+// Different screens (auth, inbox, detail, search) have different layouts and controls.
+func (m model) view() string {
+	title := m.titleView()
 	if m.err != nil {
-		return pad.Render(box.Render(title+"\n\nError: "+m.err.Error()+"\n\n"+faint.Render("q quit"))) + "\n"
+		return pad.Render(box.Render(title+"\n\n"+errorText(m.err)+"\n\n"+faint.Render("q quit"))) + "\n"
+	}
+
+	if m.confirm != nil {
+		body := m.confirm.text + "\n\n" + faint.Render("y confirm • any other key cancel")
+		return pad.Render(box.Render(title+"\n\n"+body)) + "\n"
+	}
+
+	if m.showHelp {
+		return pad.Render(box.Render(title+"\n\n"+m.helpView())) + "\n"
+	}
+
+	if m.paletteOpen {
+		return pad.Render(box.Render(title+"\n\n"+m.paletteView())) + "\n"
+	}
+
+	if m.openRefOpen {
+		return pad.Render(box.Render(title+"\n\n"+m.openRefView())) + "\n"
+	}
+
+	if m.labelPickOpen {
+		return pad.Render(box.Render(title+"\n\n"+m.labelPickView())) + "\n"
 	}
 
 	switch m.screen {
 	case screenAuth:
-		body := "No saved token found.\n\nPress l to login in your browser.\n\n" + faint.Render("l login • q quit")
+		if m.device != nil {
+			body := "To sign in, visit:\n\n  " + m.device.VerificationURI + "\n\nand enter the code:\n\n  " + bold.Render(m.device.UserCode) +
+				"\n\n" + m.status + "\n\n" + faint.Render("q quit (login resumes on next launch)")
+			return pad.Render(box.Render(title+"\n\n"+body)) + "\n"
+		}
+		body := "No saved token found.\n\nPress l to login in your browser, or d to login from another device.\n\n"
+		if m.status != "" {
+			body += m.status + "\n\n"
+		}
+		body += faint.Render("l login • d device login • R reload config • q quit")
 		return pad.Render(box.Render(title+"\n\n"+body)) + "\n"
 
 	case screenSearch:
-		body := "Search\n\n" + m.searchInput.View() + "\n\n" + faint.Render("enter apply • esc cancel")
+		scope := "Searching the inbox"
+		if m.searchEverywhere {
+			scope = bold.Render("Searching everywhere, including spam & trash")
+		}
+		body := "Search\n\n" + m.searchInput.View() + "\n\n" + scope + "\n\n" + m.footer()
 		return pad.Render(box.Render(title+"\n\n"+body)) + "\n"
 
 	case screenInbox:
-		h := title + "\n" + faint.Render("enter open • / search • g labels • r refresh • q quit")
-		if m.query != "" {
-			h += "\n" + fmt.Sprintf("Query: %s", m.query)
-		}
-		return pad.Render(box.Render(h+"\n\n"+m.inbox.View())) + "\n"
+		return pad.Render(box.Render(m.inboxHeader()+"\n\n"+m.inboxListView())) + "\n"
 
 	case screenDetail:
-		h := title + "\n" + faint.Render("b back • r reload • q quit")
-		return pad.Render(box.Render(h+"\n\n"+m.detailVP.View())) + "\n"
+		return pad.Render(box.Render(m.detailHeader()+"\n\n"+m.detailVP.View())) + "\n"
 
 	case screenLabels:
-		h := title + "\n" + faint.Render("enter filter by label • b back • r refresh • q quit")
+		h := title + "\n" + m.footer()
+		if age := m.labelsAge(); age != "" {
+			h += "\n" + faint.Render(age)
+		}
 		return pad.Render(box.Render(h+"\n\n"+m.labels.View())) + "\n"
+
+	case screenFilters:
+		return pad.Render(box.Render(title+"\n"+m.filtersView())) + "\n"
+
+	case screenSignature:
+		return pad.Render(box.Render(title+"\n\n"+m.signatureView())) + "\n"
+
+	case screenVacation:
+		return pad.Render(box.Render(title+"\n\n"+m.vacationView())) + "\n"
+
+	case screenCompose:
+		return pad.Render(box.Render(title+"\n\n"+m.composeView())) + "\n"
+
+	case screenProfile:
+		return pad.Render(box.Render(title+"\n\n"+m.profileView())) + "\n"
+
+	case screenSummary:
+		return pad.Render(box.Render(title+"\n\n"+m.summaryView())) + "\n"
+
+	case screenSetup:
+		return pad.Render(box.Render(title+"\n\n"+m.setupView())) + "\n"
+
+	case screenAttachments:
+		return pad.Render(box.Render(title+"\n"+m.attachmentsView())) + "\n"
 	}
 
 	return ""
 }
+
+// titleView renders the top of every screen: the app name, the account and
+// the dry-run marker, and the banner shown after switching accounts.
+func (m model) titleView() string {
+	title := bold.Render("Gmail TUI")
+	if badge := m.accountBadge(); badge != "" {
+		title += "  " + badge
+	}
+	if m.settings.DryRun {
+		title += "  " + bold.Render("DRY RUN")
+	}
+	if m.accountBanner {
+		title += "\n" + m.accountBannerView()
+	}
+	return title
+}
+
+// inboxHeader renders the lines above the inbox list: the title, the keys and
+// the state of the listing.
+func (m model) inboxHeader() string {
+	h := m.titleView() + "\n" + m.footer()
+	if m.offline {
+		h += "\n" + bold.Render("offline — showing cached data")
+	}
+	if n := m.unreadCount(); n > 0 {
+		h += "\n" + fmt.Sprintf("%d unread", n)
+	}
+	if m.flagFilter != "" {
+		h += "\n" + bold.Render("Flag: "+m.flagFilter)
+	}
+	if m.hideRead || m.flagFilter != "" {
+		h += "\n" + bold.Render(fmt.Sprintf("%d hidden", len(m.hiddenRows)))
+	}
+	if m.status != "" {
+		h += "\n" + faint.Render(m.status)
+	}
+	if m.labelName != "" {
+		h += "\n" + fmt.Sprintf("Label: %s", m.labelName)
+	}
+	if m.everywhere {
+		h += "\n" + bold.Render("Everywhere, including spam & trash")
+	}
+	if m.query != "" {
+		h += "\n" + fmt.Sprintf("Query: %s", m.query)
+	}
+	if cat := gmailx.QueryCategory(m.query); cat != "" {
+		h += "\n" + bold.Render("Category: "+strings.ToUpper(cat[:1])+cat[1:])
+	}
+	if p := m.pageInfo(); p != "" {
+		h += "\n" + faint.Render(p)
+	}
+	if m.jumpBuf != "" {
+		h += "\n" + fmt.Sprintf("Go to row: %s (enter jump • esc cancel)", m.jumpBuf)
+	}
+	return h
+}
+
+// detailHeader renders the lines above the open message.
+func (m model) detailHeader() string {
+	h := m.titleView() + "\n" + m.footer()
+	if m.offline {
+		h += "\n" + bold.Render("offline — showing cached data")
+	}
+	if m.status != "" {
+		h += "\n" + faint.Render(m.status)
+	}
+	if m.loadingFull != "" {
+		h += "\n" + m.bodySpinner.View() + faint.Render(" Loading the rest of the message...")
+	}
+	if m.finding {
+		h += "\n" + m.findInput.View()
+	}
+	return h
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// DeviceCode is a pending OAuth2 device authorization. It is serializable so that
+// an interrupted login can be persisted and resumed after a restart, and stores
+// the absolute expiry rather than the relative expires_in the server returns.
+type DeviceCode struct {
+	DeviceCode      string    `json:"device_code"`
+	UserCode        string    `json:"user_code"`
+	VerificationURI string    `json:"verification_uri"`
+	Interval        int64     `json:"interval"`
+	Expiry          time.Time `json:"expiry"`
+}
+
+// Expired reports whether the device code can no longer be exchanged for a token.
+func (d *DeviceCode) Expired() bool {
+	return !d.Expiry.IsZero() && time.Now().After(d.Expiry)
+}
+
+// deviceConfig returns a copy of cfg with Google's device authorization endpoint
+// filled in, since configs loaded from credentials.json don't carry it.
+func deviceConfig(cfg *oauth2.Config) *oauth2.Config {
+	c := *cfg
+	if c.Endpoint.DeviceAuthURL == "" {
+		c.Endpoint.DeviceAuthURL = google.Endpoint.DeviceAuthURL
+	}
+	return &c
+}
+
+// StartDeviceLogin begins the OAuth2 device authorization flow, returning the
+// user code and verification URL the user must visit on another device.
+// Useful on headless machines where a browser can't be opened locally.
+func StartDeviceLogin(ctx context.Context, cfg *oauth2.Config) (*DeviceCode, error) {
+	da, err := deviceConfig(cfg).DeviceAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceCode{
+		DeviceCode:      da.DeviceCode,
+		UserCode:        da.UserCode,
+		VerificationURI: da.VerificationURI,
+		Interval:        da.Interval,
+		Expiry:          da.Expiry,
+	}, nil
+}
+
+// PollDeviceLogin polls the token endpoint until the user approves the device code,
+// then returns the token. Returns an error if the user denies access or the code expires.
+func PollDeviceLogin(ctx context.Context, cfg *oauth2.Config, dc *DeviceCode) (*oauth2.Token, error) {
+	if dc.Expired() {
+		return nil, errors.New("device code expired")
+	}
+	da := &oauth2.DeviceAuthResponse{
+		DeviceCode:      dc.DeviceCode,
+		UserCode:        dc.UserCode,
+		VerificationURI: dc.VerificationURI,
+		Interval:        dc.Interval,
+		Expiry:          dc.Expiry,
+	}
+	return deviceConfig(cfg).DeviceAccessToken(ctx, da)
+}
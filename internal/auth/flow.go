@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrAccessDenied is returned when the user declines the consent screen.
+var ErrAccessDenied = errors.New("access denied on the consent screen")
+
+// IsAccessDenied reports whether err means the user declined to grant access,
+// in the browser or when approving a device code, rather than that the login
+// failed.
+func IsAccessDenied(err error) bool {
+	var rErr *oauth2.RetrieveError
+	return errors.Is(err, ErrAccessDenied) || (errors.As(err, &rErr) && rErr.ErrorCode == "access_denied")
+}
+
+// Flow is a way of signing the user in and obtaining an OAuth2 token for cfg.
+// The app holds one so that the login mechanism can be chosen by configuration
+// or replaced, e.g. by a fake in tests.
+type Flow interface {
+	Login(ctx context.Context, cfg *oauth2.Config) (*oauth2.Token, error)
+}
+
+// AccountFunc returns the address of the account tok was issued for.
+type AccountFunc func(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token) (string, error)
+
+// LoopbackFlow signs in through the browser on this machine, see LoopbackLogin.
+// ForceConsent shows Google's consent screen on every login instead of only
+// when it is needed to obtain a refresh token. Saved returns the token saved
+// from an earlier login, or nil; it may itself be nil. Account tells which
+// account a token belongs to; without it the saved refresh token is never
+// reused.
+type LoopbackFlow struct {
+	ForceConsent bool
+	Saved        func() *oauth2.Token
+	Account      AccountFunc
+}
+
+// Login runs the loopback login. Consent is forced unless a refresh token is
+// already held, since Google only issues one through the consent screen; this
+// is decided up front so the browser is usually only visited once. When
+// consent was skipped the new token comes without a refresh token and keeps
+// the saved one, which stays valid, but only if both tokens are for the same
+// account. Otherwise the user is sent through consent again, so the account
+// they just picked gets a refresh token of its own.
+func (f LoopbackFlow) Login(ctx context.Context, cfg *oauth2.Config) (*oauth2.Token, error) {
+	var saved *oauth2.Token
+	if f.Saved != nil {
+		saved = f.Saved()
+	}
+	force := f.ForceConsent || saved == nil || saved.RefreshToken == ""
+	tok, err := LoopbackLogin(ctx, cfg, force)
+	if err != nil || force || tok.RefreshToken != "" {
+		return tok, err
+	}
+	if f.sameAccount(ctx, cfg, saved, tok) {
+		tok.RefreshToken = saved.RefreshToken
+		return tok, nil
+	}
+	slog.Info("logged in to a different account than the saved token's; asking for consent")
+	return LoopbackLogin(ctx, cfg, true)
+}
+
+// sameAccount reports whether saved and tok were issued for the same account.
+// It is false when either can't be told.
+func (f LoopbackFlow) sameAccount(ctx context.Context, cfg *oauth2.Config, saved, tok *oauth2.Token) bool {
+	if f.Account == nil {
+		return false
+	}
+	was, err := f.Account(ctx, cfg, saved)
+	if err != nil {
+		slog.Warn("failed to look up the saved token's account", "err", err)
+		return false
+	}
+	now, err := f.Account(ctx, cfg, tok)
+	if err != nil {
+		slog.Warn("failed to look up the new token's account", "err", err)
+		return false
+	}
+	return now != "" && strings.EqualFold(now, was)
+}
+
+// DeviceFlow signs in from another device, for machines without a local
+// browser. Prompt is called with the code the user must enter before polling
+// for their approval starts; it may be nil.
+type DeviceFlow struct {
+	Prompt func(*DeviceCode)
+}
+
+// Login starts a device login, shows its code through Prompt and waits for
+// the user to approve it.
+func (f DeviceFlow) Login(ctx context.Context, cfg *oauth2.Config) (*oauth2.Token, error) {
+	dc, err := StartDeviceLogin(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if f.Prompt != nil {
+		f.Prompt(dc)
+	}
+	return PollDeviceLogin(ctx, cfg, dc)
+}
+
+// FlowByName returns the flow for a login_flow setting: "loopback" or "device".
+// forceConsent, saved and account are passed on to LoopbackFlow.
+func FlowByName(name string, forceConsent bool, saved func() *oauth2.Token, account AccountFunc) (Flow, error) {
+	switch name {
+	case "loopback":
+		return LoopbackFlow{ForceConsent: forceConsent, Saved: saved, Account: account}, nil
+	case "device":
+		return DeviceFlow{}, nil
+	}
+	return nil, fmt.Errorf("unknown login flow %q (use loopback or device)", name)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -16,10 +17,10 @@ import (
 	"golang.org/x/oauth2"
 )
 
-// openBrowser opens the specified URL in the user's default web browser.
+// Open opens a URL or a local file with the user's default application.
 // Uses platform-specific commands: 'open' on macOS, 'rundll32' on Windows,
 // and 'xdg-open' on Linux/Unix systems.
-func openBrowser(u string) error {
+func Open(u string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -43,26 +44,108 @@ func randState() (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
+// anyLoopbackPort is the redirect LoopbackRedirect returns for clients that
+// accept a loopback redirect on any port.
+const anyLoopbackPort = "http://127.0.0.1"
+
+// oobRedirect is the out-of-band redirect URI older Desktop clients list.
+// Google still pairs it with loopback redirects on any port.
+const oobRedirect = "urn:ietf:wg:oauth:2.0:oob"
+
+// LoopbackRedirect picks the redirect for LoopbackLogin from a client's
+// registered redirect_uris. A loopback URI (localhost, 127.0.0.1 or [::1])
+// with a port is preferred and used as is, since a client that registers
+// one may allow only that port. Otherwise a loopback URI without a port,
+// the out-of-band URI or an empty list means any loopback port is allowed,
+// and "http://127.0.0.1" is returned so LoopbackLogin picks a free one.
+// It fails when no URI can be served by a local server.
+func LoopbackRedirect(uris []string) (string, error) {
+	anyPort := len(uris) == 0
+	for _, s := range uris {
+		if s == oobRedirect {
+			anyPort = true
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme != "http" || !isLoopbackHost(u.Hostname()) {
+			continue
+		}
+		if u.Port() != "" {
+			return s, nil
+		}
+		anyPort = true
+	}
+	if !anyPort {
+		return "", errors.New("no http://localhost redirect URI; create a new Desktop app OAuth client")
+	}
+	return anyLoopbackPort, nil
+}
+
+// isLoopbackHost reports whether host names this machine's loopback
+// interface.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// loopbackListener listens for the OAuth callback at redirect, as chosen by
+// LoopbackRedirect. It returns the redirect URI to send, with the port filled
+// in when any port is allowed, and the ServeMux pattern for the callback.
+func loopbackListener(redirect string) (net.Listener, string, string, error) {
+	u, err := url.Parse(redirect)
+	if err != nil || redirect == "" || u.Port() == "" {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return nil, "", "", err
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+		return ln, fmt.Sprintf("http://127.0.0.1:%d/callback", port), "/callback", nil
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		host = "127.0.0.1"
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, u.Port()))
+	if err != nil {
+		return nil, "", "", fmt.Errorf("the OAuth client only allows redirects to %s, but it can't be listened on: %w", redirect, err)
+	}
+	pattern := u.Path
+	if pattern == "" || pattern == "/" {
+		// Match only the root, not requests such as /favicon.ico.
+		pattern = "/{$}"
+	}
+	return ln, redirect, pattern, nil
+}
+
 // LoopbackLogin implements the OAuth2 authorization code flow using a local loopback server.
-// It starts a temporary HTTP server on 127.0.0.1 with a random available port,
-// opens the user's browser to Google's authorization page, waits for the callback
+// It starts a temporary HTTP server on 127.0.0.1, on the port of cfg.RedirectURL
+// if it names one and on a random available port otherwise, opens the user's browser to Google's authorization page, waits for the callback
 // with the authorization code, then exchanges the code for access and refresh tokens.
-// Times out after 2 minutes if the user doesn't complete authorization.
-func LoopbackLogin(cfg *oauth2.Config) (*oauth2.Token, error) {
+// The exchange is protected with PKCE (S256), so an intercepted code is useless
+// without the verifier that never leaves this process.
+// Offline access is always requested, but Google only issues a refresh token
+// when the user goes through the consent screen, which it skips for clients
+// that were already granted access unless forceConsent is set. Forcing it every
+// time is reliable but makes every re-login click through consent again, so
+// LoopbackFlow only forces it when no refresh token is held yet for the
+// account.
+// Times out after 2 minutes if the user doesn't complete authorization, and stops
+// early (shutting the server down) if ctx is cancelled.
+func LoopbackLogin(ctx context.Context, cfg *oauth2.Config, forceConsent bool) (*oauth2.Token, error) {
 	state, err := randState()
 	if err != nil {
 		return nil, err
 	}
 
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	ln, redirect, pattern, err := loopbackListener(cfg.RedirectURL)
 	if err != nil {
 		return nil, err
 	}
 	defer ln.Close()
 
-	port := ln.Addr().(*net.TCPAddr).Port
-	redirect := fmt.Sprintf("http://127.0.0.1:%d/callback", port)
-
 	cfgCopy := *cfg
 	cfgCopy.RedirectURL = redirect
 
@@ -72,14 +155,18 @@ func LoopbackLogin(cfg *oauth2.Config) (*oauth2.Token, error) {
 	mux := http.NewServeMux()
 	srv := &http.Server{Handler: mux}
 
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
 		if q.Get("state") != state {
 			http.Error(w, "state mismatch", http.StatusBadRequest)
 			errCh <- errors.New("oauth state mismatch")
 			return
 		}
-		if e := q.Get("error"); e != "" {
+		if e := q.Get("error"); e == "access_denied" {
+			_, _ = fmt.Fprintln(w, "Login cancelled. You can close this tab and return to the app.")
+			errCh <- ErrAccessDenied
+			return
+		} else if e != "" {
 			http.Error(w, e, http.StatusBadRequest)
 			errCh <- fmt.Errorf("oauth error: %s", e)
 			return
@@ -103,12 +190,17 @@ func LoopbackLogin(cfg *oauth2.Config) (*oauth2.Token, error) {
 		_ = srv.Shutdown(ctx)
 	}()
 
-	authURL := cfgCopy.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
-	if err := openBrowser(authURL); err != nil {
+	verifier := oauth2.GenerateVerifier()
+	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(verifier)}
+	if forceConsent {
+		opts = append(opts, oauth2.ApprovalForce)
+	}
+	authURL := cfgCopy.AuthCodeURL(state, opts...)
+	if err := Open(authURL); err != nil {
 		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	waitCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
 	var code string
@@ -116,11 +208,14 @@ func LoopbackLogin(cfg *oauth2.Config) (*oauth2.Token, error) {
 	case code = <-codeCh:
 	case e := <-errCh:
 		return nil, e
-	case <-ctx.Done():
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, errors.New("login timed out")
 	}
 
-	tok, err := cfgCopy.Exchange(context.Background(), code)
+	tok, err := cfgCopy.Exchange(ctx, code, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, err
 	}
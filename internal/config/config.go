@@ -0,0 +1,486 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gmail-tui/internal/store"
+)
+
+// Config holds the user-tunable settings. The effective configuration is built by
+// layering, from lowest to highest precedence: built-in defaults, the config file
+// at ~/.gmail-tui/config.json, environment variables, and command-line flags.
+type Config struct {
+	// Version is the config file format version, used to migrate older files.
+	Version int `json:"version"`
+	// CredentialsPath is the OAuth client file downloaded from Google Cloud Console.
+	// Relative paths are resolved against the working directory.
+	CredentialsPath string `json:"credentials_path"`
+	// LoginFlow is how the l key signs in: "loopback" opens a browser on this
+	// machine, "device" shows a code to enter on another device.
+	LoginFlow string `json:"login_flow"`
+	// ForceConsent shows Google's consent screen on every browser login. By
+	// default it is only shown when no refresh token is saved, which saves a
+	// click on re-login; a new refresh token is then only issued when the saved
+	// one was lost or rejected.
+	ForceConsent bool `json:"force_consent"`
+	// PageSize is how many messages are fetched per inbox page.
+	PageSize int64 `json:"page_size"`
+	// ConfirmSearchOver asks before showing a search Gmail estimates to match
+	// more messages than this; 0 never asks.
+	ConfirmSearchOver int64 `json:"confirm_search_over"`
+	// RefreshSeconds reloads the inbox automatically at this interval while it
+	// is on screen; 0 disables auto-refresh. The interval doubles, up to 30
+	// minutes, while the network is unreachable.
+	RefreshSeconds int `json:"refresh_seconds"`
+	// IdleLogoutMinutes logs out after this many minutes without a key press,
+	// for shared machines: the session token and the mail on screen are
+	// dropped from memory and the app returns to the login screen. 0, the
+	// default, never logs out. The saved token and the offline cache stay on
+	// disk unless IdleLogoutForgetToken is set, which deletes the saved token
+	// too so a restart also needs a new login.
+	IdleLogoutMinutes     int  `json:"idle_logout_minutes"`
+	IdleLogoutForgetToken bool `json:"idle_logout_forget_token"`
+	// TimeoutSeconds bounds each Gmail API command.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// NoAltScreen runs the TUI inline in the terminal instead of on the
+	// alternate screen, so the scrollback above it is kept.
+	NoAltScreen bool `json:"no_alt_screen"`
+	// SplitView starts the inbox with the preview pane enabled.
+	SplitView bool `json:"split_view"`
+	// PreviewDebounceMS is how long, in milliseconds, the cursor must rest on a
+	// row before split view fetches its preview.
+	PreviewDebounceMS int `json:"preview_debounce_ms"`
+	// PreviewMaxInFlight caps how many preview fetches may run at once.
+	PreviewMaxInFlight int `json:"preview_max_in_flight"`
+	// LabelsSidebar shows the labels, with their unread counts, in a column
+	// beside the inbox, where [ and ] switch between them.
+	LabelsSidebar bool `json:"labels_sidebar"`
+	// CompactRows shows each message in the inbox list on a single line,
+	// "date · sender · subject", so more of them fit on screen.
+	CompactRows bool `json:"compact_rows"`
+	// ExpandSnippets expands the selected inbox row in place, once the cursor
+	// has rested on it for ExpandDelayMS milliseconds, to show its whole
+	// snippet. It is a lighter alternative to split view, which takes
+	// precedence when on.
+	ExpandSnippets bool `json:"expand_snippets"`
+	ExpandDelayMS  int  `json:"expand_delay_ms"`
+	// ShowSnippets adds a line with each message's snippet to the inbox list.
+	ShowSnippets bool `json:"show_snippets"`
+	// SnippetLength truncates snippets to this many terminal columns, wide
+	// characters such as CJK and emoji taking two; 0 means no limit beyond the
+	// width of the list.
+	SnippetLength int `json:"snippet_length"`
+	// FullAddresses shows senders and recipients as the full "Name <address>"
+	// header instead of only their display names, so a misleading name can't
+	// hide where a message really came from.
+	FullAddresses bool `json:"full_addresses"`
+	// ExpandQuotes shows quoted reply text in full instead of folding it into a
+	// one-line summary that can be expanded per message.
+	ExpandQuotes bool `json:"expand_quotes"`
+	// ThreadNewestFirst shows the messages of a thread latest first instead of
+	// in reading order.
+	ThreadNewestFirst bool `json:"thread_newest_first"`
+	// HeaderDetail is how many headers the message view shows above the body:
+	// "minimal" shows only the subject and sender, "standard" adds the
+	// recipients, date and size, and "full" adds Cc, Reply-To and Message-ID.
+	HeaderDetail string `json:"header_detail"`
+	// MaxBodyBytes caps how much of a message body is shown before it is
+	// truncated; the full body can still be loaded on demand. 0 disables the cap.
+	MaxBodyBytes int `json:"max_body_bytes"`
+	// CacheMaxEntries caps how many entries, mostly viewed messages, are kept
+	// in the offline cache under ~/.gmail-tui/cache/; the least recently
+	// written are evicted at startup. 0 means no limit.
+	CacheMaxEntries int `json:"cache_max_entries"`
+	// CacheMaxAgeDays evicts cache entries not written for this many days at
+	// startup. 0 means no limit.
+	CacheMaxAgeDays int `json:"cache_max_age_days"`
+	// APIEndpoint is the base URL Gmail API requests are sent to, such as an
+	// API gateway in an egress-restricted network. Empty uses Google's.
+	APIEndpoint string `json:"api_endpoint,omitempty"`
+	// QuotaPerSecond is the Gmail API quota, in units per second, that requests
+	// are paced to. Lower it if you share the quota with other tools.
+	QuotaPerSecond int `json:"quota_per_second"`
+	// TriageAction is what the mark-and-next key does to the selected message:
+	// "archive", "read" or "trash".
+	TriageAction string `json:"triage_action"`
+	// UndoSendSeconds is how long a sent message waits before it is handed to
+	// Gmail, during which the send can be cancelled; 0 sends at once.
+	UndoSendSeconds int `json:"undo_send_seconds"`
+	// ComposeFormat is the format new messages are sent in: "plain" text,
+	// "html", or "alternative" for both, so clients that render HTML show it
+	// and text-only ones still work. It can be changed per message while
+	// composing.
+	ComposeFormat string `json:"compose_format"`
+	// NoSignature stops the Gmail signature from being appended to composed
+	// messages below a "-- " line. The signature needs the settings scope.
+	NoSignature bool `json:"no_signature"`
+	// ReplyQuoting is how a reply quotes the original message: "top" leaves
+	// room for the reply above the quote, "bottom" below it, and "none"
+	// starts with an empty body.
+	ReplyQuoting string `json:"reply_quoting"`
+	// CannedResponses are reply texts inserted with one key from the message
+	// view: 1 for the first, up to 9. They may use {{first_name}}, {{name}}
+	// and {{email}}, filled from the sender, and are quoted as ReplyQuoting
+	// says.
+	CannedResponses []string `json:"canned_responses,omitempty"`
+	// ReportAddress is where messages reported as phishing are forwarded, as
+	// an attachment, e.g. a security team's mailbox. Empty only moves them to
+	// spam.
+	ReportAddress string `json:"report_address"`
+	// Scopes are the Gmail scopes requested at login, by short name
+	// (readonly, modify, send, compose, settings, metadata, full) or URL.
+	// Only readonly is requested by default; features whose scope isn't
+	// granted are disabled until it is added. Changing them requires logging in
+	// again.
+	Scopes []string `json:"scopes"`
+	// MetadataOnly requests only the gmail.metadata scope, in place of Scopes,
+	// for accounts whose policy forbids reading message content. Messages show headers and labels
+	// but no body or snippet, and search, threads, sending,
+	// modifying messages, settings and import are unavailable. Switching modes
+	// requires logging in again so the token carries the right scopes.
+	MetadataOnly bool `json:"metadata_only"`
+	// DryRun makes archive, trash, mark-read and other label changes report
+	// what they would do without sending them to the API. It can also be
+	// toggled while running.
+	DryRun bool `json:"dry_run"`
+	// AccountColors maps account email addresses to the accent color used for
+	// them in the header, as an ANSI number ("33") or hex ("#ff8800"). Accounts
+	// not listed get a stable color picked from a built-in palette.
+	AccountColors map[string]string `json:"account_colors,omitempty"`
+	// Debug writes debug logs to ~/.gmail-tui/gtui.log.
+	Debug bool `json:"debug"`
+}
+
+// currentVersion is the config file format written by this build. Bump it and
+// add a step to migrate when a change needs more than a new field's default.
+const currentVersion = 1
+
+// Default returns the built-in settings used when nothing else is configured.
+func Default() Config {
+	return Config{
+		Version:            currentVersion,
+		CredentialsPath:    "credentials.json",
+		LoginFlow:          "loopback",
+		Scopes:             []string{"readonly"},
+		PageSize:           25,
+		ConfirmSearchOver:  10000,
+		TimeoutSeconds:     20,
+		ShowSnippets:       true,
+		SnippetLength:      80,
+		MaxBodyBytes:       1 << 20,
+		CacheMaxEntries:    500,
+		CacheMaxAgeDays:    30,
+		TriageAction:       "archive",
+		ReplyQuoting:       "top",
+		ComposeFormat:      "plain",
+		HeaderDetail:       "standard",
+		UndoSendSeconds:    5,
+		QuotaPerSecond:     250,
+		PreviewDebounceMS:  300,
+		ExpandDelayMS:      700,
+		PreviewMaxInFlight: 2,
+	}
+}
+
+// Path returns the location of the config file, ~/.gmail-tui/config.json.
+func Path() (string, error) {
+	dir, err := store.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// Load returns the defaults overlaid with the config file, if one exists,
+// and then with environment variables. Flags are applied separately by
+// RegisterFlags. Returns an error if the config file exists but is malformed;
+// out-of-range values are reported by Validate once flags have been applied.
+func Load() (Config, error) {
+	cfg, err := loadFile()
+	if err != nil {
+		return cfg, err
+	}
+	cfg.applyEnv()
+	return cfg, nil
+}
+
+// loadFile returns the defaults overlaid with the config file only, ignoring
+// the environment, so that it can be modified and written back.
+func loadFile() (Config, error) {
+	cfg := Default()
+	p, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, err
+	}
+	if err == nil {
+		// Files written before versioning have no version field, so it must not
+		// keep the default's value when absent.
+		cfg.Version = 0
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, fmt.Errorf("parse %s: %w", p, err)
+		}
+		cfg.migrate()
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = Default().Scopes
+	}
+	return cfg, nil
+}
+
+// migrate upgrades settings read from an older config file to currentVersion.
+// Fields added since then need no step: they keep Default's value because the
+// file is decoded on top of the defaults.
+func (c *Config) migrate() {
+	// Version 0 files predate the version field and differ from version 1
+	// only by fields they don't mention yet, so there is nothing to convert.
+	c.Version = currentVersion
+}
+
+// Validate reports the settings that are out of range, naming each by its
+// config file key. It is meant for the effective settings, after the config
+// file, environment and flags have all been applied.
+func (c Config) Validate() error {
+	var errs []error
+	bad := func(key string, value any, want string) {
+		errs = append(errs, fmt.Errorf("invalid %s %q: %s", key, fmt.Sprint(value), want))
+	}
+	if c.PageSize <= 0 || c.PageSize > 500 {
+		bad("page_size", c.PageSize, "must be between 1 and 500")
+	}
+	if c.ConfirmSearchOver < 0 {
+		bad("confirm_search_over", c.ConfirmSearchOver, "must not be negative")
+	}
+	if c.RefreshSeconds < 0 {
+		bad("refresh_seconds", c.RefreshSeconds, "must not be negative")
+	}
+	if c.IdleLogoutMinutes < 0 {
+		bad("idle_logout_minutes", c.IdleLogoutMinutes, "must not be negative")
+	}
+	if c.TimeoutSeconds <= 0 {
+		bad("timeout_seconds", c.TimeoutSeconds, "must be at least 1")
+	}
+	if c.SnippetLength < 0 {
+		bad("snippet_length", c.SnippetLength, "must not be negative")
+	}
+	if c.UndoSendSeconds < 0 {
+		bad("undo_send_seconds", c.UndoSendSeconds, "must not be negative")
+	}
+	if c.MaxBodyBytes < 0 {
+		bad("max_body_bytes", c.MaxBodyBytes, "must not be negative")
+	}
+	if c.CacheMaxEntries < 0 {
+		bad("cache_max_entries", c.CacheMaxEntries, "must not be negative")
+	}
+	if c.CacheMaxAgeDays < 0 {
+		bad("cache_max_age_days", c.CacheMaxAgeDays, "must not be negative")
+	}
+	if c.QuotaPerSecond <= 0 {
+		bad("quota_per_second", c.QuotaPerSecond, "must be at least 1")
+	}
+	if c.ExpandDelayMS < 0 {
+		bad("expand_delay_ms", c.ExpandDelayMS, "must not be negative")
+	}
+	if c.PreviewDebounceMS < 0 {
+		bad("preview_debounce_ms", c.PreviewDebounceMS, "must not be negative")
+	}
+	if c.PreviewMaxInFlight <= 0 {
+		bad("preview_max_in_flight", c.PreviewMaxInFlight, "must be at least 1")
+	}
+	if c.APIEndpoint != "" {
+		if u, err := url.Parse(c.APIEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("api_endpoint", c.APIEndpoint, "must be an http or https URL")
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			bad(key, value, "must be one of "+strings.Join(allowed, ", "))
+		}
+	}
+	oneOf("login_flow", c.LoginFlow, "loopback", "device")
+	oneOf("triage_action", c.TriageAction, "archive", "read", "trash")
+	oneOf("compose_format", c.ComposeFormat, "plain", "html", "alternative")
+	oneOf("reply_quoting", c.ReplyQuoting, "top", "bottom", "none")
+	oneOf("header_detail", c.HeaderDetail, "minimal", "standard", "full")
+	return errors.Join(errs...)
+}
+
+// Update applies fn to the settings stored in the config file and writes the
+// result back, creating the file if needed. The file is replaced atomically, so
+// a crash mid-write never leaves it truncated. Environment variables and flags
+// are not applied first, so temporary overrides are never persisted.
+func Update(fn func(*Config)) error {
+	cfg, err := loadFile()
+	if err != nil {
+		return err
+	}
+	fn(&cfg)
+	p, err := Path()
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFileAtomic(p, append(b, '\n'), 0600)
+}
+
+// applyEnv overrides settings from GMAIL_TUI_* environment variables.
+// Values that can't be parsed are ignored.
+func (c *Config) applyEnv() {
+	if v := os.Getenv("GMAIL_TUI_CREDENTIALS"); v != "" {
+		c.CredentialsPath = v
+	}
+	if v := os.Getenv("GMAIL_TUI_PAGE_SIZE"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			c.PageSize = n
+		}
+	}
+	if v := os.Getenv("GMAIL_TUI_TIMEOUT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			c.TimeoutSeconds = n
+		}
+	}
+	if os.Getenv("GMAIL_TUI_DEBUG") != "" {
+		c.Debug = true
+	}
+}
+
+// RegisterFlags binds command-line flags to the config's fields, using the
+// already-loaded values as defaults so that flags take the highest precedence.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.CredentialsPath, "credentials", c.CredentialsPath, "path to the OAuth client credentials.json")
+	fs.StringVar(&c.LoginFlow, "login-flow", c.LoginFlow, "how to sign in: loopback (local browser) or device (code on another device)")
+	fs.StringVar(&c.ComposeFormat, "compose-format", c.ComposeFormat, "format messages are sent in: plain, html or alternative")
+	fs.BoolVar(&c.NoSignature, "no-signature", c.NoSignature, "don't append the Gmail signature to composed messages")
+	fs.StringVar(&c.ReplyQuoting, "reply-quoting", c.ReplyQuoting, "how replies quote the original: top, bottom or none")
+	fs.StringVar(&c.HeaderDetail, "header-detail", c.HeaderDetail, "headers shown above a message: minimal, standard or full")
+	fs.StringVar(&c.APIEndpoint, "api-endpoint", c.APIEndpoint, "base URL to send Gmail API requests to instead of Google's")
+	fs.StringVar(&c.ReportAddress, "report-address", c.ReportAddress, "address to forward messages reported as phishing to")
+	fs.BoolVar(&c.ForceConsent, "force-consent", c.ForceConsent, "show Google's consent screen on every browser login")
+	fs.Int64Var(&c.PageSize, "page-size", c.PageSize, "messages fetched per inbox page")
+	fs.IntVar(&c.TimeoutSeconds, "timeout", c.TimeoutSeconds, "timeout in seconds for each Gmail API command")
+	fs.IntVar(&c.IdleLogoutMinutes, "idle-logout", c.IdleLogoutMinutes, "log out after this many idle minutes; 0 never does")
+	fs.IntVar(&c.UndoSendSeconds, "undo-send", c.UndoSendSeconds, "seconds a sent message can still be cancelled; 0 sends at once")
+	fs.BoolVar(&c.NoAltScreen, "no-altscreen", c.NoAltScreen, "run inline in the terminal instead of on the alternate screen")
+	fs.BoolVar(&c.SplitView, "split", c.SplitView, "start with the split preview pane enabled")
+	fs.BoolVar(&c.LabelsSidebar, "sidebar", c.LabelsSidebar, "show the labels sidebar beside the inbox")
+	fs.BoolVar(&c.ExpandSnippets, "expand-snippets", c.ExpandSnippets, "expand the selected inbox row to show its whole snippet after a pause")
+	fs.BoolVar(&c.CompactRows, "compact", c.CompactRows, "show inbox messages on a single line each")
+	fs.BoolVar(&c.FullAddresses, "full-addresses", c.FullAddresses, "show full sender and recipient addresses instead of display names")
+	fs.Func("scopes", "comma-separated Gmail scopes to request (readonly, modify, send, compose, settings, metadata, full)", func(v string) error {
+		c.Scopes = nil
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				c.Scopes = append(c.Scopes, s)
+			}
+		}
+		return nil
+	})
+	fs.BoolVar(&c.MetadataOnly, "metadata-only", c.MetadataOnly, "use the narrower gmail.metadata scope (headers only, no bodies)")
+	fs.BoolVar(&c.DryRun, "dry-run", c.DryRun, "report label changes, archiving and trashing without applying them")
+	fs.BoolVar(&c.Debug, "debug", c.Debug, "write debug logs to ~/.gmail-tui/gtui.log")
+}
+
+// Print writes every effective setting to w, one per line with the values
+// aligned, followed by the resolved locations of the files the app reads and
+// writes. Lists and maps are written as JSON.
+func (c Config) Print(w io.Writer) error {
+	cfgPath, err := Path()
+	if err != nil {
+		return err
+	}
+	creds, err := filepath.Abs(c.CredentialsPath)
+	if err != nil {
+		return err
+	}
+	ts, err := store.NewTokenStore()
+	if err != nil {
+		return err
+	}
+	colors, err := json.Marshal(c.AccountColors)
+	if err != nil {
+		return err
+	}
+	canned, err := json.Marshal(c.CannedResponses)
+	if err != nil {
+		return err
+	}
+	rows := [][2]string{
+		{"version", strconv.Itoa(c.Version)},
+		{"credentials_path", c.CredentialsPath},
+		{"login_flow", c.LoginFlow},
+		{"force_consent", strconv.FormatBool(c.ForceConsent)},
+		{"page_size", strconv.FormatInt(c.PageSize, 10)},
+		{"confirm_search_over", strconv.FormatInt(c.ConfirmSearchOver, 10)},
+		{"refresh_seconds", strconv.Itoa(c.RefreshSeconds)},
+		{"idle_logout_minutes", strconv.Itoa(c.IdleLogoutMinutes)},
+		{"idle_logout_forget_token", strconv.FormatBool(c.IdleLogoutForgetToken)},
+		{"timeout_seconds", strconv.Itoa(c.TimeoutSeconds)},
+		{"no_alt_screen", strconv.FormatBool(c.NoAltScreen)},
+		{"split_view", strconv.FormatBool(c.SplitView)},
+		{"preview_debounce_ms", strconv.Itoa(c.PreviewDebounceMS)},
+		{"preview_max_in_flight", strconv.Itoa(c.PreviewMaxInFlight)},
+		{"labels_sidebar", strconv.FormatBool(c.LabelsSidebar)},
+		{"compact_rows", strconv.FormatBool(c.CompactRows)},
+		{"expand_snippets", strconv.FormatBool(c.ExpandSnippets)},
+		{"expand_delay_ms", strconv.Itoa(c.ExpandDelayMS)},
+		{"show_snippets", strconv.FormatBool(c.ShowSnippets)},
+		{"full_addresses", strconv.FormatBool(c.FullAddresses)},
+		{"snippet_length", strconv.Itoa(c.SnippetLength)},
+		{"expand_quotes", strconv.FormatBool(c.ExpandQuotes)},
+		{"thread_newest_first", strconv.FormatBool(c.ThreadNewestFirst)},
+		{"header_detail", c.HeaderDetail},
+		{"max_body_bytes", strconv.Itoa(c.MaxBodyBytes)},
+		{"triage_action", c.TriageAction},
+		{"undo_send_seconds", strconv.Itoa(c.UndoSendSeconds)},
+		{"compose_format", c.ComposeFormat},
+		{"no_signature", strconv.FormatBool(c.NoSignature)},
+		{"reply_quoting", c.ReplyQuoting},
+		{"canned_responses", string(canned)},
+		{"report_address", c.ReportAddress},
+		{"cache_max_entries", strconv.Itoa(c.CacheMaxEntries)},
+		{"cache_max_age_days", strconv.Itoa(c.CacheMaxAgeDays)},
+		{"api_endpoint", c.APIEndpoint},
+		{"quota_per_second", strconv.Itoa(c.QuotaPerSecond)},
+		{"scopes", strings.Join(c.Scopes, ",")},
+		{"metadata_only", strconv.FormatBool(c.MetadataOnly)},
+		{"dry_run", strconv.FormatBool(c.DryRun)},
+		{"account_colors", string(colors)},
+		{"debug", strconv.FormatBool(c.Debug)},
+		{"", ""},
+		{"config file", cfgPath},
+		{"credentials file", creds},
+		{"token file", ts.Path()},
+	}
+	width := 0
+	for _, r := range rows {
+		width = max(width, len(r[0]))
+	}
+	for _, r := range rows {
+		if r[0] == "" {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%-*s %s\n", width, r[0], r[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
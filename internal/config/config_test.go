@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default().Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*Config)
+		key  string
+	}{
+		{"zero timeout", func(c *Config) { c.TimeoutSeconds = 0 }, "timeout_seconds"},
+		{"negative page size", func(c *Config) { c.PageSize = -5 }, "page_size"},
+		{"page size too large", func(c *Config) { c.PageSize = 501 }, "page_size"},
+		{"unknown compose format", func(c *Config) { c.ComposeFormat = "bogus" }, "compose_format"},
+		{"unknown header detail", func(c *Config) { c.HeaderDetail = "x" }, "header_detail"},
+		{"negative undo send", func(c *Config) { c.UndoSendSeconds = -1 }, "undo_send_seconds"},
+		{"negative idle logout", func(c *Config) { c.IdleLogoutMinutes = -1 }, "idle_logout_minutes"},
+		{"non-http endpoint", func(c *Config) { c.APIEndpoint = "ftp://example.com" }, "api_endpoint"},
+		{"endpoint without host", func(c *Config) { c.APIEndpoint = "https://" }, "api_endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.set(&c)
+			err := c.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("Validate() = %v, want an error naming %s", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	c := Default()
+	c.TimeoutSeconds = 0
+	c.LoginFlow = "carrier-pigeon"
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want an error")
+	}
+	for _, key := range []string{"timeout_seconds", "login_flow"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Validate() = %v, want it to name %s", err, key)
+		}
+	}
+}
+
+func TestPrintListsEveryFieldAligned(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	c := Default()
+	c.AccountColors = map[string]string{"ana@example.com": "33"}
+	c.CannedResponses = []string{"Thanks, {{first_name}}!"}
+	var b bytes.Buffer
+	if err := c.Print(&b); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+	out := b.String()
+
+	typ := reflect.TypeOf(c)
+	for i := range typ.NumField() {
+		key, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if !strings.Contains(out, "\n"+key+" ") && !strings.HasPrefix(out, key+" ") {
+			t.Errorf("Print() doesn't list %s:\n%s", key, out)
+		}
+	}
+	for _, want := range []string{`{"ana@example.com":"33"}`, `["Thanks, {{first_name}}!"]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Print() doesn't contain %s:\n%s", want, out)
+		}
+	}
+
+	// Values start one space after the longest key, idle_logout_forget_token.
+	column := len("idle_logout_forget_token") + 1
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		if len(line) < column || line[column-1] != ' ' || (len(line) > column && line[column] == ' ') {
+			t.Errorf("line %q doesn't have its value at column %d", line, column)
+		}
+	}
+}
+
+func TestScopesFlagTrimsEntries(t *testing.T) {
+	c := Default()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	c.RegisterFlags(fs)
+	if err := fs.Parse([]string{"--scopes", "readonly, send ,,modify"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if want := []string{"readonly", "send", "modify"}; !slices.Equal(c.Scopes, want) {
+		t.Errorf("Scopes = %q, want %q", c.Scopes, want)
+	}
+}
+
+func TestUpdateWritesAtomically(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := Update(func(c *Config) { c.PageSize = 42 }); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.PageSize != 42 {
+		t.Errorf("PageSize = %d, want the updated 42", cfg.PageSize)
+	}
+	p, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".config.json.") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file mode = %v, want 0600", perm)
+	}
+}
@@ -0,0 +1,168 @@
+package gmailx
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+type Attachment struct {
+	ID       string `json:"id"`
+	Filename string `json:"filename"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+}
+
+// collectAttachments walks a message's MIME tree and returns every part that
+// carries a filename, which is how Gmail marks attachments.
+func collectAttachments(part *gmail.MessagePart) []Attachment {
+	if part == nil {
+		return nil
+	}
+	var out []Attachment
+	if part.Filename != "" {
+		a := Attachment{Filename: part.Filename, MimeType: part.MimeType}
+		if part.Body != nil {
+			a.ID = part.Body.AttachmentId
+			a.Size = part.Body.Size
+		}
+		out = append(out, a)
+	}
+	for _, p := range part.Parts {
+		out = append(out, collectAttachments(p)...)
+	}
+	return out
+}
+
+// GetAttachment downloads the content of one of a message's attachments.
+func (c *Client) GetAttachment(ctx context.Context, msgID string, a Attachment) ([]byte, error) {
+	if a.ID == "" {
+		return nil, errors.New("attachment has no ID")
+	}
+	body, err := c.svc.Users.Messages.Attachments.Get("me", msgID, a.ID).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	s, err := decodeB64URL(body.Data)
+	return []byte(s), err
+}
+
+// executableExts are file extensions that run code when opened with the
+// system's default application.
+var executableExts = map[string]bool{
+	"exe": true, "msi": true, "bat": true, "cmd": true, "com": true, "scr": true,
+	"ps1": true, "vbs": true, "js": true, "jar": true, "sh": true, "command": true,
+	"app": true, "pkg": true, "dmg": true, "apk": true, "lnk": true, "reg": true,
+}
+
+// IsExecutable reports whether opening the attachment could run a program,
+// judged by its extension and MIME type.
+func IsExecutable(a Attachment) bool {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(a.Filename)), ".")
+	if executableExts[ext] {
+		return true
+	}
+	switch strings.ToLower(a.MimeType) {
+	case "application/x-msdownload", "application/x-msdos-program", "application/x-executable",
+		"application/x-sh", "application/java-archive", "application/vnd.microsoft.portable-executable":
+		return true
+	}
+	return false
+}
+
+// attachmentKinds maps the friendly attachment filter names to the file
+// extensions Gmail's filename: operator should match for them.
+var attachmentKinds = map[string][]string{
+	"pdf":          {"pdf"},
+	"image":        {"jpg", "jpeg", "png", "gif", "heic", "webp"},
+	"document":     {"doc", "docx", "odt", "rtf", "txt"},
+	"spreadsheet":  {"xls", "xlsx", "ods", "csv"},
+	"presentation": {"ppt", "pptx", "odp", "key"},
+	"archive":      {"zip", "gz", "tgz", "rar", "7z"},
+	"calendar":     {"ics"},
+}
+
+// AttachmentKind classifies an attachment as one of the friendly kinds used by
+// AttachmentQuery, by extension first and MIME type second. Returns "" when it
+// doesn't fit any of them.
+func AttachmentKind(a Attachment) string {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(a.Filename)), ".")
+	for kind, exts := range attachmentKinds {
+		for _, e := range exts {
+			if e == ext {
+				return kind
+			}
+		}
+	}
+	mt := strings.ToLower(a.MimeType)
+	switch {
+	case mt == "application/pdf":
+		return "pdf"
+	case strings.HasPrefix(mt, "image/"):
+		return "image"
+	case mt == "text/calendar":
+		return "calendar"
+	}
+	return ""
+}
+
+// AttachmentQuery returns the Gmail search fragment matching messages with an
+// attachment of the given kind, e.g. "pdf" gives "has:attachment filename:pdf".
+// An empty or "any" kind matches any attachment. ok is false for unknown kinds.
+func AttachmentQuery(kind string) (q string, ok bool) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" || kind == "any" {
+		return "has:attachment", true
+	}
+	exts, ok := attachmentKinds[kind]
+	if !ok {
+		return "", false
+	}
+	if len(exts) == 1 {
+		return "has:attachment filename:" + exts[0], true
+	}
+	terms := make([]string, len(exts))
+	for i, e := range exts {
+		terms[i] = "filename:" + e
+	}
+	return "has:attachment {" + strings.Join(terms, " ") + "}", true
+}
+
+// ExpandQuery rewrites the app's friendly "att:<kind>" terms (for example
+// "att:pdf from:boss newer_than:30d") into Gmail search syntax. Unknown kinds
+// are left as typed so Gmail reports them rather than silently matching nothing.
+func ExpandQuery(q string) string {
+	fields := strings.Fields(q)
+	for i, f := range fields {
+		kind, found := strings.CutPrefix(strings.ToLower(f), "att:")
+		if !found {
+			continue
+		}
+		if frag, ok := AttachmentQuery(kind); ok {
+			fields[i] = frag
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// ToggleTerm adds term to the query, or removes it if the query already
+// contains it as a whole word.
+func ToggleTerm(q, term string) string {
+	fields := strings.Fields(q)
+	out := fields[:0]
+	found := false
+	for _, f := range fields {
+		if strings.EqualFold(f, term) {
+			found = true
+			continue
+		}
+		out = append(out, f)
+	}
+	if !found {
+		out = append(out, term)
+	}
+	return strings.Join(out, " ")
+}
@@ -0,0 +1,87 @@
+package gmailx
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// Errors returned by Client methods are matched against these with errors.Is.
+// The original *googleapi.Error or *oauth2.RetrieveError stays reachable with
+// errors.As.
+var (
+	// ErrNotFound is returned for a message, thread or label that doesn't
+	// exist, e.g. because it was deleted after it was listed.
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is returned when Gmail throttled the request.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrInsufficientScope is returned when the token lacks the scope the
+	// request needs.
+	ErrInsufficientScope = errors.New("insufficient scope")
+	// ErrUnauthenticated is returned when the credentials were rejected.
+	// IsReauthRequired tells whether the user must log in again.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// rateLimitReasons are the 403 error reasons Gmail uses for throttling.
+var rateLimitReasons = []string{"rateLimitExceeded", "userRateLimitExceeded"}
+
+// apiError is an API failure classified as one of the Err* kinds. It reads
+// as the original error.
+type apiError struct {
+	kind error
+	err  error
+}
+
+func (e *apiError) Error() string { return e.err.Error() }
+
+func (e *apiError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// wrapAPIError classifies an error returned by the API client so callers can
+// match it with errors.Is. Errors of no known kind, and nil, are returned as is.
+func wrapAPIError(err error) error {
+	if kind := errorKind(err); kind != nil {
+		return &apiError{kind: kind, err: err}
+	}
+	return err
+}
+
+// errorKind maps err to one of the Err* kinds, or nil.
+func errorKind(err error) error {
+	var rErr *oauth2.RetrieveError
+	if errors.As(err, &rErr) {
+		if rErr.ErrorCode == "invalid_grant" || (rErr.Response != nil && rErr.Response.StatusCode == http.StatusUnauthorized) {
+			return ErrUnauthenticated
+		}
+		return nil
+	}
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	switch apiErr.Code {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnauthorized:
+		return ErrUnauthenticated
+	case http.StatusForbidden:
+		if strings.Contains(apiErr.Header.Get("WWW-Authenticate"), "insufficient_scope") {
+			return ErrInsufficientScope
+		}
+		for _, item := range apiErr.Errors {
+			switch {
+			case item.Reason == "insufficientPermissions":
+				return ErrInsufficientScope
+			case slices.Contains(rateLimitReasons, item.Reason):
+				return ErrRateLimited
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package gmailx_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"google.golang.org/api/googleapi"
+)
+
+// failingClient returns a client whose every request fails with status, the
+// given error reason and, if set, a WWW-Authenticate header.
+func failingClient(t *testing.T, status int, reason, authenticate string) *gmailx.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authenticate != "" {
+			w.Header().Set("WWW-Authenticate", authenticate)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": map[string]any{
+				"code":    status,
+				"message": http.StatusText(status),
+				"errors":  []map[string]any{{"reason": reason, "message": http.StatusText(status)}},
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	c, err := gmailx.New(context.Background(), nil, nil, gmailx.WithEndpoint(srv.URL), gmailx.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestAPIErrorReasonsAreClassified(t *testing.T) {
+	kinds := []error{gmailx.ErrNotFound, gmailx.ErrRateLimited, gmailx.ErrInsufficientScope, gmailx.ErrUnauthenticated}
+	tests := []struct {
+		name         string
+		status       int
+		reason       string
+		authenticate string
+		want         error
+	}{
+		{"insufficient permissions", http.StatusForbidden, "insufficientPermissions", "", gmailx.ErrInsufficientScope},
+		{"insufficient scope header", http.StatusForbidden, "forbidden", `Bearer realm="https://accounts.google.com/", error="insufficient_scope", scope="https://www.googleapis.com/auth/gmail.modify"`, gmailx.ErrInsufficientScope},
+		{"rate limit exceeded", http.StatusForbidden, "rateLimitExceeded", "", gmailx.ErrRateLimited},
+		{"user rate limit exceeded", http.StatusForbidden, "userRateLimitExceeded", "", gmailx.ErrRateLimited},
+		{"other forbidden", http.StatusForbidden, "domainPolicy", "", nil},
+		{"server error", http.StatusInternalServerError, "backendError", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := failingClient(t, tt.status, tt.reason, tt.authenticate)
+
+			_, err := c.GetDetail(context.Background(), "m1", 0)
+			if err == nil {
+				t.Fatal("GetDetail() error = nil, want an API error")
+			}
+			for _, kind := range kinds {
+				if got, want := errors.Is(err, kind), kind == tt.want; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, kind, got, want)
+				}
+			}
+			var apiErr *googleapi.Error
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.status {
+				t.Errorf("errors.As(%v) found %v, want the original %d error", err, apiErr, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package gmailx
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// exportCSP stops an exported file from loading anything remote when opened,
+// so tracking images and scripts in the original HTML stay inert. Only the
+// embedded data: images and inline styles are allowed.
+const exportCSP = "default-src 'none'; img-src data:; style-src 'unsafe-inline'"
+
+// findPart returns the first part in the MIME tree whose type starts with mimeType
+// and that has an inline body.
+func findPart(part *gmail.MessagePart, mimeType string) *gmail.MessagePart {
+	if part == nil {
+		return nil
+	}
+	if strings.HasPrefix(strings.ToLower(part.MimeType), mimeType) && part.Body != nil && part.Body.Data != "" {
+		return part
+	}
+	for _, p := range part.Parts {
+		if f := findPart(p, mimeType); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// inlineImages collects the image parts that have a Content-ID, which HTML
+// bodies reference as cid: URLs.
+func inlineImages(part *gmail.MessagePart, out map[string]*gmail.MessagePart) {
+	if part == nil {
+		return
+	}
+	if cid := headerVal(part.Headers, "Content-ID"); cid != "" && strings.HasPrefix(strings.ToLower(part.MimeType), "image/") {
+		out[strings.Trim(cid, "<>")] = part
+	}
+	for _, p := range part.Parts {
+		inlineImages(p, out)
+	}
+}
+
+// partData returns the decoded content of a part, downloading it separately
+// when Gmail stored it as an attachment rather than inline.
+func (c *Client) partData(ctx context.Context, msgID string, part *gmail.MessagePart) ([]byte, error) {
+	data := part.Body.Data
+	if data == "" && part.Body.AttachmentId != "" {
+		a, err := c.svc.Users.Messages.Attachments.Get("me", msgID, part.Body.AttachmentId).Context(ctx).Do()
+		if err != nil {
+			return nil, wrapAPIError(err)
+		}
+		data = a.Data
+	}
+	s, err := decodeB64URL(data)
+	return []byte(s), err
+}
+
+// ExportHTML renders a message as a standalone HTML document: a table of its
+// main headers followed by its HTML body, or its plain-text body in a <pre> when
+// it has no HTML part. Inline images referenced by cid: URLs are embedded as
+// data: URIs so the file is self-contained; images that fail to download are
+// left as they are. Returns the document and the message subject.
+func (c *Client) ExportHTML(ctx context.Context, id string) ([]byte, string, error) {
+	msg, err := c.svc.Users.Messages.Get("me", id).Format("full").Context(ctx).Do()
+	if err != nil {
+		return nil, "", wrapAPIError(err)
+	}
+	d := detailFromMessage(msg)
+
+	var body string
+	if p := findPart(msg.Payload, "text/html"); p != nil {
+		raw, err := decodeB64URL(p.Body.Data)
+		if err != nil {
+			return nil, "", err
+		}
+		body = toUTF8(raw, partCharset(p))
+
+		images := map[string]*gmail.MessagePart{}
+		inlineImages(msg.Payload, images)
+		for cid, img := range images {
+			if !strings.Contains(body, "cid:"+cid) {
+				continue
+			}
+			data, err := c.partData(ctx, id, img)
+			if err != nil {
+				continue
+			}
+			uri := "data:" + img.MimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+			body = strings.ReplaceAll(body, "cid:"+cid, uri)
+		}
+	} else {
+		text, _ := extractBody(msg.Payload)
+		body = "<pre style=\"white-space: pre-wrap\">" + html.EscapeString(text) + "</pre>"
+	}
+
+	var b bytes.Buffer
+	b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<meta http-equiv=\"Content-Security-Policy\" content=\"%s\">\n", exportCSP)
+	fmt.Fprintf(&b, "<title>%s</title></head><body>\n", html.EscapeString(d.Subject))
+	b.WriteString("<table style=\"font-family: sans-serif; margin-bottom: 1em\">\n")
+	for _, h := range [][2]string{{"Subject", d.Subject}, {"From", d.From}, {"To", d.To}, {"Date", d.Date}} {
+		if h[1] != "" {
+			fmt.Fprintf(&b, "<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", h[0], html.EscapeString(h[1]))
+		}
+	}
+	b.WriteString("</table>\n<hr>\n")
+	b.WriteString(body)
+	b.WriteString("\n</body></html>\n")
+	return b.Bytes(), d.Subject, nil
+}
+
+// GetRaw returns a message exactly as Gmail stores it, as RFC 822 bytes with
+// every header and MIME part, for forwarding it as an attachment.
+func (c *Client) GetRaw(ctx context.Context, id string) ([]byte, error) {
+	msg, err := c.svc.Users.Messages.Get("me", id).Format("raw").Fields("raw").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	raw, err := decodeB64URL(msg.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode raw message: %w", err)
+	}
+	return []byte(raw), nil
+}
@@ -3,47 +3,239 @@ package gmailx
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/text/encoding/htmlindex"
 	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
 type Client struct {
 	svc *gmail.Service
+	// dryRun makes the calls that change messages log what they would have
+	// done and return without sending anything to the API.
+	dryRun bool
+}
+
+// Option customizes a client created by New.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	endpoint   string
+	httpClient *http.Client
+	limiter    *QuotaLimiter
+	dryRun     bool
+	events     chan<- RefreshEvent
+}
+
+// WithEndpoint sends the client's requests to url, such as an API gateway in
+// an egress-restricted network or a fake server, instead of
+// https://gmail.googleapis.com/. An empty url keeps the default.
+func WithEndpoint(url string) Option {
+	return func(o *clientOptions) {
+		if url != "" && !strings.HasSuffix(url, "/") {
+			url += "/"
+		}
+		o.endpoint = url
+	}
+}
+
+// WithHTTPClient sends the client's requests through hc without
+// authentication, instead of an OAuth client built from the configuration and
+// token, which may then be nil. It is meant for pointing the app at a fake
+// server, such as the one in the gmailtest package.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(o *clientOptions) { o.httpClient = hc }
+}
+
+// WithQuotaLimiter paces the client's requests with l, so that it shares its
+// quota with the other clients given l. Without it the client gets a limiter
+// of its own at DefaultQuotaPerSecond.
+func WithQuotaLimiter(l *QuotaLimiter) Option {
+	return func(o *clientOptions) { o.limiter = l }
+}
+
+// WithDryRun turns on dry-run mode when on is set: label changes, archiving
+// and trashing are logged instead of sent.
+func WithDryRun(on bool) Option {
+	return func(o *clientOptions) { o.dryRun = on }
+}
+
+// WithRefreshEvents reports token refreshes that are being retried on ch, for
+// showing the user that the client is reconnecting. Events are dropped when
+// ch is full.
+func WithRefreshEvents(ch chan<- RefreshEvent) Option {
+	return func(o *clientOptions) { o.events = ch }
 }
 
 // New creates a new Gmail API client using the provided OAuth2 configuration and token.
 // The client is configured with automatic token refresh and ready to make Gmail API calls.
+// Every request is logged through slog for debugging, and paced by the client's
+// quota limiter so bursts of calls stay under Gmail's per-user rate limit.
 // Returns an error if the Gmail service cannot be initialized.
-func New(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token) (*Client, error) {
-	httpClient := oauth2.NewClient(ctx, cfg.TokenSource(ctx, tok))
-	svc, err := gmail.NewService(ctx, option.WithHTTPClient(httpClient))
+func New(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token, opts ...Option) (*Client, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var httpClient *http.Client
+	if o.httpClient != nil {
+		hc := *o.httpClient
+		httpClient = &hc
+	} else {
+		httpClient = oauth2.NewClient(ctx, retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, tok), events: o.events})
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if o.limiter == nil {
+		o.limiter = NewQuotaLimiter(DefaultQuotaPerSecond)
+	}
+	httpClient.Transport = pacingTransport{base: loggingTransport{base: base}, limiter: o.limiter}
+	svcOpts := []option.ClientOption{option.WithHTTPClient(httpClient)}
+	if o.endpoint != "" {
+		svcOpts = append(svcOpts, option.WithEndpoint(o.endpoint))
+	}
+	svc, err := gmail.NewService(ctx, svcOpts...)
 	if err != nil {
 		return nil, err
 	}
-	return &Client{svc: svc}, nil
+	return &Client{svc: svc, dryRun: o.dryRun}, nil
 }
 
+// rowFields is the partial-response field set requested for each inbox row.
+// Extend it here when a feature needs more of the message resource in the list.
+const rowFields googleapi.Field = "id,threadId,snippet,labelIds,payload/headers"
+
+// EmailRow, EmailDetail, Header and Label are part of the app's external
+// contract: they are written to the on-disk cache and printed by JSON output, so
+// their JSON field names must stay stable. The names follow the Gmail API's
+// camelCase, which also lets caches written before the tags existed decode,
+// since encoding/json matches field names case-insensitively.
+
 type EmailRow struct {
-	ID      string
-	Subject string
-	From    string
-	Date    string
-	Snippet string
+	ID      string `json:"id"`
+	Subject string `json:"subject"`
+	From    string `json:"from"`
+	// To is the recipients header, shown instead of From for sent mail.
+	To      string `json:"to,omitempty"`
+	Date    string `json:"date"`
+	Snippet string `json:"snippet"`
+	Unread  bool   `json:"unread"`
+	// Time is Date parsed, zero when the header is missing or malformed.
+	// It is encoded in RFC 3339.
+	Time time.Time `json:"time,omitzero"`
+	// FromName and FromAddress are the parts of From, e.g. "Jane Doe" and
+	// "jane@example.com". FromAddress holds the raw header if it can't be parsed.
+	FromName    string `json:"fromName,omitempty"`
+	FromAddress string `json:"fromAddress"`
+	// ThreadID is the ID of the message's conversation, and ThreadSize how
+	// many messages it has; 0 until ThreadSizes has been asked.
+	ThreadID   string `json:"threadId,omitempty"`
+	ThreadSize int    `json:"threadSize,omitempty"`
+}
+
+// NewEmailRow creates a row from raw header values, filling in the parsed time
+// and sender address parts.
+func NewEmailRow(id, subject, from, date, snippet string, unread bool) EmailRow {
+	r := EmailRow{ID: id, Subject: subject, From: from, Date: date, Snippet: snippet, Unread: unread}
+	r.Time, _ = parseDate(date)
+	r.FromName, r.FromAddress = splitAddress(from)
+	return r
 }
 
 type EmailDetail struct {
-	ID      string
-	Subject string
-	From    string
-	To      string
-	Date    string
-	Snippet string
-	Body    string
+	ID       string `json:"id"`
+	ThreadID string `json:"threadId"`
+	Subject  string `json:"subject"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Cc       string `json:"cc,omitempty"`
+	Date     string `json:"date"`
+	Snippet  string `json:"snippet"`
+	Body     string `json:"body"`
+	Charset  string `json:"charset,omitempty"`
+	// Time, FromName and FromAddress are parsed from Date and From as in EmailRow.
+	Time        time.Time `json:"time,omitzero"`
+	FromName    string    `json:"fromName,omitempty"`
+	FromAddress string    `json:"fromAddress"`
+	// SizeEstimate is Gmail's estimate of the whole message size in bytes.
+	SizeEstimate int64 `json:"sizeEstimate"`
+	// Truncated is the number of body bytes left out because the body
+	// exceeded the cap passed to GetDetail; 0 when the full body is present.
+	Truncated int `json:"truncated,omitempty"`
+	// TrackersStripped counts tracking pixels and hidden elements removed
+	// when the body was converted from HTML.
+	TrackersStripped int `json:"trackersStripped,omitempty"`
+	// LabelIDs are the IDs of the labels on the message, such as INBOX,
+	// UNREAD or MUTED.
+	LabelIDs []string `json:"labelIds,omitempty"`
+	// Headers holds every header of the message in order, for debugging
+	// deliverability (Received chains, SPF/DKIM results, and so on).
+	Headers []Header `json:"headers,omitempty"`
+	// Attachments lists the message's attachments; only known for messages
+	// fetched with GetDetail.
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// HTMLFallback is set when Body is the HTML part with its tags stripped,
+	// because converting it properly found no readable text.
+	HTMLFallback bool `json:"htmlFallback,omitempty"`
+	// PlainPart and HTMLPart are the message's first text/plain and text/html
+	// parts as sent, converted to UTF-8 but otherwise untouched, for inspecting
+	// the source. Only set by GetDetail; they aren't cached. Like Body they are
+	// capped, and PlainTruncated and HTMLTruncated count the bytes left out.
+	PlainPart      string `json:"-"`
+	HTMLPart       string `json:"-"`
+	PlainTruncated int    `json:"-"`
+	HTMLTruncated  int    `json:"-"`
+}
+
+// NewEmailDetail creates a detail from raw header values and a body, filling in
+// the parsed time and sender address parts.
+func NewEmailDetail(id, threadID, subject, from, to, date, snippet, body string) *EmailDetail {
+	d := &EmailDetail{ID: id, ThreadID: threadID, Subject: subject, From: from, To: to, Date: date, Snippet: snippet, Body: body}
+	d.Time, _ = parseDate(date)
+	d.FromName, d.FromAddress = splitAddress(from)
+	return d
+}
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Header returns the value of the first header with the given name, ignoring
+// case, or "" if the message has none.
+func (d *EmailDetail) Header(name string) string {
+	for _, h := range d.Headers {
+		if strings.EqualFold(h.Name, name) {
+			return h.Value
+		}
+	}
+	return ""
+}
+
+// splitAddress splits an RFC 5322 address such as `"Jane Doe" <jane@example.com>`
+// into its display name and address. If it can't be parsed, the trimmed input
+// is returned as the address.
+func splitAddress(s string) (name, addr string) {
+	a, err := mail.ParseAddress(s)
+	if err != nil {
+		return "", strings.TrimSpace(s)
+	}
+	return a.Name, a.Address
 }
 
 // headerVal extracts the value of a specific email header by name (case-insensitive).
@@ -52,61 +244,162 @@ type EmailDetail struct {
 func headerVal(headers []*gmail.MessagePartHeader, name string) string {
 	ln := strings.ToLower(name)
 	for _, h := range headers {
-		if strings.ToLower(h.Name) == ln {
+		if h != nil && strings.ToLower(h.Name) == ln {
 			return h.Value
 		}
 	}
 	return ""
 }
 
-// ListInbox fetches up to 'max' email messages from the user's Gmail inbox.
-// If a query string is provided, it applies Gmail search syntax filtering
-// (e.g., "from:someone newer_than:7d", "label:SENT"). Returns basic metadata including
-// subject, sender, date, and snippet. Silently skips emails that fail to fetch.
-// If the query contains a label filter, it won't apply the default INBOX filter.
-func (c *Client) ListInbox(ctx context.Context, max int64, query string) ([]EmailRow, error) {
-	call := c.svc.Users.Messages.List("me").MaxResults(max)
+// messageHeaders returns the top-level headers of a message, or nil when the
+// API returned it without a payload, as happens for some drafts and imported
+// messages. Header lookups on nil fall back to empty values.
+func messageHeaders(msg *gmail.Message) []*gmail.MessagePartHeader {
+	if msg == nil || msg.Payload == nil {
+		return nil
+	}
+	return msg.Payload.Headers
+}
+
+// listCall builds a Messages.List call for the given query. When labelID is set
+// the listing is restricted to that label by ID, which works for any label name,
+// including ones with spaces or punctuation that label: search terms mangle.
+// Otherwise the INBOX filter is applied unless the query contains its own
+// mailbox term or inbox is false. spamTrash includes messages in spam and
+// trash, which Gmail leaves out of every listing by default.
+func (c *Client) listCall(query, labelID string, inbox, spamTrash bool) *gmail.UsersMessagesListCall {
+	call := c.svc.Users.Messages.List("me")
+	if spamTrash {
+		call = call.IncludeSpamTrash(true)
+	}
 
-	// Only apply INBOX filter if query doesn't contain a label filter
-	if !strings.Contains(strings.ToLower(query), "label:") {
+	switch {
+	case labelID != "":
+		call = call.LabelIds(labelID)
+	case inbox && !scopesMailbox(query):
 		call = call.LabelIds("INBOX")
 	}
 
 	if strings.TrimSpace(query) != "" {
 		call = call.Q(query)
 	}
+	return call
+}
 
-	ml, err := call.Do()
+// ListInbox fetches up to 'max' email messages from the user's Gmail inbox.
+// If a query string is provided, it applies Gmail search syntax filtering
+// (e.g., "from:someone newer_than:7d", "label:SENT"). Returns basic metadata including
+// subject, sender, date, and snippet. Silently skips emails that fail to fetch.
+// If the query contains a label filter, it won't apply the default INBOX filter.
+// Labels referenced by the query are validated first, returning a *LabelNotFoundError
+// if one no longer exists. If the INBOX-filtered listing comes back empty or fails on
+// an account without an INBOX label, the listing is retried without that filter.
+func (c *Client) ListInbox(ctx context.Context, max int64, query string) ([]EmailRow, error) {
+	ids, err := c.ListInboxIDs(ctx, max, query)
 	if err != nil {
 		return nil, err
 	}
 
-	out := make([]EmailRow, 0, len(ml.Messages))
-	for _, m := range ml.Messages {
-		msg, err := c.svc.Users.Messages.Get("me", m.Id).
-			Format("metadata").
-			MetadataHeaders("Subject", "From", "Date").
-			Do()
+	out := make([]EmailRow, 0, len(ids))
+	for _, id := range ids {
+		row, err := c.GetRow(ctx, id)
 		if err != nil {
+			slog.Warn("skipping message that failed to fetch", "id", id, "err", err)
 			continue
 		}
+		out = append(out, row)
+	}
+	return out, nil
+}
 
-		subj := headerVal(msg.Payload.Headers, "Subject")
-		if strings.TrimSpace(subj) == "" {
-			subj = "(no subject)"
-		}
-		from := headerVal(msg.Payload.Headers, "From")
-		date := headerVal(msg.Payload.Headers, "Date")
+// ListInboxIDs returns the IDs of the messages ListInbox would show, newest
+// first, without fetching their metadata. Callers that want to show rows as they
+// load fetch each one with GetRow.
+func (c *Client) ListInboxIDs(ctx context.Context, max int64, query string) ([]string, error) {
+	p, err := c.ListInboxPage(ctx, max, query, "", "", false)
+	if err != nil {
+		return nil, err
+	}
+	return p.IDs, nil
+}
+
+// InboxPage is one page of a message listing.
+type InboxPage struct {
+	IDs []string
+	// NextPageToken loads the following page; it is empty on the last page.
+	NextPageToken string
+	// Estimate is Gmail's approximate total number of matching messages.
+	Estimate int64
+}
 
-		out = append(out, EmailRow{
-			ID:      m.Id,
-			Subject: subj,
-			From:    from,
-			Date:    date,
-			Snippet: msg.Snippet,
-		})
+// ListInboxPage lists the page of message IDs starting at pageToken, or the
+// first page when it is empty, with the same query handling as ListInboxIDs.
+// A non-empty labelID lists that label instead of the inbox. everywhere
+// searches all mail, spam and trash included, instead of the inbox.
+func (c *Client) ListInboxPage(ctx context.Context, max int64, query, labelID, pageToken string, everywhere bool) (InboxPage, error) {
+	if err := c.checkQueryLabels(ctx, query); err != nil {
+		return InboxPage{}, err
 	}
-	return out, nil
+
+	ml, err := c.listCall(query, labelID, !everywhere, everywhere).MaxResults(max).PageToken(pageToken).Context(ctx).Do()
+	if labelID == "" && !everywhere && (err != nil || len(ml.Messages) == 0) && !c.hasInbox(ctx) {
+		slog.Warn("account has no INBOX label; listing without it", "err", err)
+		ml, err = c.listCall(query, "", false, false).MaxResults(max).PageToken(pageToken).Context(ctx).Do()
+	}
+	if err != nil {
+		return InboxPage{}, wrapAPIError(err)
+	}
+
+	p := InboxPage{
+		IDs:           make([]string, 0, len(ml.Messages)),
+		NextPageToken: ml.NextPageToken,
+		Estimate:      ml.ResultSizeEstimate,
+	}
+	for _, m := range ml.Messages {
+		p.IDs = append(p.IDs, m.Id)
+	}
+	return p, nil
+}
+
+// EstimateResults returns Gmail's estimate of how many messages ListInboxPage
+// would find for the same arguments, without listing them.
+func (c *Client) EstimateResults(ctx context.Context, query, labelID string, everywhere bool) (int64, error) {
+	ml, err := c.listCall(query, labelID, !everywhere, everywhere).MaxResults(1).Fields("resultSizeEstimate").Context(ctx).Do()
+	if err != nil {
+		return 0, wrapAPIError(err)
+	}
+	return ml.ResultSizeEstimate, nil
+}
+
+// GetRow fetches the list-row metadata of a single message. It is used to
+// refresh one row in place after an action changes it, without re-listing.
+func (c *Client) GetRow(ctx context.Context, id string) (EmailRow, error) {
+	msg, err := c.svc.Users.Messages.Get("me", id).
+		Format("metadata").
+		MetadataHeaders("Subject", "From", "To", "Date").
+		Fields(rowFields).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return EmailRow{}, wrapAPIError(err)
+	}
+
+	hs := messageHeaders(msg)
+	subj := headerVal(hs, "Subject")
+	if strings.TrimSpace(subj) == "" {
+		subj = "(no subject)"
+	}
+	r := NewEmailRow(
+		id,
+		subj,
+		headerVal(hs, "From"),
+		headerVal(hs, "Date"),
+		msg.Snippet,
+		slices.Contains(msg.LabelIds, "UNREAD"),
+	)
+	r.To = headerVal(hs, "To")
+	r.ThreadID = msg.ThreadId
+	return r, nil
 }
 
 // decodeB64URL decodes a URL-safe base64 encoded string to plain text.
@@ -129,88 +422,270 @@ func decodeB64URL(s string) (string, error) {
 	return string(b), nil
 }
 
+// partCharset returns the charset declared in a message part's Content-Type header,
+// lowercased. Returns an empty string when the part doesn't declare one.
+func partCharset(part *gmail.MessagePart) string {
+	ct := headerVal(part.Headers, "Content-Type")
+	if ct == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(params["charset"]))
+}
+
+// toUTF8 transcodes text from the named charset into UTF-8. Bodies declared as
+// ISO-8859-1, windows-1252 and friends come back from the API as raw bytes in that
+// charset, which render as mojibake if treated as UTF-8. Unknown or unspecified
+// charsets are assumed to already be UTF-8 and are returned unchanged.
+func toUTF8(s, charset string) string {
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return s
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return s
+	}
+	out, err := enc.NewDecoder().String(s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
 // extractBody recursively searches through email message parts to find and extract
 // the plain text body. Gmail messages have a complex MIME structure with nested parts.
-// This function prefers text/plain parts and decodes them from base64url encoding.
-// Returns empty string if no plain text body is found.
-func extractBody(part *gmail.MessagePart) string {
+// This function prefers text/plain parts, decodes them from base64url encoding and
+// transcodes them to UTF-8 using the part's declared charset.
+// Returns the body and the charset it was declared in (empty string if unspecified),
+// or empty strings if no plain text body is found.
+func extractBody(part *gmail.MessagePart) (string, string) {
 	if part == nil {
-		return ""
+		return "", ""
 	}
 
 	mt := strings.ToLower(part.MimeType)
 	if strings.HasPrefix(mt, "text/plain") && part.Body != nil && part.Body.Data != "" {
 		txt, err := decodeB64URL(part.Body.Data)
-		if err == nil {
-			return txt
+		if err != nil {
+			slog.Warn("failed to decode message body", "mimeType", part.MimeType, "err", err)
+			return "", ""
 		}
-		return ""
+		charset := partCharset(part)
+		return toUTF8(txt, charset), charset
 	}
 
 	for _, p := range part.Parts {
-		if b := extractBody(p); strings.TrimSpace(b) != "" {
-			return b
+		if b, charset := extractBody(p); strings.TrimSpace(b) != "" {
+			return b, charset
 		}
 	}
 
+	return "", ""
+}
+
+// extractHTMLBody finds the first text/html part and converts it to plain text.
+// It is used for messages that have no plain-text alternative. Returns the text,
+// its declared charset and the number of tracking elements stripped from it.
+func extractHTMLBody(part *gmail.MessagePart) (string, string, int) {
+	if part == nil {
+		return "", "", 0
+	}
+	if strings.HasPrefix(strings.ToLower(part.MimeType), "text/html") && part.Body != nil && part.Body.Data != "" {
+		raw, err := decodeB64URL(part.Body.Data)
+		if err != nil {
+			slog.Warn("failed to decode message body", "mimeType", part.MimeType, "err", err)
+			return "", "", 0
+		}
+		charset := partCharset(part)
+		txt, stripped := htmlToText(toUTF8(raw, charset))
+		return txt, charset, stripped
+	}
+	for _, p := range part.Parts {
+		if b, charset, stripped := extractHTMLBody(p); strings.TrimSpace(b) != "" {
+			return b, charset, stripped
+		}
+	}
+	return "", "", 0
+}
+
+// rawPart returns the content of the first part whose MIME type starts with
+// prefix, decoded and converted to UTF-8, or "" if there is none.
+func rawPart(part *gmail.MessagePart, prefix string) string {
+	if part == nil {
+		return ""
+	}
+	if strings.HasPrefix(strings.ToLower(part.MimeType), prefix) && part.Body != nil && part.Body.Data != "" {
+		raw, err := decodeB64URL(part.Body.Data)
+		if err != nil {
+			return ""
+		}
+		return toUTF8(raw, partCharset(part))
+	}
+	for _, p := range part.Parts {
+		if s := rawPart(p, prefix); s != "" {
+			return s
+		}
+	}
 	return ""
 }
 
+// truncateBody cuts body to at most max bytes on a UTF-8 boundary and returns the
+// result with the number of bytes omitted. A non-positive max means no limit.
+func truncateBody(body string, max int) (string, int) {
+	if max <= 0 || len(body) <= max {
+		return body, 0
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return body[:cut], len(body) - cut
+}
+
+// detailFromMessage fills the header fields of an EmailDetail from a message
+// fetched in any format that includes the payload headers.
+func detailFromMessage(msg *gmail.Message) *EmailDetail {
+	hs := messageHeaders(msg)
+	subj := headerVal(hs, "Subject")
+	if strings.TrimSpace(subj) == "" {
+		subj = "(no subject)"
+	}
+	headers := make([]Header, 0, len(hs))
+	for _, h := range hs {
+		if h == nil {
+			continue
+		}
+		headers = append(headers, Header{Name: h.Name, Value: h.Value})
+	}
+	d := NewEmailDetail(
+		msg.Id,
+		msg.ThreadId,
+		subj,
+		headerVal(hs, "From"),
+		headerVal(hs, "To"),
+		headerVal(hs, "Date"),
+		msg.Snippet,
+		"",
+	)
+	d.Cc = headerVal(hs, "Cc")
+	d.SizeEstimate = msg.SizeEstimate
+	d.Headers = headers
+	d.LabelIDs = msg.LabelIds
+	return d
+}
+
 // GetDetail fetches the complete details of a specific email by ID.
 // Returns full email content including all headers and the plain text body.
 // The 'full' format includes the entire MIME structure of the message,
 // allowing extraction of the message body and all metadata.
-func (c *Client) GetDetail(ctx context.Context, id string) (*EmailDetail, error) {
-	msg, err := c.svc.Users.Messages.Get("me", id).Format("full").Do()
+// Bodies longer than maxBody bytes are truncated so pathological messages don't
+// stall rendering; Truncated reports how much was left out. The raw parts are
+// capped the same way. 0 disables the cap.
+func (c *Client) GetDetail(ctx context.Context, id string, maxBody int) (*EmailDetail, error) {
+	msg, err := c.svc.Users.Messages.Get("me", id).Format("full").Context(ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, wrapAPIError(err)
 	}
 
-	subj := headerVal(msg.Payload.Headers, "Subject")
-	if strings.TrimSpace(subj) == "" {
-		subj = "(no subject)"
-	}
+	d := detailFromMessage(msg)
+	plain := rawPart(msg.Payload, "text/plain")
+	html := rawPart(msg.Payload, "text/html")
+	d.PlainPart, d.PlainTruncated = truncateBody(plain, maxBody)
+	d.HTMLPart, d.HTMLTruncated = truncateBody(html, maxBody)
 
-	body := extractBody(msg.Payload)
+	body, charset := extractBody(msg.Payload)
+	stripped := 0
 	if strings.TrimSpace(body) == "" {
-		body = "(no plain-text body found)"
+		body, charset, stripped = extractHTMLBody(msg.Payload)
 	}
-
-	d := &EmailDetail{
-		ID:      id,
-		Subject: subj,
-		From:    headerVal(msg.Payload.Headers, "From"),
-		To:      headerVal(msg.Payload.Headers, "To"),
-		Date:    headerVal(msg.Payload.Headers, "Date"),
-		Snippet: msg.Snippet,
-		Body:    body,
+	if strings.TrimSpace(body) == "" && html != "" {
+		// The HTML didn't convert to any text, e.g. because it is malformed
+		// or all of it is hidden; a crude rendering beats nothing.
+		body = stripTags(html)
+		d.HTMLFallback = true
+	}
+	if strings.TrimSpace(body) == "" {
+		body = "(no readable body found)"
 	}
+
+	d.Body, d.Truncated = truncateBody(body, maxBody)
+	d.Charset = charset
+	d.TrackersStripped = stripped
+	d.Attachments = collectAttachments(msg.Payload)
 	return d, nil
 }
 
+// GetMetadata fetches a message's headers without its body. It is the only
+// detail lookup allowed under the gmail.metadata scope, which can't read
+// message content; Body is left empty.
+func (c *Client) GetMetadata(ctx context.Context, id string) (*EmailDetail, error) {
+	msg, err := c.svc.Users.Messages.Get("me", id).Format("metadata").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	return detailFromMessage(msg), nil
+}
+
+// metadataWorkers bounds how many messages GetMetadataEach fetches
+// concurrently.
+const metadataWorkers = 4
+
+// GetMetadataEach fetches the headers of each of ids, as GetMetadata does,
+// with one request per message, a few at a time, and returns them by ID. It
+// doesn't use the API's batch endpoint. Messages that fail to load are left
+// out; an error is returned only if none load.
+func (c *Client) GetMetadataEach(ctx context.Context, ids []string) (map[string]*EmailDetail, error) {
+	return fetchEach(ids, metadataWorkers, func(id string) (*EmailDetail, error) {
+		msg, err := c.svc.Users.Messages.Get("me", id).Format("metadata").Context(ctx).Do()
+		if err != nil {
+			return nil, err
+		}
+		return detailFromMessage(msg), nil
+	})
+}
+
 type Label struct {
-	ID   string
-	Name string
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// TextColor and BackgroundColor are the label's hex colors, such as
+	// "#ffffff", or empty for labels without a custom color, which includes
+	// every system label.
+	TextColor       string `json:"textColor,omitempty"`
+	BackgroundColor string `json:"backgroundColor,omitempty"`
 }
 
 // ListLabels fetches all Gmail labels (both system and user-created) for the user's account.
 // System labels include INBOX, SENT, DRAFT, TRASH, SPAM, etc. User labels are custom
-// organizational tags. Returns a slice of labels with both ID and display Name.
+// organizational tags. Returns a slice of labels with their ID, display Name and colors.
 func (c *Client) ListLabels(ctx context.Context) ([]Label, error) {
-	labelsResp, err := c.svc.Users.Labels.List("me").Do()
+	labelsResp, err := c.svc.Users.Labels.List("me").Context(ctx).Do()
 	if err != nil {
-		return nil, err
+		return nil, wrapAPIError(err)
 	}
 	labels := make([]Label, 0, len(labelsResp.Labels))
 	for _, l := range labelsResp.Labels {
-		labels = append(labels, Label{
+		label := Label{
 			ID:   l.Id,
 			Name: l.Name,
-		})
+		}
+		if l.Color != nil {
+			label.TextColor = l.Color.TextColor
+			label.BackgroundColor = l.Color.BackgroundColor
+		}
+		labels = append(labels, label)
 	}
 	return labels, nil
 }
 
+// Permalink builds the Gmail web URL for a thread, for the signed-in account at the
+// given index (the N in mail.google.com/mail/u/N, 0 for the default account).
+func Permalink(accountIndex int, threadID string) string {
+	return fmt.Sprintf("https://mail.google.com/mail/u/%d/#all/%s", accountIndex, threadID)
+}
+
 // HumanTimeoutCtx creates a context with a timeout specified in seconds.
 // This is a convenience wrapper around context.WithTimeout that accepts
 // seconds as an integer instead of a time.Duration, making it more readable.
@@ -222,9 +697,59 @@ func HumanTimeoutCtx(parent context.Context, seconds int) (context.Context, cont
 // This is a lightweight check to verify that authentication is working
 // and the Gmail API is accessible. Returns an error if the connection fails.
 func (c *Client) Ping(ctx context.Context) error {
-	_, err := c.svc.Users.GetProfile("me").Do()
+	_, err := c.svc.Users.GetProfile("me").Context(ctx).Do()
 	if err != nil {
-		return fmt.Errorf("gmail ping failed: %w", err)
+		return fmt.Errorf("gmail ping failed: %w", wrapAPIError(err))
 	}
 	return nil
 }
+
+// IsNetworkError reports whether err was caused by the network rather than the
+// Gmail API, e.g. no connectivity, DNS failure, or a connection timeout. API
+// errors such as auth or quota failures are not network errors.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return false
+	}
+	var authErr *oauth2.RetrieveError
+	if errors.As(err, &authErr) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// IsNotFound reports whether err is the API's 404 response, as returned for a
+// message that was deleted after it was listed.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// AccountProfile describes the authenticated mailbox.
+type AccountProfile struct {
+	Email         string `json:"email"`
+	MessagesTotal int64  `json:"messagesTotal"`
+	ThreadsTotal  int64  `json:"threadsTotal"`
+	HistoryID     uint64 `json:"historyId"`
+}
+
+// Profile returns the address and mailbox totals of the authenticated account.
+func (c *Client) Profile(ctx context.Context) (AccountProfile, error) {
+	p, err := c.svc.Users.GetProfile("me").Fields("emailAddress,messagesTotal,threadsTotal,historyId").Context(ctx).Do()
+	if err != nil {
+		return AccountProfile{}, wrapAPIError(err)
+	}
+	return AccountProfile{
+		Email:         p.EmailAddress,
+		MessagesTotal: p.MessagesTotal,
+		ThreadsTotal:  p.ThreadsTotal,
+		HistoryID:     p.HistoryId,
+	}, nil
+}
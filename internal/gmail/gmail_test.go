@@ -0,0 +1,316 @@
+package gmailx_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	gmailx "gmail-tui/internal/gmail"
+	"gmail-tui/internal/gmail/gmailtest"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// newServer starts a fake server for the test and returns it with a client
+// talking to it.
+func newServer(t *testing.T) (*gmailtest.Server, *gmailx.Client) {
+	t.Helper()
+	s := gmailtest.NewServer()
+	t.Cleanup(s.Close)
+	c, err := s.Client(context.Background())
+	if err != nil {
+		t.Fatalf("Client() error = %v", err)
+	}
+	return s, c
+}
+
+// b64 encodes s the way the Gmail API encodes part bodies.
+func b64(s string) string {
+	return base64.URLEncoding.EncodeToString([]byte(s))
+}
+
+// part builds a leaf MIME part of the given type with body as its data.
+func part(mimeType, contentType, body string) *gmail.MessagePart {
+	return &gmail.MessagePart{
+		MimeType: mimeType,
+		Headers:  []*gmail.MessagePartHeader{{Name: "Content-Type", Value: contentType}},
+		Body:     &gmail.MessagePartBody{Data: b64(body)},
+	}
+}
+
+// multipartMessage builds a message whose payload is a multipart/alternative
+// of parts.
+func multipartMessage(id, subject string, parts ...*gmail.MessagePart) *gmail.Message {
+	return &gmail.Message{
+		Id:       id,
+		ThreadId: id,
+		LabelIds: []string{"INBOX"},
+		Payload: &gmail.MessagePart{
+			MimeType: "multipart/alternative",
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "Subject", Value: subject},
+				{Name: "From", Value: "Ana <ana@example.com>"},
+			},
+			Parts: parts,
+		},
+	}
+}
+
+func TestListInboxPagePaginates(t *testing.T) {
+	s, c := newServer(t)
+	for i := 1; i <= 5; i++ {
+		s.Messages = append(s.Messages, gmailtest.Message(fmt.Sprint(i), "Subject", "a@example.com", "body", "INBOX"))
+	}
+	ctx := context.Background()
+
+	var pages [][]string
+	token := ""
+	for {
+		p, err := c.ListInboxPage(ctx, 2, "", "", token, false)
+		if err != nil {
+			t.Fatalf("ListInboxPage(%q) error = %v", token, err)
+		}
+		if p.Estimate != 5 {
+			t.Errorf("Estimate = %d, want 5", p.Estimate)
+		}
+		pages = append(pages, p.IDs)
+		if p.NextPageToken == "" {
+			break
+		}
+		token = p.NextPageToken
+	}
+
+	want := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+	if !slices.EqualFunc(pages, want, slices.Equal) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+}
+
+func TestListInboxReturnsRows(t *testing.T) {
+	s, c := newServer(t)
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("new", "Hello", "Ana <ana@example.com>", "hi there", "INBOX", "UNREAD"),
+		gmailtest.Message("old", "", "bob@example.com", "older", "INBOX"),
+	}
+
+	rows, err := c.ListInbox(context.Background(), 10, "")
+	if err != nil {
+		t.Fatalf("ListInbox() error = %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("ListInbox() returned %d rows, want 2", len(rows))
+	}
+	if r := rows[0]; r.ID != "new" || r.Subject != "Hello" || !r.Unread || r.FromName != "Ana" || r.FromAddress != "ana@example.com" {
+		t.Errorf("rows[0] = %+v, want the unread message from Ana", r)
+	}
+	if r := rows[1]; r.Subject != "(no subject)" || r.Unread {
+		t.Errorf("rows[1] = %+v, want a read message with no subject", r)
+	}
+}
+
+func TestListInboxPageFiltersByLabel(t *testing.T) {
+	s, c := newServer(t)
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("inbox", "In the inbox", "a@example.com", "x", "INBOX"),
+		gmailtest.Message("work", "Work only", "a@example.com", "x", "Label_1"),
+		gmailtest.Message("both", "Work in the inbox", "a@example.com", "x", "INBOX", "Label_1"),
+	}
+	ctx := context.Background()
+
+	p, err := c.ListInboxPage(ctx, 10, "", "", "", false)
+	if err != nil {
+		t.Fatalf("ListInboxPage() error = %v", err)
+	}
+	if want := []string{"inbox", "both"}; !slices.Equal(p.IDs, want) {
+		t.Errorf("inbox IDs = %v, want %v", p.IDs, want)
+	}
+
+	p, err = c.ListInboxPage(ctx, 10, "", "Label_1", "", false)
+	if err != nil {
+		t.Fatalf("ListInboxPage(Label_1) error = %v", err)
+	}
+	if want := []string{"work", "both"}; !slices.Equal(p.IDs, want) {
+		t.Errorf("Label_1 IDs = %v, want %v", p.IDs, want)
+	}
+}
+
+func TestGetDetailExtractsBodyAndParts(t *testing.T) {
+	s, c := newServer(t)
+	s.Messages = []*gmail.Message{
+		multipartMessage("both", "Both parts",
+			part("text/plain", "text/plain; charset=utf-8", "Plain body"),
+			part("text/html", "text/html; charset=utf-8", "<p>HTML <b>body</b></p>"),
+		),
+		multipartMessage("html", "HTML only",
+			part("text/html", "text/html; charset=utf-8", "<p>Only <i>HTML</i></p>"),
+		),
+	}
+	ctx := context.Background()
+
+	d, err := c.GetDetail(ctx, "both", 0)
+	if err != nil {
+		t.Fatalf("GetDetail(both) error = %v", err)
+	}
+	if d.Body != "Plain body" {
+		t.Errorf("Body = %q, want the plain part", d.Body)
+	}
+	if d.PlainPart != "Plain body" || d.HTMLPart != "<p>HTML <b>body</b></p>" {
+		t.Errorf("parts = %q, %q, want both parts as sent", d.PlainPart, d.HTMLPart)
+	}
+	if d.Subject != "Both parts" || d.FromAddress != "ana@example.com" {
+		t.Errorf("headers = %q from %q, want Both parts from ana@example.com", d.Subject, d.FromAddress)
+	}
+
+	d, err = c.GetDetail(ctx, "html", 0)
+	if err != nil {
+		t.Fatalf("GetDetail(html) error = %v", err)
+	}
+	if !strings.Contains(d.Body, "Only") || !strings.Contains(d.Body, "HTML") || strings.Contains(d.Body, "<") {
+		t.Errorf("Body = %q, want the HTML part converted to text", d.Body)
+	}
+}
+
+func TestGetDetailCapsBodyAndParts(t *testing.T) {
+	s, c := newServer(t)
+	long := strings.Repeat("a", 100)
+	s.Messages = []*gmail.Message{
+		multipartMessage("long", "Long",
+			part("text/plain", "text/plain; charset=utf-8", long),
+			part("text/html", "text/html; charset=utf-8", "<p>"+long+"</p>"),
+		),
+	}
+
+	d, err := c.GetDetail(context.Background(), "long", 10)
+	if err != nil {
+		t.Fatalf("GetDetail() error = %v", err)
+	}
+	if len(d.Body) != 10 || d.Truncated != 90 {
+		t.Errorf("Body is %d bytes with %d truncated, want 10 and 90", len(d.Body), d.Truncated)
+	}
+	if len(d.PlainPart) != 10 || d.PlainTruncated != 90 {
+		t.Errorf("PlainPart is %d bytes with %d truncated, want 10 and 90", len(d.PlainPart), d.PlainTruncated)
+	}
+	if len(d.HTMLPart) != 10 || d.HTMLTruncated != 97 {
+		t.Errorf("HTMLPart is %d bytes with %d truncated, want 10 and 97", len(d.HTMLPart), d.HTMLTruncated)
+	}
+}
+
+func TestAPIErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusNotFound, gmailx.ErrNotFound},
+		{http.StatusTooManyRequests, gmailx.ErrRateLimited},
+		{http.StatusUnauthorized, gmailx.ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			s, c := newServer(t)
+			s.Messages = []*gmail.Message{gmailtest.Message("m1", "s", "a@example.com", "b", "INBOX")}
+			s.Errors["/gmail/v1/users/me/messages/m1"] = tt.status
+
+			_, err := c.GetDetail(context.Background(), "m1", 0)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("GetDetail() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDetailTranscodesLatin1(t *testing.T) {
+	s, c := newServer(t)
+	msg := gmailtest.Message("latin1", "Menu", "chef@example.com", "", "INBOX")
+	msg.Payload.Headers[2].Value = "text/plain; charset=ISO-8859-1"
+	// "Café crème brûlée" in ISO-8859-1, where each accented letter is a
+	// single byte that isn't valid UTF-8 on its own.
+	msg.Payload.Body.Data = b64("Caf\xe9 cr\xe8me br\xfbl\xe9e")
+	s.Messages = []*gmail.Message{msg}
+
+	d, err := c.GetDetail(context.Background(), "latin1", 0)
+	if err != nil {
+		t.Fatalf("GetDetail() error = %v", err)
+	}
+	if d.Body != "Café crème brûlée" {
+		t.Errorf("Body = %q, want %q", d.Body, "Café crème brûlée")
+	}
+	if d.Charset != "iso-8859-1" {
+		t.Errorf("Charset = %q, want iso-8859-1", d.Charset)
+	}
+}
+
+func TestMessageWithoutPayload(t *testing.T) {
+	s, c := newServer(t)
+	s.Messages = []*gmail.Message{{Id: "bare", ThreadId: "bare", LabelIds: []string{"INBOX"}}}
+	ctx := context.Background()
+
+	rows, err := c.ListInbox(ctx, 10, "")
+	if err != nil {
+		t.Fatalf("ListInbox() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].Subject != "(no subject)" {
+		t.Errorf("ListInbox() = %+v, want one row with no subject", rows)
+	}
+	r, err := c.GetRow(ctx, "bare")
+	if err != nil {
+		t.Fatalf("GetRow() error = %v", err)
+	}
+	if r.Subject != "(no subject)" {
+		t.Errorf("GetRow().Subject = %q, want (no subject)", r.Subject)
+	}
+	d, err := c.GetDetail(ctx, "bare", 0)
+	if err != nil {
+		t.Fatalf("GetDetail() error = %v", err)
+	}
+	if d.Subject != "(no subject)" || len(d.Attachments) != 0 {
+		t.Errorf("GetDetail() = %q with %d attachments, want no subject and none", d.Subject, len(d.Attachments))
+	}
+}
+
+func TestListInboxPageFiltersByLabelID(t *testing.T) {
+	s, c := newServer(t)
+	s.Labels = []*gmail.Label{{Id: "Label_7", Name: "Client work: 2024", Type: "user"}}
+	s.Messages = []*gmail.Message{
+		gmailtest.Message("starred", "Starred", "a@example.com", "x", "INBOX", "STARRED"),
+		gmailtest.Message("client", "Client", "a@example.com", "x", "Label_7"),
+		gmailtest.Message("plain", "Plain", "a@example.com", "x", "INBOX"),
+	}
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		labelID string
+		want    []string
+	}{
+		{"STARRED", []string{"starred"}},
+		{"Label_7", []string{"client"}},
+	} {
+		s.Lock()
+		s.Lists = nil
+		s.Unlock()
+		p, err := c.ListInboxPage(ctx, 10, "", tt.labelID, "", false)
+		if err != nil {
+			t.Fatalf("ListInboxPage(%s) error = %v", tt.labelID, err)
+		}
+		if !slices.Equal(p.IDs, tt.want) {
+			t.Errorf("ListInboxPage(%s) IDs = %v, want %v", tt.labelID, p.IDs, tt.want)
+		}
+		s.Lock()
+		lists := s.Lists
+		s.Unlock()
+		if len(lists) != 1 {
+			t.Fatalf("ListInboxPage(%s) made %d list requests, want 1", tt.labelID, len(lists))
+		}
+		q := lists[0]
+		if got := q["labelIds"]; !slices.Equal(got, []string{tt.labelID}) {
+			t.Errorf("ListInboxPage(%s) labelIds = %v, want only the label", tt.labelID, got)
+		}
+		if q.Has("q") {
+			t.Errorf("ListInboxPage(%s) q = %q, want no search query", tt.labelID, q.Get("q"))
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package gmailtest provides a fake Gmail API server for exercising gmailx
+// clients without network access or an account.
+package gmailtest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	gmailx "gmail-tui/internal/gmail"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Server is a fake Gmail API serving canned messages and labels for the user
+// "me". It implements message listing with paging and label filtering, message
+// lookup and label listing; search queries are accepted but ignored. Fields may
+// be changed between requests while holding Lock.
+type Server struct {
+	*httptest.Server
+	sync.Mutex
+
+	// Messages are listed in order, so put the newest first, as Gmail does.
+	Messages []*gmail.Message
+	Labels   []*gmail.Label
+	// Errors maps a request path, such as
+	// "/gmail/v1/users/me/messages", to the HTTP status to fail it with.
+	Errors map[string]int
+	// Lists records the query parameters of each messages.list request.
+	Lists []url.Values
+}
+
+// NewServer starts a fake server with no messages or labels. Close it when
+// done.
+func NewServer() *Server {
+	s := &Server{Errors: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns a gmailx client that talks to the server, customized by opts.
+func (s *Server) Client(ctx context.Context, opts ...gmailx.Option) (*gmailx.Client, error) {
+	opts = append([]gmailx.Option{gmailx.WithEndpoint(s.URL), gmailx.WithHTTPClient(s.Server.Client())}, opts...)
+	return gmailx.New(ctx, nil, nil, opts...)
+}
+
+// Message builds a single-part text/plain message with the given headers and
+// body, carrying labelIDs; "UNREAD" marks it unread.
+func Message(id, subject, from, body string, labelIDs ...string) *gmail.Message {
+	return &gmail.Message{
+		Id:       id,
+		ThreadId: id,
+		LabelIds: labelIDs,
+		Snippet:  body,
+		Payload: &gmail.MessagePart{
+			MimeType: "text/plain",
+			Headers: []*gmail.MessagePartHeader{
+				{Name: "Subject", Value: subject},
+				{Name: "From", Value: from},
+				{Name: "Content-Type", Value: "text/plain; charset=utf-8"},
+			},
+			Body: &gmail.MessagePartBody{Data: base64.URLEncoding.EncodeToString([]byte(body))},
+		},
+	}
+}
+
+const usersPrefix = "/gmail/v1/users/me/"
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if code, ok := s.Errors[r.URL.Path]; ok {
+		writeError(w, code, http.StatusText(code))
+		return
+	}
+	rest, ok := strings.CutPrefix(r.URL.Path, usersPrefix)
+	if !ok || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	switch {
+	case rest == "messages":
+		s.listMessages(w, r)
+	case strings.HasPrefix(rest, "messages/"):
+		id := strings.TrimPrefix(rest, "messages/")
+		for _, msg := range s.Messages {
+			if msg.Id == id {
+				writeJSON(w, msg)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+	case rest == "labels":
+		writeJSON(w, &gmail.ListLabelsResponse{Labels: s.Labels})
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+// listMessages serves messages.list. Page tokens are offsets into the
+// filtered message list.
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.Lists = append(s.Lists, q)
+	var matched []*gmail.Message
+	for _, msg := range s.Messages {
+		keep := true
+		for _, l := range q["labelIds"] {
+			if !slices.Contains(msg.LabelIds, l) {
+				keep = false
+			}
+		}
+		if keep {
+			matched = append(matched, msg)
+		}
+	}
+	start, _ := strconv.Atoi(q.Get("pageToken"))
+	start = min(max(start, 0), len(matched))
+	end := len(matched)
+	if n, err := strconv.Atoi(q.Get("maxResults")); err == nil && n > 0 {
+		end = min(start+n, end)
+	}
+	resp := &gmail.ListMessagesResponse{ResultSizeEstimate: int64(len(matched))}
+	for _, msg := range matched[start:end] {
+		resp.Messages = append(resp.Messages, &gmail.Message{Id: msg.Id, ThreadId: msg.ThreadId})
+	}
+	if end < len(matched) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	writeJSON(w, resp)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responds with an error in the shape the Gmail API uses, which
+// the client library turns into a *googleapi.Error.
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{"code": code, "message": message},
+	})
+}
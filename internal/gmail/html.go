@@ -0,0 +1,152 @@
+package gmailx
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// trackingPixelMax is the largest width or height, in pixels, at which an image
+// is assumed to be a tracking pixel rather than content.
+const trackingPixelMax = 2
+
+var (
+	hiddenStyle = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden|opacity\s*:\s*0(\.0+)?\s*(;|$)|(^|;)\s*(max-)?(width|height)\s*:\s*0(px)?\s*(;|$)`)
+	styleSize   = regexp.MustCompile(`(?i)(?:^|;)\s*(width|height)\s*:\s*(\d+)(?:px)?\s*(?:;|$)`)
+	spaceRun    = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	tag         = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+)
+
+// htmlToText converts an HTML body to readable plain text. Content the reader
+// can't see is dropped: scripts, styles, elements hidden by style or attribute,
+// and tiny images, which are almost always tracking pixels. Nothing referenced
+// by the HTML is ever fetched. Returns the text and the number of tracking
+// elements that were removed.
+func htmlToText(s string) (string, int) {
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		return s, 0
+	}
+	var b strings.Builder
+	stripped := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(spaceRun.ReplaceAllString(strings.ReplaceAll(n.Data, "\n", " "), " "))
+			return
+		case html.ElementNode:
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Head, atom.Title, atom.Noscript:
+				return
+			case atom.Img:
+				if isTrackingImage(n) {
+					stripped++
+				} else if alt := attr(n, "alt"); strings.TrimSpace(alt) != "" {
+					b.WriteString("[" + strings.TrimSpace(alt) + "]")
+				}
+				return
+			case atom.Br:
+				b.WriteString("\n")
+				return
+			case atom.Li:
+				b.WriteString("\n• ")
+			}
+			if isHidden(n) {
+				stripped++
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if n.Type == html.ElementNode && isBlock(n.DataAtom) {
+			b.WriteString("\n")
+		}
+	}
+	walk(doc)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	out := blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(out), stripped
+}
+
+// HTMLToText converts an HTML body to plain text as htmlToText does, for
+// showing the HTML alternative of a message that also has a plain-text part.
+func HTMLToText(s string) string {
+	txt, _ := htmlToText(s)
+	return txt
+}
+
+// stripTags renders HTML as text by removing every tag and comment and
+// unescaping entities, keeping whatever text is left, hidden or not. It is a
+// last resort for HTML that htmlToText finds no text in.
+func stripTags(s string) string {
+	s = html.UnescapeString(tag.ReplaceAllString(s, " "))
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(spaceRun.ReplaceAllString(l, " "))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// attr returns the value of the named attribute, or "" if it isn't set.
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// isHidden reports whether an element is invisible to the reader, either via
+// the hidden attribute or an inline style that hides it or gives it no size.
+func isHidden(n *html.Node) bool {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, "hidden") {
+			return true
+		}
+	}
+	return hiddenStyle.MatchString(attr(n, "style"))
+}
+
+// isTrackingImage reports whether an img element is hidden or no larger than
+// trackingPixelMax in either dimension, by attribute or inline style.
+func isTrackingImage(n *html.Node) bool {
+	if isHidden(n) {
+		return true
+	}
+	small := func(v string) bool {
+		px, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(v), "px"))
+		return err == nil && px <= trackingPixelMax
+	}
+	if small(attr(n, "width")) || small(attr(n, "height")) {
+		return true
+	}
+	for _, m := range styleSize.FindAllStringSubmatch(attr(n, "style"), -1) {
+		if small(m[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlock reports whether an element starts a new line in rendered output.
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Tr, atom.Table, atom.Li, atom.Ul, atom.Ol,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Blockquote, atom.Pre, atom.Hr, atom.Section, atom.Article,
+		atom.Header, atom.Footer:
+		return true
+	}
+	return false
+}
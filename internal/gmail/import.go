@@ -0,0 +1,29 @@
+package gmailx
+
+import (
+	"bytes"
+	"context"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Import adds a raw RFC 822 message to the mailbox as if it had been received,
+// without sending it. The message date is taken from its Date header so old
+// mail keeps its original place in the timeline, and it is never sent to spam.
+// The message is uploaded as media rather than base64 in the request body,
+// which allows messages up to Gmail's 50 MB limit. Requires the modify or
+// insert scope. Returns the ID of the new message.
+func (c *Client) Import(ctx context.Context, raw []byte, labelIDs []string) (string, error) {
+	msg, err := c.svc.Users.Messages.Import("me", &gmail.Message{LabelIds: labelIDs}).
+		InternalDateSource("dateHeader").
+		NeverMarkSpam(true).
+		Media(bytes.NewReader(raw), googleapi.ContentType("message/rfc822")).
+		Fields("id").
+		Context(ctx).
+		Do()
+	if err != nil {
+		return "", wrapAPIError(err)
+	}
+	return msg.Id, nil
+}
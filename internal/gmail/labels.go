@@ -0,0 +1,184 @@
+package gmailx
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LabelNotFoundError reports that a query refers to a label that doesn't exist,
+// for example one that was deleted after being picked on the labels screen.
+type LabelNotFoundError struct {
+	Label string
+}
+
+// Error returns a message naming the missing label.
+func (e *LabelNotFoundError) Error() string {
+	return "label " + e.Label + " no longer exists"
+}
+
+var labelRef = regexp.MustCompile(`(?i)(?:^|\s)-?label:("[^"]*"|\S+)`)
+
+// queryLabels extracts the label references from a Gmail search query,
+// e.g. `label:work -label:"old stuff"` yields "work" and "old stuff".
+func queryLabels(query string) []string {
+	var out []string
+	for _, m := range labelRef.FindAllStringSubmatch(query, -1) {
+		out = append(out, strings.Trim(m[1], `"`))
+	}
+	return out
+}
+
+// normalizeLabel folds a label name the way Gmail search does, so that
+// "My Label/Sub" and "my-label-sub" compare equal.
+func normalizeLabel(s string) string {
+	return strings.NewReplacer(" ", "-", "/", "-").Replace(strings.ToLower(s))
+}
+
+// labelExists reports whether ref names one of the labels, by ID or by name.
+func labelExists(labels []Label, ref string) bool {
+	n := normalizeLabel(ref)
+	for _, l := range labels {
+		if strings.EqualFold(l.ID, ref) || normalizeLabel(l.Name) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQueryLabels verifies that every label referenced in the query exists.
+// Returns a *LabelNotFoundError for the first missing label.
+func (c *Client) checkQueryLabels(ctx context.Context, query string) error {
+	refs := queryLabels(query)
+	if len(refs) == 0 {
+		return nil
+	}
+	labels, err := c.ListLabels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if !labelExists(labels, ref) {
+			return &LabelNotFoundError{Label: ref}
+		}
+	}
+	return nil
+}
+
+// hasInbox reports whether the account has an INBOX label. Some Workspace
+// configurations behave oddly when filtering by it, so ListInbox checks this
+// before trusting an empty or failed INBOX listing.
+func (c *Client) hasInbox(ctx context.Context) bool {
+	labels, err := c.ListLabels(ctx)
+	if err != nil {
+		return true
+	}
+	return labelExists(labels, "INBOX")
+}
+
+// maxLabelSuggestions caps how many close matches an UnknownLabelError lists.
+const maxLabelSuggestions = 5
+
+// UnknownLabelError reports that a label given by name doesn't exist. Similar
+// lists the names of existing labels that come close, best first.
+type UnknownLabelError struct {
+	Name    string
+	Similar []string
+}
+
+// Error returns a message naming the label and any close matches.
+func (e *UnknownLabelError) Error() string {
+	msg := fmt.Sprintf("no label named %q", e.Name)
+	if len(e.Similar) > 0 {
+		msg += "; did you mean " + strings.Join(e.Similar, ", ") + "?"
+	}
+	return msg
+}
+
+// FindLabel returns the label named ref, compared the way Gmail search
+// compares label names, or whose ID is ref, such as "STARRED". When there is
+// none it returns an *UnknownLabelError listing labels whose names contain
+// ref or are a couple of typos away from it.
+func FindLabel(labels []Label, ref string) (Label, error) {
+	n := normalizeLabel(ref)
+	for _, l := range labels {
+		if strings.EqualFold(l.ID, ref) || normalizeLabel(l.Name) == n {
+			return l, nil
+		}
+	}
+	type match struct {
+		name string
+		dist int
+	}
+	var near []match
+	for _, l := range labels {
+		name := normalizeLabel(l.Name)
+		d := editDistance(name, n)
+		if strings.Contains(name, n) || strings.Contains(n, name) {
+			d = 0
+		}
+		if d <= 2 {
+			near = append(near, match{l.Name, d})
+		}
+	}
+	slices.SortFunc(near, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.dist, b.dist), cmp.Compare(a.name, b.name))
+	})
+	err := &UnknownLabelError{Name: ref}
+	for _, m := range near[:min(len(near), maxLabelSuggestions)] {
+		err.Similar = append(err.Similar, m.name)
+	}
+	return Label{}, err
+}
+
+// ResolveLabel fetches the account's labels and returns the one named ref, as
+// FindLabel does.
+func (c *Client) ResolveLabel(ctx context.Context, ref string) (Label, error) {
+	labels, err := c.ListLabels(ctx)
+	if err != nil {
+		return Label{}, err
+	}
+	return FindLabel(labels, ref)
+}
+
+// labelWorkers bounds how many label counts UnreadCounts fetches concurrently.
+const labelWorkers = 4
+
+// UnreadCounts returns how many unread messages each of the given labels has.
+// Gmail only reports counts per label, so one request is made for each,
+// a few at a time. Labels whose count fails to load are left out; an error is
+// returned only if none load.
+func (c *Client) UnreadCounts(ctx context.Context, ids []string) (map[string]int64, error) {
+	return fetchEach(ids, labelWorkers, func(id string) (int64, error) {
+		l, err := c.svc.Users.Labels.Get("me", id).Fields("id,messagesUnread").Context(ctx).Do()
+		if err != nil {
+			return 0, err
+		}
+		return l.MessagesUnread, nil
+	})
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
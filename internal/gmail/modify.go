@@ -0,0 +1,116 @@
+package gmailx
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// batchModifyLimit is the maximum number of message IDs accepted by one BatchModify call.
+const batchModifyLimit = 1000
+
+// ListMessageIDs pages through every message matching the query (with the same
+// INBOX/label and everywhere handling as ListInboxPage) and returns all of their IDs.
+// Only IDs are requested, so this is cheap even for large result sets.
+func (c *Client) ListMessageIDs(ctx context.Context, query, labelID string, everywhere bool) ([]string, error) {
+	var ids []string
+	err := c.listCall(query, labelID, !everywhere, everywhere).MaxResults(500).Fields("messages/id,nextPageToken").Pages(ctx, func(ml *gmail.ListMessagesResponse) error {
+		for _, m := range ml.Messages {
+			ids = append(ids, m.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	return ids, nil
+}
+
+// ListMessageIDsPage returns the IDs of one page of up to 500 messages matching
+// the query, like ListMessageIDs, and the token of the next page, which is ""
+// after the last one. It is for callers that report progress as they page.
+func (c *Client) ListMessageIDsPage(ctx context.Context, query, labelID string, everywhere bool, pageToken string) ([]string, string, error) {
+	call := c.listCall(query, labelID, !everywhere, everywhere).MaxResults(500).Fields("messages/id,nextPageToken")
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	ml, err := call.Context(ctx).Do()
+	if err != nil {
+		return nil, "", wrapAPIError(err)
+	}
+	ids := make([]string, 0, len(ml.Messages))
+	for _, m := range ml.Messages {
+		ids = append(ids, m.Id)
+	}
+	return ids, ml.NextPageToken, nil
+}
+
+// ModifyLabels adds and removes labels on the given messages, splitting the IDs
+// into BatchModify calls of at most batchModifyLimit messages each. In dry-run
+// mode the change is only logged.
+func (c *Client) ModifyLabels(ctx context.Context, ids, add, remove []string) error {
+	if c.dryRun {
+		slog.Info("dry run: skipped modify", "messages", len(ids), "add", add, "remove", remove)
+		return nil
+	}
+	for start := 0; start < len(ids); start += batchModifyLimit {
+		end := min(start+batchModifyLimit, len(ids))
+		req := &gmail.BatchModifyMessagesRequest{
+			Ids:            ids[start:end],
+			AddLabelIds:    add,
+			RemoveLabelIds: remove,
+		}
+		if err := c.svc.Users.Messages.BatchModify("me", req).Context(ctx).Do(); err != nil {
+			return wrapAPIError(err)
+		}
+	}
+	return nil
+}
+
+// MarkRead removes the UNREAD label from the given messages.
+func (c *Client) MarkRead(ctx context.Context, ids []string) error {
+	return c.ModifyLabels(ctx, ids, nil, []string{"UNREAD"})
+}
+
+// Archive removes the given messages from the inbox without deleting them.
+func (c *Client) Archive(ctx context.Context, ids []string) error {
+	return c.ModifyLabels(ctx, ids, nil, []string{"INBOX"})
+}
+
+// Trash moves the given messages to the trash, where Gmail deletes them
+// permanently after 30 days.
+func (c *Client) Trash(ctx context.Context, ids []string) error {
+	return c.ModifyLabels(ctx, ids, []string{"TRASH"}, []string{"INBOX"})
+}
+
+// Spam moves the given messages to spam, which also tells Gmail's filters
+// they are unwanted.
+func (c *Client) Spam(ctx context.Context, ids []string) error {
+	return c.ModifyLabels(ctx, ids, []string{"SPAM"}, []string{"INBOX"})
+}
+
+// ModifyThread adds and removes labels on every message of a thread, including
+// ones that arrive later for labels Gmail applies per thread, such as MUTED.
+// In dry-run mode the change is only logged.
+func (c *Client) ModifyThread(ctx context.Context, threadID string, add, remove []string) error {
+	if c.dryRun {
+		slog.Info("dry run: skipped thread modify", "thread", threadID, "add", add, "remove", remove)
+		return nil
+	}
+	req := &gmail.ModifyThreadRequest{AddLabelIds: add, RemoveLabelIds: remove}
+	_, err := c.svc.Users.Threads.Modify("me", threadID, req).Context(ctx).Do()
+	return wrapAPIError(err)
+}
+
+// MuteThread mutes a thread so later replies skip the inbox, and archives it,
+// as muting does in Gmail.
+func (c *Client) MuteThread(ctx context.Context, threadID string) error {
+	return c.ModifyThread(ctx, threadID, []string{"MUTED"}, []string{"INBOX"})
+}
+
+// UnmuteThread lets later replies to a thread reach the inbox again. The
+// thread itself stays where it is.
+func (c *Client) UnmuteThread(ctx context.Context, threadID string) error {
+	return c.ModifyThread(ctx, threadID, nil, []string{"MUTED"})
+}
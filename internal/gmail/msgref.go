@@ -0,0 +1,66 @@
+package gmailx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// apiIDRE matches the hexadecimal message and thread IDs the API uses, which
+// also appear in older Gmail web URLs.
+var apiIDRE = regexp.MustCompile(`^[0-9a-fA-F]{8,24}$`)
+
+// MessageRef is what a user pasted to open a message: an API message ID, or
+// the Message-ID header of the message, to be looked up with a search.
+type MessageRef struct {
+	ID        string
+	MessageID string
+}
+
+// ParseMessageRef interprets s as a Gmail web URL, an API message or thread
+// ID, or a Message-ID header such as <abc@example.com>. In a URL the ID is
+// the last part of the fragment, as in
+// https://mail.google.com/mail/u/0/#inbox/18c2f3a4b5d6e7f8. A thread ID
+// equals the ID of its first message, so it opens that message. Newer web
+// URLs carry an opaque token the API can't look up, which is reported as such.
+func ParseMessageRef(s string) (MessageRef, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return MessageRef{}, errors.New("enter a Gmail URL, message ID or Message-ID header")
+	}
+	if strings.Contains(s, "@") && !strings.Contains(s, "://") {
+		return MessageRef{MessageID: strings.Trim(s, "<>")}, nil
+	}
+	id := s
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil || u.Fragment == "" {
+			return MessageRef{}, fmt.Errorf("%q isn't a link to a Gmail message", s)
+		}
+		frag, _, _ := strings.Cut(u.Fragment, "?")
+		id = frag[strings.LastIndex(frag, "/")+1:]
+	}
+	if !apiIDRE.MatchString(id) {
+		if strings.Contains(s, "://") && len(id) > 24 {
+			return MessageRef{}, errors.New("this link uses Gmail's newer web ID, which the API can't look up; paste the Message-ID from \"Show original\" instead")
+		}
+		return MessageRef{}, fmt.Errorf("%q isn't a Gmail message ID", id)
+	}
+	return MessageRef{ID: strings.ToLower(id)}, nil
+}
+
+// FindByMessageID returns the API ID of the message with the given Message-ID
+// header, searching all mail including spam and trash.
+func (c *Client) FindByMessageID(ctx context.Context, messageID string) (string, error) {
+	p, err := c.ListInboxPage(ctx, 1, "rfc822msgid:"+messageID, "", "", true)
+	if err != nil {
+		return "", err
+	}
+	if len(p.IDs) == 0 {
+		return "", fmt.Errorf("no message has Message-ID <%s>", messageID)
+	}
+	return p.IDs[0], nil
+}
@@ -0,0 +1,76 @@
+package gmailx
+
+import "strings"
+
+// Categories are the inbox tabs Gmail sorts mail into, in the order they are shown.
+var Categories = []string{"primary", "social", "promotions", "updates", "forums"}
+
+// QueryCategory returns the category a query is restricted to with a
+// "category:" term, or "" when it has none.
+func QueryCategory(q string) string {
+	for _, f := range strings.Fields(q) {
+		if c, ok := strings.CutPrefix(strings.ToLower(f), "category:"); ok {
+			return c
+		}
+	}
+	return ""
+}
+
+// WithCategory returns q restricted to the given category, replacing any
+// category term it already has. An empty category removes the restriction.
+func WithCategory(q, category string) string {
+	fields := strings.Fields(q)
+	out := fields[:0]
+	for _, f := range fields {
+		if !strings.HasPrefix(strings.ToLower(f), "category:") {
+			out = append(out, f)
+		}
+	}
+	if category != "" {
+		out = append(out, "category:"+category)
+	}
+	return strings.Join(out, " ")
+}
+
+// NextCategory returns the category after current in Categories, cycling
+// through no category after the last one.
+func NextCategory(current string) string {
+	for i, c := range Categories {
+		if c == current {
+			if i+1 < len(Categories) {
+				return Categories[i+1]
+			}
+			return ""
+		}
+	}
+	return Categories[0]
+}
+
+// scopesMailbox reports whether a query picks its own mailbox with a label:
+// or in: term, in which case the inbox listing shouldn't also restrict it to
+// INBOX. Negated terms such as -label:foo only narrow the results, so they
+// don't count.
+func scopesMailbox(q string) bool {
+	for _, f := range strings.Fields(strings.ToLower(q)) {
+		if strings.HasPrefix(f, "label:") || strings.HasPrefix(f, "in:") {
+			return true
+		}
+	}
+	return false
+}
+
+// outgoingMailboxes are the label: and in: values of mailboxes holding mail
+// the user wrote, where the recipients say more about a row than the sender.
+var outgoingMailboxes = map[string]bool{"sent": true, "draft": true, "drafts": true}
+
+// IsOutgoingMailbox reports whether a query lists sent mail or drafts.
+func IsOutgoingMailbox(q string) bool {
+	for _, f := range strings.Fields(strings.ToLower(q)) {
+		for _, p := range []string{"label:", "in:"} {
+			if v, ok := strings.CutPrefix(f, p); ok && outgoingMailboxes[v] {
+				return true
+			}
+		}
+	}
+	return false
+}
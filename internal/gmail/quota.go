@@ -0,0 +1,162 @@
+package gmailx
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultQuotaPerSecond is Gmail's per-user quota: 15,000 units per minute.
+const DefaultQuotaPerSecond = 250
+
+// quotaCosts lists the quota units charged by the Gmail API for the requests this
+// app makes, keyed by method and the last path segment or collection name.
+// Anything not listed is charged defaultQuotaCost.
+var quotaCosts = []struct {
+	method string
+	suffix string
+	units  int
+}{
+	{"POST", "/messages/send", 100},
+	{"POST", "/messages/batchModify", 50},
+	{"POST", "/messages/import", 25},
+	{"GET", "/profile", 1},
+	{"GET", "/labels", 1},
+	{"GET", "/settings/vacation", 1},
+	{"PUT", "/settings/vacation", 5},
+	{"GET", "/settings/filters", 1},
+	{"GET", "/settings/sendAs", 1},
+}
+
+const defaultQuotaCost = 5
+
+// threadGetCost is the charge for fetching a thread, which isn't a fixed
+// suffix since it ends in the thread ID.
+const threadGetCost = 10
+
+// quotaCost returns the quota units a request will be charged.
+func quotaCost(req *http.Request) int {
+	p := req.URL.Path
+	for _, c := range quotaCosts {
+		if req.Method == c.method && strings.HasSuffix(p, c.suffix) {
+			return c.units
+		}
+	}
+	if req.Method == "GET" && strings.Contains(p, "/threads/") {
+		return threadGetCost
+	}
+	return defaultQuotaCost
+}
+
+// QuotaLimiter paces requests to stay under a quota. It is a token bucket
+// measured in quota units that refills at its rate up to one second's worth
+// of units, and can be paused until a time given by the server in a
+// Retry-After header. Clients spending the same user's quota should share one,
+// passed to New with WithQuotaLimiter.
+type QuotaLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	tokens  float64
+	last    time.Time
+	blocked time.Time
+}
+
+// NewQuotaLimiter returns a limiter allowing unitsPerSecond quota units a
+// second, or DefaultQuotaPerSecond if unitsPerSecond isn't positive.
+func NewQuotaLimiter(unitsPerSecond int) *QuotaLimiter {
+	if unitsPerSecond <= 0 {
+		unitsPerSecond = DefaultQuotaPerSecond
+	}
+	return &QuotaLimiter{rate: float64(unitsPerSecond), tokens: float64(unitsPerSecond)}
+}
+
+// reserve takes n units from the bucket, going into debt if needed, and returns
+// how long the caller must wait before its request may be sent.
+func (l *QuotaLimiter) reserve(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if d := l.blocked.Sub(now); d > wait {
+		wait = d
+	}
+	return wait
+}
+
+// wait blocks until n units are available or ctx is done.
+func (l *QuotaLimiter) wait(ctx context.Context, n int) error {
+	d := l.reserve(n)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause stops all requests until the given time.
+func (l *QuotaLimiter) pause(until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.blocked) {
+		l.blocked = until
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if s, err := strconv.Atoi(v); err == nil {
+		return time.Now().Add(time.Duration(s) * time.Second), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// pacingTransport is an http.RoundTripper that spends quota units from its
+// limiter before each request, so bursts such as loading a large inbox page are
+// spread out instead of being throttled by Gmail. When the server answers 429 or
+// 403 with a Retry-After header, every later request waits until that time.
+type pacingTransport struct {
+	base    http.RoundTripper
+	limiter *QuotaLimiter
+}
+
+// RoundTrip waits for quota, performs the request and honours any Retry-After.
+func (t pacingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.wait(req.Context(), quotaCost(req)); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
+		if until, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			slog.Warn("gmail api asked to slow down", "path", req.URL.Path, "until", until)
+			t.limiter.pause(until)
+		}
+	}
+	return resp, nil
+}
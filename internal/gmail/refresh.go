@@ -0,0 +1,112 @@
+package gmailx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// refreshAttempts is how many times a token refresh is tried before giving up.
+const refreshAttempts = 4
+
+// refreshBackoff is the wait before the first retry; it doubles after each one.
+const refreshBackoff = 500 * time.Millisecond
+
+// RefreshEvent reports the progress of a token refresh that is being retried.
+// Done is set once it has succeeded or been given up on.
+type RefreshEvent struct {
+	Attempt int
+	Err     error
+	Done    bool
+}
+
+// report sends ev to the events channel, if there is one. Events are dropped
+// rather than blocking a refresh when nobody is reading.
+func (s retryingTokenSource) report(ev RefreshEvent) {
+	if s.events == nil {
+		return
+	}
+	select {
+	case s.events <- ev:
+	default:
+		slog.Debug("dropped token refresh event", "attempt", ev.Attempt)
+	}
+}
+
+// IsReauthRequired reports whether err means the refresh token itself is no
+// longer valid, e.g. because access was revoked, so the user must log in again.
+// Only an invalid_grant answer from the token endpoint means that; other
+// rejected credentials, such as an unknown OAuth client or a 401 from an API
+// call, leave the refresh token as it is.
+func IsReauthRequired(err error) bool {
+	var rErr *oauth2.RetrieveError
+	return errors.As(err, &rErr) && rErr.ErrorCode == "invalid_grant"
+}
+
+// isTransientRefreshError reports whether a failed refresh is worth retrying:
+// network failures and server errors are, rejected grants are not.
+func isTransientRefreshError(err error) bool {
+	var rErr *oauth2.RetrieveError
+	if errors.As(err, &rErr) {
+		return rErr.Response != nil && rErr.Response.StatusCode >= http.StatusInternalServerError
+	}
+	return IsNetworkError(err)
+}
+
+// CurrentToken returns tok if it is still valid, or a token refreshed from it,
+// retrying transient failures like API requests do and reporting the retries
+// on events, which may be nil. Comparing the result with tok tells whether a
+// new token needs saving.
+func CurrentToken(ctx context.Context, cfg *oauth2.Config, tok *oauth2.Token, events chan<- RefreshEvent) (*oauth2.Token, error) {
+	return retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, tok), events: events}.Token()
+}
+
+// retryingTokenSource retries transient failures of the wrapped token source
+// with exponential backoff, so a brief network drop while the access token is
+// being refreshed doesn't end the session. Waiting between attempts stops
+// early when ctx is done. Retries are reported on events when it is set, and
+// backoff overrides refreshBackoff when set.
+type retryingTokenSource struct {
+	ctx     context.Context
+	base    oauth2.TokenSource
+	events  chan<- RefreshEvent
+	backoff time.Duration
+}
+
+// Token returns a valid token, refreshing it through the wrapped source.
+func (s retryingTokenSource) Token() (*oauth2.Token, error) {
+	wait := refreshBackoff
+	if s.backoff > 0 {
+		wait = s.backoff
+	}
+	for attempt := 1; ; attempt++ {
+		tok, err := s.base.Token()
+		if err == nil {
+			if attempt > 1 {
+				s.report(RefreshEvent{Attempt: attempt, Done: true})
+			}
+			return tok, nil
+		}
+		if attempt == refreshAttempts || !isTransientRefreshError(err) {
+			if attempt > 1 {
+				s.report(RefreshEvent{Attempt: attempt, Err: err, Done: true})
+			}
+			return nil, err
+		}
+		slog.Warn("token refresh failed; retrying", "attempt", attempt, "err", err)
+		s.report(RefreshEvent{Attempt: attempt, Err: err})
+		t := time.NewTimer(wait)
+		select {
+		case <-s.ctx.Done():
+			t.Stop()
+			s.report(RefreshEvent{Attempt: attempt, Err: s.ctx.Err(), Done: true})
+			return nil, s.ctx.Err()
+		case <-t.C:
+		}
+		wait *= 2
+	}
+}
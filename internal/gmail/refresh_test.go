@@ -0,0 +1,155 @@
+package gmailx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/googleapi"
+)
+
+// flakyTokenServer starts a token endpoint that answers each refresh with the
+// next of statuses, then succeeds. It returns the OAuth config pointing at it
+// and the number of refreshes it has seen.
+func flakyTokenServer(t *testing.T, statuses ...int) (*oauth2.Config, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		w.Header().Set("Content-Type", "application/json")
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			if statuses[n-1] == http.StatusBadRequest {
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`))
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(srv.Close)
+	cfg := &oauth2.Config{
+		ClientID: "client",
+		Endpoint: oauth2.Endpoint{TokenURL: srv.URL, AuthStyle: oauth2.AuthStyleInParams},
+	}
+	return cfg, &calls
+}
+
+// expired is a token whose access token has to be refreshed before use.
+func expired() *oauth2.Token {
+	return &oauth2.Token{AccessToken: "stale", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+}
+
+func TestRetryingTokenSourceRetriesTransientErrors(t *testing.T) {
+	cfg, calls := flakyTokenServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+	ctx := context.Background()
+	src := retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, expired()), backoff: time.Millisecond}
+
+	tok, err := src.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if tok.AccessToken != "fresh" {
+		t.Errorf("AccessToken = %q, want fresh", tok.AccessToken)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("refreshes = %d, want 3", got)
+	}
+}
+
+func TestRetryingTokenSourceGivesUpOnInvalidGrant(t *testing.T) {
+	cfg, calls := flakyTokenServer(t, http.StatusBadRequest)
+	ctx := context.Background()
+	src := retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, expired()), backoff: time.Millisecond}
+
+	_, err := src.Token()
+	if err == nil {
+		t.Fatal("Token() error = nil, want invalid_grant")
+	}
+	if !IsReauthRequired(err) {
+		t.Errorf("IsReauthRequired(%v) = false, want true", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("refreshes = %d, want 1", got)
+	}
+}
+
+func TestRetryingTokenSourceStopsAfterAttempts(t *testing.T) {
+	statuses := make([]int, refreshAttempts+1)
+	for i := range statuses {
+		statuses[i] = http.StatusInternalServerError
+	}
+	cfg, calls := flakyTokenServer(t, statuses...)
+	ctx := context.Background()
+	src := retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, expired()), backoff: time.Millisecond}
+
+	if _, err := src.Token(); err == nil {
+		t.Fatal("Token() error = nil, want the last server error")
+	}
+	if got := calls.Load(); got != refreshAttempts {
+		t.Errorf("refreshes = %d, want %d", got, refreshAttempts)
+	}
+}
+
+func TestRetryingTokenSourceStopsWaitingWhenCancelled(t *testing.T) {
+	cfg, _ := flakyTokenServer(t, http.StatusServiceUnavailable)
+	ctx, cancel := context.WithCancel(context.Background())
+	src := retryingTokenSource{ctx: ctx, base: cfg.TokenSource(context.Background(), expired()), backoff: time.Hour}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	_, err := src.Token()
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Token() error = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Token() took %v after cancelling, want it to stop waiting", d)
+	}
+}
+
+func TestRetryingTokenSourceReportsOnItsChannel(t *testing.T) {
+	cfg, _ := flakyTokenServer(t, http.StatusServiceUnavailable)
+	ctx := context.Background()
+	events := make(chan RefreshEvent, 4)
+	src := retryingTokenSource{ctx: ctx, base: cfg.TokenSource(ctx, expired()), events: events, backoff: time.Millisecond}
+
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	close(events)
+	var got []RefreshEvent
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) != 2 || got[0].Done || got[0].Err == nil || !got[1].Done || got[1].Err != nil {
+		t.Errorf("events = %+v, want a failed attempt and then success", got)
+	}
+}
+
+func TestIsReauthRequiredOnlyForInvalidGrant(t *testing.T) {
+	unauthorized := &http.Response{StatusCode: http.StatusUnauthorized}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid grant", &oauth2.RetrieveError{ErrorCode: "invalid_grant"}, true},
+		{"wrapped invalid grant", wrapAPIError(fmt.Errorf("get: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant"})), true},
+		{"invalid client", wrapAPIError(&oauth2.RetrieveError{Response: unauthorized, ErrorCode: "invalid_client"}), false},
+		{"API call", wrapAPIError(&googleapi.Error{Code: http.StatusUnauthorized}), false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := IsReauthRequired(tt.err); got != tt.want {
+			t.Errorf("%s: IsReauthRequired(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package gmailx
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// Body formats an outgoing message can be sent in.
+const (
+	// FormatPlain sends the body as text/plain.
+	FormatPlain = "plain"
+	// FormatHTML sends the body as text/html, escaped and with its line
+	// breaks kept.
+	FormatHTML = "html"
+	// FormatAlternative sends multipart/alternative with both a plain-text
+	// and an HTML part, so clients show whichever they render best.
+	FormatAlternative = "alternative"
+)
+
+type OutgoingMessage struct {
+	To      string
+	Subject string
+	Body    string
+	// Format is how Body is sent: FormatPlain, FormatHTML or
+	// FormatAlternative. Empty means FormatPlain.
+	Format string
+	// Attached is a raw RFC 822 message to attach as message/rfc822, for
+	// forwarding a message whole; nil for none.
+	Attached []byte
+	// ThreadID, InReplyTo and References make the message a reply: Gmail
+	// files it in the thread, and the headers let other clients do the same.
+	ThreadID   string
+	InReplyTo  string
+	References string
+}
+
+// maxHeaderLine is the line length RFC 5322 recommends headers be folded to.
+const maxHeaderLine = 78
+
+// foldHeader renders a header field, folding it so no line is longer than
+// maxHeaderLine where possible, as RFC 5322 recommends. A fold is a line break
+// inserted before a space or tab already in the value, at the last one that
+// keeps the line short enough, so unfolding gives back the value byte for
+// byte. Runs of text without spaces that are longer than that are left whole.
+func foldHeader(name, value string) string {
+	var b strings.Builder
+	rest := name + ": " + value
+	// The first line keeps the field name and the start of the value together.
+	after := len(name) + 1
+	for len(rest) > maxHeaderLine {
+		i := foldPoint(rest, after)
+		if i < 0 {
+			break
+		}
+		b.WriteString(rest[:i] + "\r\n")
+		rest = rest[i:]
+		after = 0
+	}
+	b.WriteString(rest + "\r\n")
+	return b.String()
+}
+
+// foldPoint returns the index of the space or tab after index after in line
+// to fold before: the last one within maxHeaderLine, or the first one beyond
+// it if there is none. A fold never leaves a line of only whitespace. Returns
+// -1 if line can't be folded.
+func foldPoint(line string, after int) int {
+	canFold := func(i int) bool {
+		return (line[i] == ' ' || line[i] == '\t') && strings.TrimLeft(line[:i], " \t") != ""
+	}
+	for i := maxHeaderLine; i > after; i-- {
+		if canFold(i) {
+			return i
+		}
+	}
+	for i := max(maxHeaderLine+1, after+1); i < len(line); i++ {
+		if canFold(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatAddressList normalizes a comma-separated list of recipients, encoding
+// non-ASCII display names and separating the addresses with ", ". A list
+// net/mail can't parse is passed through with only its spacing tidied, and
+// Gmail rejects it if it is really invalid.
+func formatAddressList(list string) string {
+	addrs, err := mail.ParseAddressList(list)
+	if err != nil {
+		var parts []string
+		for _, p := range strings.Split(list, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// BuildMessage renders an outgoing message as RFC 822 bytes with a UTF-8
+// body in the message's Format. The subject is MIME-encoded so non-ASCII text
+// survives, and the body is quoted-printable so long lines aren't broken in
+// transit. Long recipient lists and subjects are folded over several lines.
+// With an attached message the result is multipart/mixed, the body followed
+// by the attachment. Gmail fills in the From header with the authenticated
+// user's address.
+func BuildMessage(m *OutgoingMessage) []byte {
+	var b bytes.Buffer
+	b.WriteString(foldHeader("To", formatAddressList(m.To)))
+	b.WriteString(foldHeader("Subject", mime.QEncoding.Encode("utf-8", m.Subject)))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	if m.InReplyTo != "" {
+		b.WriteString(foldHeader("In-Reply-To", m.InReplyTo))
+	}
+	if m.References != "" {
+		b.WriteString(foldHeader("References", m.References))
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+	header, body := bodyPart(m.Body, m.Format)
+	if m.Attached == nil {
+		for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if v := header.Get(k); v != "" {
+				b.WriteString(k + ": " + v + "\r\n")
+			}
+		}
+		b.WriteString("\r\n")
+		b.Write(body)
+		return b.Bytes()
+	}
+	mw := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+	pw, _ := mw.CreatePart(header)
+	_, _ = pw.Write(body)
+	// RFC 2046 doesn't allow encoding message/rfc822 parts, so the message
+	// is attached as is.
+	pw, _ = mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":        {"message/rfc822"},
+		"Content-Disposition": {`attachment; filename="original.eml"`},
+	})
+	_, _ = pw.Write(m.Attached)
+	_ = mw.Close()
+	return b.Bytes()
+}
+
+// bodyPart renders body as a MIME part in the given format, returning the
+// part's header and its encoded content.
+func bodyPart(body, format string) (textproto.MIMEHeader, []byte) {
+	var b bytes.Buffer
+	switch format {
+	case FormatHTML:
+		writeQP(&b, htmlBody(body))
+		return textPartHeader("text/html"), b.Bytes()
+	case FormatAlternative:
+		// Parts go from least to most preferred, as RFC 2046 asks.
+		mw := multipart.NewWriter(&b)
+		pw, _ := mw.CreatePart(textPartHeader("text/plain"))
+		writeQP(pw, body)
+		pw, _ = mw.CreatePart(textPartHeader("text/html"))
+		writeQP(pw, htmlBody(body))
+		_ = mw.Close()
+		return textproto.MIMEHeader{
+			"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+		}, b.Bytes()
+	}
+	writeQP(&b, body)
+	return textPartHeader("text/plain"), b.Bytes()
+}
+
+// textPartHeader is the header of a quoted-printable UTF-8 text part of the
+// given media type.
+func textPartHeader(mediaType string) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Type":              {mediaType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	}
+}
+
+// htmlBody wraps a plain-text body in a minimal HTML document, escaping it
+// and turning its line breaks into <br> so it reads as typed.
+func htmlBody(body string) string {
+	lines := strings.Split(html.EscapeString(body), "\n")
+	return "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"></head><body>\n" +
+		strings.Join(lines, "<br>\n") + "\n</body></html>\n"
+}
+
+// writeQP writes body to w quoted-printable, with CRLF line endings.
+func writeQP(w io.Writer, body string) {
+	qp := quotedprintable.NewWriter(w)
+	_, _ = qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n")))
+	_ = qp.Close()
+}
+
+// Send sends an outgoing message from the authenticated user's account.
+// Returns the ID of the sent message.
+func (c *Client) Send(ctx context.Context, m *OutgoingMessage) (string, error) {
+	raw := base64.URLEncoding.EncodeToString(BuildMessage(m))
+	msg, err := c.svc.Users.Messages.Send("me", &gmail.Message{Raw: raw, ThreadId: m.ThreadID}).Context(ctx).Do()
+	if err != nil {
+		return "", wrapAPIError(err)
+	}
+	return msg.Id, nil
+}
@@ -0,0 +1,179 @@
+package gmailx_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	gmailx "gmail-tui/internal/gmail"
+)
+
+// readMessage parses raw as an RFC 822 message.
+func readMessage(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("mail.ReadMessage() error = %v\n%s", err, raw)
+	}
+	return msg
+}
+
+func TestBuildMessageFoldsLongRecipientLists(t *testing.T) {
+	var to []string
+	for i := range 30 {
+		to = append(to, fmt.Sprintf("Recipient %d <recipient%d@example.com>", i, i))
+	}
+	to = append(to, "José Núñez <jose@example.com>")
+	subject := "A subject long enough that it has to be folded onto a second line of the header"
+	raw := gmailx.BuildMessage(&gmailx.OutgoingMessage{
+		To:      strings.Join(to, ","),
+		Subject: subject,
+		Body:    "Hi all",
+	})
+
+	header, _, _ := bytes.Cut(raw, []byte("\r\n\r\n"))
+	for _, line := range strings.Split(string(header), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("header line is %d characters, want at most 78: %q", len(line), line)
+		}
+	}
+
+	msg := readMessage(t, raw)
+	if got := msg.Header.Get("Subject"); got != subject {
+		t.Errorf("Subject = %q, want %q", got, subject)
+	}
+	addrs, err := msg.Header.AddressList("To")
+	if err != nil {
+		t.Fatalf("AddressList(To) error = %v", err)
+	}
+	if len(addrs) != len(to) {
+		t.Fatalf("To has %d addresses, want %d", len(addrs), len(to))
+	}
+	for i, want := range to {
+		w, _ := mail.ParseAddress(want)
+		if addrs[i].Name != w.Name || addrs[i].Address != w.Address {
+			t.Errorf("To[%d] = %v, want %v", i, addrs[i], w)
+		}
+	}
+}
+
+func TestBuildMessageFoldingKeepsWhitespace(t *testing.T) {
+	subject := strings.Repeat("Q3  results:   see the  attached figures. ", 4)
+	raw := gmailx.BuildMessage(&gmailx.OutgoingMessage{To: "a@example.com", Subject: subject, Body: "Hi"})
+
+	header, _, _ := bytes.Cut(raw, []byte("\r\n\r\n"))
+	for _, line := range strings.Split(string(header), "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("header line is %d characters, want at most 78: %q", len(line), line)
+		}
+	}
+	if strings.Contains(string(header), subject) {
+		t.Fatal("Subject wasn't folded")
+	}
+	// Unfolding only removes the inserted line breaks.
+	unfolded := strings.NewReplacer("\r\n ", " ", "\r\n\t", "\t").Replace(string(header))
+	if !strings.Contains(unfolded, "\r\nSubject: "+subject+"\r\n") {
+		t.Errorf("unfolded header = %q, want the Subject %q unchanged", unfolded, subject)
+	}
+}
+
+// readPart returns the media type, params and decoded body of a part.
+func readPart(t *testing.T, p *multipart.Part) (string, map[string]string, string) {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("ParseMediaType(%q) error = %v", p.Header.Get("Content-Type"), err)
+	}
+	body, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatalf("reading %s part: %v", mediaType, err)
+	}
+	return mediaType, params, string(body)
+}
+
+// checkAlternative checks that r holds a plain-text part and then an HTML
+// part, both carrying text.
+func checkAlternative(t *testing.T, r *multipart.Reader, text string) {
+	t.Helper()
+	for _, want := range []string{"text/plain", "text/html"} {
+		p, err := r.NextPart()
+		if err != nil {
+			t.Fatalf("reading %s part: %v", want, err)
+		}
+		mediaType, params, body := readPart(t, p)
+		if mediaType != want || !strings.EqualFold(params["charset"], "utf-8") {
+			t.Errorf("part is %s; charset=%s, want %s; charset=UTF-8", mediaType, params["charset"], want)
+		}
+		if !strings.Contains(body, text) {
+			t.Errorf("%s part = %q, want it to contain %q", want, body, text)
+		}
+	}
+	if _, err := r.NextPart(); err != io.EOF {
+		t.Errorf("NextPart() after the HTML part = %v, want io.EOF", err)
+	}
+}
+
+func TestBuildMessageFormats(t *testing.T) {
+	text := "Café & crème"
+	t.Run("html", func(t *testing.T) {
+		msg := readMessage(t, gmailx.BuildMessage(&gmailx.OutgoingMessage{To: "a@example.com", Body: text, Format: gmailx.FormatHTML}))
+		if ct := msg.Header.Get("Content-Type"); ct != "text/html; charset=UTF-8" {
+			t.Errorf("Content-Type = %q, want text/html; charset=UTF-8", ct)
+		}
+		body, _ := io.ReadAll(msg.Body)
+		if !strings.Contains(string(body), "Caf=C3=A9 &amp; cr=C3=A8me") {
+			t.Errorf("body = %q, want the text escaped and quoted-printable", body)
+		}
+	})
+
+	t.Run("alternative", func(t *testing.T) {
+		msg := readMessage(t, gmailx.BuildMessage(&gmailx.OutgoingMessage{To: "a@example.com", Body: text, Format: gmailx.FormatAlternative}))
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/alternative" {
+			t.Fatalf("Content-Type = %q, want multipart/alternative", msg.Header.Get("Content-Type"))
+		}
+		checkAlternative(t, multipart.NewReader(msg.Body, params["boundary"]), "crème")
+	})
+
+	t.Run("alternative with an attached message", func(t *testing.T) {
+		attached := "Subject: Original\r\n\r\nThe original message.\r\n"
+		msg := readMessage(t, gmailx.BuildMessage(&gmailx.OutgoingMessage{
+			To:       "a@example.com",
+			Body:     text,
+			Format:   gmailx.FormatAlternative,
+			Attached: []byte(attached),
+		}))
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/mixed" {
+			t.Fatalf("Content-Type = %q, want multipart/mixed", msg.Header.Get("Content-Type"))
+		}
+		mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+		p, err := mixed.NextPart()
+		if err != nil {
+			t.Fatalf("reading the body part: %v", err)
+		}
+		mediaType, params, err = mime.ParseMediaType(p.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/alternative" {
+			t.Fatalf("first part is %q, want multipart/alternative", p.Header.Get("Content-Type"))
+		}
+		checkAlternative(t, multipart.NewReader(p, params["boundary"]), "crème")
+
+		p, err = mixed.NextPart()
+		if err != nil {
+			t.Fatalf("reading the attached part: %v", err)
+		}
+		mediaType, _, body := readPart(t, p)
+		if mediaType != "message/rfc822" || body != attached {
+			t.Errorf("second part = %s %q, want message/rfc822 %q", mediaType, body, attached)
+		}
+		if _, err := mixed.NextPart(); err != io.EOF {
+			t.Errorf("NextPart() after the attachment = %v, want io.EOF", err)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package gmailx
+
+import (
+	"context"
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+type VacationSettings struct {
+	Enabled bool
+	Subject string
+	Message string
+	Start   time.Time
+	End     time.Time
+}
+
+// msToTime converts a Gmail API millisecond timestamp to a time.Time.
+// A zero timestamp means "not set" and maps to the zero time.
+func msToTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+// timeToMs converts a time.Time to a Gmail API millisecond timestamp.
+// The zero time maps to 0, which the API treats as "not set".
+func timeToMs(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// GetVacation fetches the user's vacation responder (auto-reply) settings.
+// Start and End are zero when the responder has no date restriction.
+func (c *Client) GetVacation(ctx context.Context) (*VacationSettings, error) {
+	v, err := c.svc.Users.Settings.GetVacation("me").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	return &VacationSettings{
+		Enabled: v.EnableAutoReply,
+		Subject: v.ResponseSubject,
+		Message: v.ResponseBodyPlainText,
+		Start:   msToTime(v.StartTime),
+		End:     msToTime(v.EndTime),
+	}, nil
+}
+
+// UpdateVacation replaces the user's vacation responder settings.
+// EnableAutoReply is always sent so that disabling the responder takes effect,
+// since the API would otherwise drop the false value from the request.
+func (c *Client) UpdateVacation(ctx context.Context, s *VacationSettings) error {
+	v := &gmail.VacationSettings{
+		EnableAutoReply:       s.Enabled,
+		ResponseSubject:       s.Subject,
+		ResponseBodyPlainText: s.Message,
+		StartTime:             timeToMs(s.Start),
+		EndTime:               timeToMs(s.End),
+		ForceSendFields:       []string{"EnableAutoReply"},
+	}
+	_, err := c.svc.Users.Settings.UpdateVacation("me", v).Context(ctx).Do()
+	return wrapAPIError(err)
+}
+
+type Filter struct {
+	ID string
+
+	From          string
+	To            string
+	Subject       string
+	Query         string
+	NegatedQuery  string
+	HasAttachment bool
+
+	AddLabelIDs    []string
+	RemoveLabelIDs []string
+	Forward        string
+}
+
+// ListFilters fetches the user's server-side message filters, flattening each
+// filter's criteria and actions into a Filter.
+func (c *Client) ListFilters(ctx context.Context) ([]Filter, error) {
+	resp, err := c.svc.Users.Settings.Filters.List("me").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	filters := make([]Filter, 0, len(resp.Filter))
+	for _, f := range resp.Filter {
+		out := Filter{ID: f.Id}
+		if cr := f.Criteria; cr != nil {
+			out.From = cr.From
+			out.To = cr.To
+			out.Subject = cr.Subject
+			out.Query = cr.Query
+			out.NegatedQuery = cr.NegatedQuery
+			out.HasAttachment = cr.HasAttachment
+		}
+		if a := f.Action; a != nil {
+			out.AddLabelIDs = a.AddLabelIds
+			out.RemoveLabelIDs = a.RemoveLabelIds
+			out.Forward = a.Forward
+		}
+		filters = append(filters, out)
+	}
+	return filters, nil
+}
+
+type Signature struct {
+	Email string
+	Text  string
+}
+
+var sigBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(div|p)>`)
+var sigTag = regexp.MustCompile(`<[^>]*>`)
+
+// signatureToText converts the HTML signature Gmail stores into plain text
+// by turning line breaks and block ends into newlines and dropping other tags.
+func signatureToText(s string) string {
+	s = sigBreak.ReplaceAllString(s, "\n")
+	s = sigTag.ReplaceAllString(s, "")
+	return strings.TrimRight(html.UnescapeString(s), "\n")
+}
+
+// signatureToHTML converts a plain-text signature into the HTML form Gmail expects,
+// escaping markup characters and joining lines with <br>.
+func signatureToHTML(s string) string {
+	lines := strings.Split(html.EscapeString(s), "\n")
+	return strings.Join(lines, "<br>")
+}
+
+// primarySendAs returns the address of the user's primary send-as alias,
+// which is the one whose signature Gmail applies by default.
+func (c *Client) primarySendAs(ctx context.Context) (string, error) {
+	resp, err := c.svc.Users.Settings.SendAs.List("me").Context(ctx).Do()
+	if err != nil {
+		return "", wrapAPIError(err)
+	}
+	for _, sa := range resp.SendAs {
+		if sa.IsPrimary {
+			return sa.SendAsEmail, nil
+		}
+	}
+	return "", errors.New("no primary send-as address found")
+}
+
+// GetSignature fetches the signature of the primary send-as alias as plain text.
+func (c *Client) GetSignature(ctx context.Context) (*Signature, error) {
+	email, err := c.primarySendAs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sa, err := c.svc.Users.Settings.SendAs.Get("me", email).Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+	return &Signature{Email: email, Text: signatureToText(sa.Signature)}, nil
+}
+
+// UpdateSignature replaces the signature of the given send-as alias with the
+// plain-text signature, converted to HTML. Signature is always sent so that
+// clearing it takes effect.
+func (c *Client) UpdateSignature(ctx context.Context, sig *Signature) error {
+	sa := &gmail.SendAs{
+		Signature:       signatureToHTML(sig.Text),
+		ForceSendFields: []string{"Signature"},
+	}
+	_, err := c.svc.Users.Settings.SendAs.Patch("me", sig.Email, sa).Context(ctx).Do()
+	return wrapAPIError(err)
+}
@@ -0,0 +1,168 @@
+package gmailx
+
+import (
+	"context"
+	"net/mail"
+	"slices"
+	"sync"
+	"time"
+)
+
+// threadWorkers bounds how many messages of a thread are fetched concurrently.
+const threadWorkers = 4
+
+type Thread struct {
+	ID       string
+	Messages []EmailDetail
+	// Failed holds the IDs of messages that couldn't be loaded.
+	Failed []string
+}
+
+// parseDate parses an RFC 5322 Date header. The boolean is false when the
+// header is missing or malformed.
+func parseDate(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := mail.ParseDate(s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// sortByDate orders messages chronologically by their Date header, with
+// messages whose date is missing or malformed last. The sort is stable, so
+// messages with equal dates, and the undated ones, keep the order the API
+// returned.
+func sortByDate(msgs []EmailDetail) {
+	slices.SortStableFunc(msgs, func(a, b EmailDetail) int {
+		ta, oka := parseDate(a.Date)
+		tb, okb := parseDate(b.Date)
+		switch {
+		case oka && okb:
+			return ta.Compare(tb)
+		case oka:
+			return -1
+		case okb:
+			return 1
+		}
+		return 0
+	})
+}
+
+// GetThread fetches every message in a thread with full bodies. Messages are loaded
+// concurrently by a small worker pool and returned in chronological order. Messages
+// that fail to load are reported in Failed rather than failing the whole thread;
+// an error is returned only if the thread itself can't be listed or nothing loads.
+// Each body is capped at maxBody bytes as in GetDetail.
+func (c *Client) GetThread(ctx context.Context, threadID string, maxBody int) (*Thread, error) {
+	t, err := c.svc.Users.Threads.Get("me", threadID).Format("minimal").Fields("id,messages/id").Context(ctx).Do()
+	if err != nil {
+		return nil, wrapAPIError(err)
+	}
+
+	type result struct {
+		idx int
+		d   *EmailDetail
+		err error
+	}
+	jobs := make(chan int)
+	results := make(chan result, len(t.Messages))
+
+	var wg sync.WaitGroup
+	for range min(threadWorkers, len(t.Messages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				d, err := c.GetDetail(ctx, t.Messages[i].Id, maxBody)
+				results <- result{idx: i, d: d, err: err}
+			}
+		}()
+	}
+	for i := range t.Messages {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	close(results)
+
+	loaded := make([]*EmailDetail, len(t.Messages))
+	out := &Thread{ID: threadID}
+	var firstErr error
+	for r := range results {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		loaded[r.idx] = r.d
+	}
+	for i, d := range loaded {
+		if d == nil {
+			out.Failed = append(out.Failed, t.Messages[i].Id)
+			continue
+		}
+		out.Messages = append(out.Messages, *d)
+	}
+	if len(out.Messages) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	sortByDate(out.Messages)
+	return out, nil
+}
+
+// ThreadSizes returns how many messages each of the given threads has. Only
+// the message IDs are requested, a few threads at a time. Threads that fail
+// to load are left out; an error is returned only if none load.
+func (c *Client) ThreadSizes(ctx context.Context, threadIDs []string) (map[string]int, error) {
+	return fetchEach(threadIDs, threadWorkers, func(id string) (int, error) {
+		t, err := c.svc.Users.Threads.Get("me", id).Format("minimal").Fields("messages/id").Context(ctx).Do()
+		if err != nil {
+			return 0, err
+		}
+		return len(t.Messages), nil
+	})
+}
+
+// fetchEach calls fetch for each of ids on up to workers goroutines and
+// returns the results by ID. IDs whose fetch fails are left out; the first
+// error is returned only if every fetch failed.
+func fetchEach[T any](ids []string, workers int, fetch func(id string) (T, error)) (map[string]T, error) {
+	out := make(map[string]T, len(ids))
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	jobs := make(chan string)
+	for range min(workers, len(ids)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				v, err := fetch(id)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = wrapAPIError(err)
+					}
+				} else {
+					out[id] = v
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+	if len(out) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
@@ -0,0 +1,28 @@
+package gmailx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortByDatePutsUndatedLast(t *testing.T) {
+	msgs := []EmailDetail{
+		{ID: "undated-1"},
+		{ID: "wed", Date: "Wed, 03 Jan 2024 10:00:00 +0000"},
+		{ID: "bad", Date: "not a date"},
+		{ID: "mon", Date: "Mon, 01 Jan 2024 10:00:00 +0000"},
+		{ID: "undated-2"},
+		{ID: "tue", Date: "Tue, 02 Jan 2024 10:00:00 +0000"},
+		{ID: "mon-again", Date: "Mon, 01 Jan 2024 10:00:00 +0000"},
+	}
+	sortByDate(msgs)
+
+	var got []string
+	for _, m := range msgs {
+		got = append(got, m.ID)
+	}
+	want := []string{"mon", "mon-again", "tue", "wed", "undated-1", "bad", "undated-2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sortByDate order = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+package gmailx
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// loggingTransport is an http.RoundTripper that records every Gmail API request
+// with its status and duration at debug level, and failures at warn level.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip performs the request with the wrapped transport and logs the outcome.
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		slog.Warn("gmail api request failed", "method", req.Method, "path", req.URL.Path, "duration", elapsed, "err", err)
+		return nil, err
+	}
+	level := slog.LevelDebug
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	slog.Log(req.Context(), level, "gmail api request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", elapsed)
+	return resp, nil
+}
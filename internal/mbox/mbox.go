@@ -0,0 +1,125 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// maxLine is the longest line the reader accepts. Lines in well-formed mail are
+// short, so anything larger is treated as a corrupt file rather than buffered.
+const maxLine = 1 << 20
+
+var ErrNotMbox = errors.New("not an mbox file: missing \"From \" separator line")
+
+// Reader streams messages out of an mbox file one at a time, so arbitrarily
+// large files can be processed without loading them into memory.
+type Reader struct {
+	r       *bufio.Reader
+	next    []byte // separator line that starts the next message, if already read
+	started bool
+	line    int
+}
+
+// NewReader returns a Reader that reads mbox data from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// isSeparator reports whether line is an mbox "From " separator line.
+func isSeparator(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("From "))
+}
+
+// unquote reverses the ">From " escaping applied to body lines when the mbox
+// was written, removing one level of '>' quoting (mboxrd).
+func unquote(line []byte) []byte {
+	i := 0
+	for i < len(line) && line[i] == '>' {
+		i++
+	}
+	if i > 0 && bytes.HasPrefix(line[i:], []byte("From ")) {
+		return line[1:]
+	}
+	return line
+}
+
+// readLine reads one line including its terminator, normalizing CRLF to LF.
+func (r *Reader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxLine {
+			return nil, errors.New("mbox line too long")
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return line, err
+		}
+		break
+	}
+	r.line++
+	if n := len(line); n >= 2 && line[n-2] == '\r' {
+		line = append(line[:n-2], '\n')
+	}
+	return line, nil
+}
+
+// Next returns the next message as raw RFC 822 bytes, without its "From "
+// separator line, along with the line number the message starts on.
+// Returns io.EOF when there are no more messages.
+func (r *Reader) Next() (msg []byte, line int, err error) {
+	if !r.started {
+		r.started = true
+		for {
+			l, err := r.readLine()
+			if len(l) > 0 && len(bytes.TrimSpace(l)) > 0 {
+				if !isSeparator(l) {
+					return nil, 0, ErrNotMbox
+				}
+				r.next = l
+				break
+			}
+			if err != nil {
+				if err == io.EOF {
+					return nil, 0, io.EOF
+				}
+				return nil, 0, err
+			}
+		}
+	}
+	if r.next == nil {
+		return nil, 0, io.EOF
+	}
+	r.next = nil
+	start := r.line + 1
+
+	var buf bytes.Buffer
+	for {
+		l, err := r.readLine()
+		if len(l) > 0 {
+			if isSeparator(l) {
+				r.next = l
+				break
+			}
+			buf.Write(unquote(l))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, start, err
+		}
+	}
+	// The blank line written before each separator belongs to the mbox format,
+	// not to the message.
+	b := buf.Bytes()
+	if bytes.HasSuffix(b, []byte("\n\n")) {
+		b = b[:len(b)-1]
+	}
+	return b, start, nil
+}
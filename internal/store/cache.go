@@ -0,0 +1,116 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Cache is a small on-disk JSON cache under ~/.gmail-tui/cache/, used to keep
+// recently fetched mail readable when the Gmail API can't be reached.
+type Cache struct {
+	dir string
+}
+
+// NewCache creates a Cache and ensures the cache directory exists with
+// 0700 permissions. Returns an error if the directory cannot be created.
+func NewCache() (*Cache, error) {
+	base, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(base, "cache")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// path maps a cache key to its file. Path separators are replaced so that
+// keys built from message IDs can never escape the cache directory.
+func (c *Cache) path(key string) string {
+	key = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(key)
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Put serializes v as JSON and stores it under key with 0600 permissions,
+// replacing any previous entry.
+func (c *Cache) Put(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(c.path(key), b, 0600)
+}
+
+// Get reads the entry stored under key into v.
+// Returns an error if the entry doesn't exist or cannot be parsed.
+func (c *Cache) Get(key string, v any) error {
+	b, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Delete removes the entry stored under key. A missing entry is not an error.
+func (c *Cache) Delete(key string) error {
+	err := os.Remove(c.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Prune evicts entries last written more than maxAge ago, then the oldest
+// entries beyond maxEntries, and returns how many it removed. A zero limit
+// is not applied.
+func (c *Cache) Prune(maxEntries int, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return 0, err
+	}
+	type file struct {
+		name string
+		mod  time.Time
+	}
+	var files []file
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, file{e.Name(), info.ModTime()})
+	}
+	slices.SortFunc(files, func(a, b file) int { return b.mod.Compare(a.mod) })
+
+	keep := len(files)
+	if maxEntries > 0 {
+		keep = min(keep, maxEntries)
+	}
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge)
+		if i := slices.IndexFunc(files[:keep], func(f file) bool { return f.mod.Before(cutoff) }); i >= 0 {
+			keep = i
+		}
+	}
+	removed := 0
+	for _, f := range files[keep:] {
+		if err := os.Remove(filepath.Join(c.dir, f.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
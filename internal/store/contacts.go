@@ -0,0 +1,160 @@
+package store
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxContactMessages caps how many message IDs are remembered per contact.
+// The count of them is how often the contact was seen, so frequency stops
+// growing there.
+const maxContactMessages = 50
+
+// Contact is an address seen in the From or To header of a message.
+type Contact struct {
+	Name    string `json:"name,omitempty"`
+	Address string `json:"address"`
+	// LastSeen is the date of the newest message the address was seen on.
+	LastSeen time.Time `json:"lastSeen"`
+	// Messages are the IDs of the messages the address was seen on, so a
+	// message listed again on every refresh is only counted once.
+	Messages []string `json:"messages,omitempty"`
+}
+
+// score ranks c for suggestions: how many messages it was seen on, halved
+// for every month since it was last seen.
+func (c Contact) score(now time.Time) float64 {
+	months := max(now.Sub(c.LastSeen).Hours()/(24*30), 0)
+	return float64(len(c.Messages)) / (1 + months)
+}
+
+// matches reports whether the address, the name or a word of the name starts
+// with prefix, which must be lower case.
+func (c Contact) matches(prefix string) bool {
+	if strings.HasPrefix(strings.ToLower(c.Address), prefix) || strings.HasPrefix(strings.ToLower(c.Name), prefix) {
+		return true
+	}
+	for _, w := range strings.Fields(strings.ToLower(c.Name)) {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contacts are the correspondents seen in one account's fetched mail, keyed by
+// lower-case address, in ~/.gmail-tui/contacts-<account>.json. They are mined from headers the app
+// already fetches, so they need no extra scope. A nil *Contacts is empty and
+// ignores additions.
+type Contacts struct {
+	path     string
+	contacts map[string]*Contact
+	dirty    bool
+}
+
+// LoadContacts reads the contacts file of the account with the given address,
+// starting empty if there isn't one yet.
+func LoadContacts(account string) (*Contacts, error) {
+	if account == "" || strings.ContainsAny(account, `/\`) {
+		return nil, fmt.Errorf("invalid account address %q", account)
+	}
+	base, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	name := "contacts-" + strings.ToLower(account) + ".json"
+	c := &Contacts{path: filepath.Join(base, name), contacts: map[string]*Contact{}}
+	b, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.contacts); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Add records that address, with display name name, was seen on the message
+// with the given ID and date. The name of the newest message wins; an empty
+// name never replaces a known one. Call Save to write the changes.
+func (c *Contacts) Add(name, address, msgID string, date time.Time) {
+	if c == nil || address == "" {
+		return
+	}
+	key := strings.ToLower(address)
+	ct := c.contacts[key]
+	if ct == nil {
+		ct = &Contact{Address: address}
+		c.contacts[key] = ct
+		c.dirty = true
+	}
+	if name != "" && (ct.Name == "" || !date.Before(ct.LastSeen)) && name != ct.Name {
+		ct.Name = name
+		c.dirty = true
+	}
+	if date.After(ct.LastSeen) {
+		ct.LastSeen = date
+		c.dirty = true
+	}
+	if msgID != "" && !slices.Contains(ct.Messages, msgID) {
+		ct.Messages = append(ct.Messages, msgID)
+		if len(ct.Messages) > maxContactMessages {
+			ct.Messages = ct.Messages[len(ct.Messages)-maxContactMessages:]
+		}
+		c.dirty = true
+	}
+}
+
+// Suggest returns up to n contacts whose address or name starts with prefix,
+// ignoring case, best first: those seen on more messages and more recently
+// rank higher.
+func (c *Contacts) Suggest(prefix string, n int) []Contact {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if c == nil || prefix == "" {
+		return nil
+	}
+	now := time.Now()
+	var out []Contact
+	for _, ct := range c.contacts {
+		if ct.matches(prefix) {
+			out = append(out, *ct)
+		}
+	}
+	slices.SortFunc(out, func(a, b Contact) int {
+		if d := cmp.Compare(b.score(now), a.score(now)); d != 0 {
+			return d
+		}
+		return cmp.Compare(a.Address, b.Address)
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// Save writes the contacts with 0600 permissions if they changed since they
+// were loaded or last saved.
+func (c *Contacts) Save() error {
+	if c == nil || !c.dirty {
+		return nil
+	}
+	b, err := json.MarshalIndent(c.contacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, b, 0600); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
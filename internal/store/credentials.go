@@ -0,0 +1,30 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// CredentialsPath returns where the setup wizard keeps the OAuth client file,
+// ~/.gmail-tui/credentials.json. It is used when the configured credentials
+// file doesn't exist.
+func CredentialsPath() (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "credentials.json"), nil
+}
+
+// SaveCredentials writes an OAuth client file to CredentialsPath with 0600
+// permissions, replacing any previous one, and returns its path.
+func SaveCredentials(b []byte) (string, error) {
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
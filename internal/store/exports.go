@@ -0,0 +1,67 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ExportPath returns a path under ~/.gmail-tui/exports/ for an exported file,
+// creating the directory with 0700 permissions. name is reduced to characters
+// that are safe in file names on every platform and shortened if needed.
+func ExportPath(name, ext string) (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "exports")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, safeName(name, "message")+ext), nil
+}
+
+// DownloadPath returns a path under ~/.gmail-tui/downloads/ for a downloaded
+// attachment named name, creating the directory with 0700 permissions. The
+// name is made safe as for ExportPath, and a number is added before the
+// extension if a file with that name already exists.
+func DownloadPath(name string) (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "downloads")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(name)
+	stem := safeName(strings.TrimSuffix(name, ext), "attachment")
+	ext = safeName(ext, "")
+	if ext != "" {
+		ext = "." + ext
+	}
+	path := filepath.Join(dir, stem+ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path, nil
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s-%d%s", stem, i, ext))
+	}
+}
+
+// safeName reduces name to characters that are safe in file names on every
+// platform, shortened if needed, or returns fallback if nothing is left.
+func safeName(name, fallback string) string {
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_.")
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	if name == "" {
+		return fallback
+	}
+	return name
+}
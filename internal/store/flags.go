@@ -0,0 +1,62 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Flags holds local flags on messages, keyed by message ID, in
+// ~/.gmail-tui/flags.json. They never leave this machine, so they need no
+// Gmail scope. A nil *Flags has no flags and can't set any.
+type Flags struct {
+	path  string
+	flags map[string]string
+}
+
+// LoadFlags reads the flags file, starting empty if there isn't one yet.
+func LoadFlags() (*Flags, error) {
+	base, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	f := &Flags{path: filepath.Join(base, "flags.json"), flags: map[string]string{}}
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &f.flags); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Get returns the flag on the message with the given ID, or "" if it has none.
+func (f *Flags) Get(id string) string {
+	if f == nil {
+		return ""
+	}
+	return f.flags[id]
+}
+
+// Set flags the message with the given ID, or clears its flag when flag is
+// empty, and saves the file with 0600 permissions.
+func (f *Flags) Set(id, flag string) error {
+	if f == nil {
+		return errors.New("local flags are unavailable")
+	}
+	if flag == "" {
+		delete(f.flags, id)
+	} else {
+		f.flags[id] = flag
+	}
+	b, err := json.MarshalIndent(f.flags, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, b, 0600)
+}
@@ -0,0 +1,13 @@
+package store
+
+import "path/filepath"
+
+// TemplatesDir returns the directory compose templates are read from,
+// ~/.gmail-tui/templates. It is not created; templates are optional.
+func TemplatesDir() (string, error) {
+	base, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "templates"), nil
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 
@@ -10,24 +11,44 @@ import (
 
 // TokenStore manages persistent storage of OAuth2 tokens on disk.
 // Tokens are saved in the user's home directory at ~/.gmail-tui/token.json
+// It also holds transient login state, such as a pending device authorization,
+// in ~/.gmail-tui/pending_login.json so an interrupted login can be resumed.
 type TokenStore struct {
-	path string
+	path        string
+	pendingPath string
 }
 
-// NewTokenStore creates a new TokenStore instance and ensures the storage
-// directory exists. The directory is created with 0700 permissions (user-only access)
-// for security. Returns an error if the home directory cannot be determined or
-// the .gmail-tui directory cannot be created.
-func NewTokenStore() (*TokenStore, error) {
+// Dir returns the application's data directory (~/.gmail-tui), creating it
+// with 0700 permissions (user-only access) if it doesn't exist yet.
+func Dir() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	dir := filepath.Join(home, ".gmail-tui")
 	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// NewTokenStore creates a new TokenStore instance and ensures the storage
+// directory exists. Returns an error if the home directory cannot be determined or
+// the .gmail-tui directory cannot be created.
+func NewTokenStore() (*TokenStore, error) {
+	dir, err := Dir()
+	if err != nil {
 		return nil, err
 	}
-	return &TokenStore{path: filepath.Join(dir, "token.json")}, nil
+	return &TokenStore{
+		path:        filepath.Join(dir, "token.json"),
+		pendingPath: filepath.Join(dir, "pending_login.json"),
+	}, nil
+}
+
+// Path returns the location of the token file.
+func (s *TokenStore) Path() string {
+	return s.path
 }
 
 // Load reads and deserializes an OAuth2 token from disk.
@@ -48,10 +69,79 @@ func (s *TokenStore) Load() (*oauth2.Token, error) {
 // Save serializes and writes an OAuth2 token to disk with 0600 permissions
 // (user read/write only) for security. This allows the token to persist across
 // application restarts so the user doesn't need to re-authenticate each time.
+// The file is replaced atomically, so a crash mid-write leaves the previous
+// token in place rather than a truncated one.
 func (s *TokenStore) Save(t *oauth2.Token) error {
 	b, err := json.MarshalIndent(t, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.path, b, 0600)
+	return WriteFileAtomic(s.path, b, 0600)
+}
+
+// WriteFileAtomic writes b to a temporary file next to path and renames it
+// over path, so readers see either the old contents or all of the new ones.
+func WriteFileAtomic(path string, b []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+	}
+	return err
+}
+
+// SavePending serializes an in-progress login (e.g. a device code) to disk with
+// 0600 permissions so it can be resumed if the app is closed before it completes.
+func (s *TokenStore) SavePending(v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(s.pendingPath, b, 0600)
+}
+
+// LoadPending reads a previously saved in-progress login into v.
+// Returns an error if there is no pending login or it cannot be parsed.
+func (s *TokenStore) LoadPending(v any) error {
+	b, err := os.ReadFile(s.pendingPath)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Delete removes the saved token, so the next start needs a new login. A
+// missing file is not an error.
+func (s *TokenStore) Delete() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// ClearPending removes any saved in-progress login. It is called once a token is
+// obtained or the pending login expires. A missing file is not an error.
+func (s *TokenStore) ClearPending() error {
+	err := os.Remove(s.pendingPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
 }
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tempStore returns a token store in a temporary directory.
+func tempStore(t *testing.T) *TokenStore {
+	t.Helper()
+	dir := t.TempDir()
+	return &TokenStore{
+		path:        filepath.Join(dir, "token.json"),
+		pendingPath: filepath.Join(dir, "pending_login.json"),
+	}
+}
+
+// dirEntries returns the names of the files in dir.
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomicCleansUpOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	// Renaming a file over a non-empty directory fails after the temporary
+	// file has been written.
+	path := filepath.Join(dir, "token.json")
+	if err := os.MkdirAll(filepath.Join(path, "keep"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte(`{"access_token":"new"}`), 0600); err == nil {
+		t.Fatal("WriteFileAtomic() error = nil, want the rename to fail")
+	}
+	if names := dirEntries(t, dir); len(names) != 1 || names[0] != "token.json" {
+		t.Errorf("directory holds %v, want only token.json and no temporary file", names)
+	}
+}
+
+func TestSaveNeverExposesPartialToken(t *testing.T) {
+	s := tempStore(t)
+	if err := s.Save(&oauth2.Token{AccessToken: "first"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Large tokens make a non-atomic write take long enough to be caught
+	// half done.
+	done := make(chan error)
+	go func() {
+		for i := range 200 {
+			tok := &oauth2.Token{AccessToken: strings.Repeat(string(rune('a'+i%26)), 64<<10)}
+			if err := s.Save(tok); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if names := dirEntries(t, filepath.Dir(s.path)); len(names) != 1 {
+				t.Errorf("directory holds %v after saving, want only token.json", names)
+			}
+			return
+		default:
+		}
+		tok, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load() during saves error = %v, want a whole token", err)
+		}
+		if a := tok.AccessToken; a != "first" && strings.Count(a, a[:1]) != len(a) {
+			t.Fatalf("Load() during saves returned a mixed token of %d bytes", len(a))
+		}
+	}
+}
+
+func TestSavePendingIsAtomic(t *testing.T) {
+	s := tempStore(t)
+	if err := s.SavePending(map[string]string{"device_code": "abc"}); err != nil {
+		t.Fatalf("SavePending() error = %v", err)
+	}
+	var got map[string]string
+	if err := s.LoadPending(&got); err != nil || got["device_code"] != "abc" {
+		t.Errorf("LoadPending() = %v, %v, want the saved code", got, err)
+	}
+	if names := dirEntries(t, filepath.Dir(s.path)); len(names) != 1 {
+		t.Errorf("directory holds %v, want only pending_login.json", names)
+	}
+}
+
+func TestPreviousTokenSurvivesInterruptedSave(t *testing.T) {
+	s := tempStore(t)
+	prev := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", TokenType: "Bearer"}
+	if err := s.Save(prev); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// A crash between writing and renaming leaves a truncated temporary
+	// file next to the token.
+	f, err := os.CreateTemp(filepath.Dir(s.path), ".token.json.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"access_token":"half-writ`)
+	_ = f.Close()
+
+	// A save that fails before anything is renamed: Expiry can't be
+	// marshalled outside years 0-9999.
+	bad := &oauth2.Token{AccessToken: "new", Expiry: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := s.Save(bad); err == nil {
+		t.Fatal("Save() error = nil, want the marshalling to fail")
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want the previous token", err)
+	}
+	if got.AccessToken != prev.AccessToken || got.RefreshToken != prev.RefreshToken || got.TokenType != prev.TokenType {
+		t.Errorf("Load() = %+v, want the previous token %+v", got, prev)
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file permissions = %v, want 0600", perm)
+	}
+
+	// The next save still replaces the token, with the same permissions.
+	if err := s.Save(&oauth2.Token{AccessToken: "next", RefreshToken: "refresh"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if got, err := s.Load(); err != nil || got.AccessToken != "next" {
+		t.Errorf("Load() after saving again = %v, %v, want the new token", got, err)
+	}
+	if info, err := os.Stat(s.path); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token file permissions after saving again = %v, want 0600", perm)
+	}
+}